@@ -0,0 +1,211 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// LoadManyOptions configures how Client.LoadMany loads players.
+type LoadManyOptions struct {
+	// The number of workers that are to be spawned for loading players in parallel. Defaults to the number of
+	// available CPUs.
+	NumWorkers int
+
+	// Max amount of time loading a single player may take across all of its attempts. Zero disables the timeout.
+	Timeout time.Duration
+
+	// Max number of times loading a player is retried before it is marked to have failed. Only failures deemed
+	// retryable by IsRetryable are retried.
+	MaxRetries int
+
+	// Max number of attempts at loading a player made per second across all workers. Zero disables rate limiting.
+	PlayersPerSecond float64
+
+	// Decide whether or not results are sent in the same order as the ids they were loaded for. Otherwise, results
+	// are sent as soon as they are available. Workers never load more than twice as many players ahead of the next
+	// result to be sent as there are workers, which bounds the number of results held back for reordering.
+	Ordered bool
+}
+
+// LoadResult is the result of loading the player of a single stream.
+type LoadResult struct {
+	ID     StreamID
+	Player Player
+	Err    error
+}
+
+// LoadMany loads the players of ids in parallel, and sends a result for each id over the channel returned. The channel
+// is closed once a result has been sent for every id.
+//
+// Should ctx be done, no further players are loaded and the channel is closed without sending results for the ids
+// that remain. The channel must either be drained or ctx be cancelled for all workers to exit.
+func (c *Client) LoadMany(ctx context.Context, ids []StreamID, opts LoadManyOptions) <-chan LoadResult {
+	workers := opts.NumWorkers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(ids) {
+		workers = len(ids)
+	}
+
+	var limiter *bucket
+	if opts.PlayersPerSecond > 0 {
+		limiter = newBucket(opts.PlayersPerSecond, 1)
+	}
+
+	type indexedResult struct {
+		index int
+		LoadResult
+	}
+
+	jobs := make(chan int, workers)
+	results := make(chan indexedResult, workers)
+
+	// Should results be ordered, a slot in the window is taken for every id fed to workers, and given back once its
+	// result is sent.
+
+	var window chan struct{}
+	if opts.Ordered {
+		window = make(chan struct{}, 2*workers)
+	}
+
+	var wg sync.WaitGroup
+
+	// Spawn workers that will load players.
+
+	wg.Add(workers)
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+
+			for index := range jobs {
+				id := ids[index]
+				player, err := c.loadWithRetries(ctx, id, opts, limiter)
+
+				select {
+				case results <- indexedResult{index: index, LoadResult: LoadResult{ID: id, Player: player, Err: err}}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	// Feed workers the ids to load.
+
+	go func() {
+		defer close(jobs)
+
+		for index := range ids {
+			if window != nil {
+				select {
+				case window <- struct{}{}:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case jobs <- index:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Forward results, reordering them should they be requested in order.
+
+	out := make(chan LoadResult, workers)
+
+	go func() {
+		defer close(out)
+
+		pending := make(map[int]LoadResult)
+		next := 0
+
+		for r := range results {
+			if !opts.Ordered {
+				select {
+				case out <- r.LoadResult:
+				case <-ctx.Done():
+				}
+				continue
+			}
+
+			pending[r.index] = r.LoadResult
+
+			for {
+				result, ok := pending[next]
+				if !ok {
+					break
+				}
+
+				delete(pending, next)
+				next++
+
+				select {
+				case out <- result:
+				case <-ctx.Done():
+				}
+
+				<-window
+			}
+		}
+	}()
+
+	return out
+}
+
+// loadWithRetries loads the player of id, retrying with the same exponential backoff as RetryingTransport should
+// loading fail with a retryable error. Loading is never retried should YouTube be rate limiting us, or be asking for
+// consent to its use of cookies, as retrying would only make matters worse.
+func (c *Client) loadWithRetries(ctx context.Context, id StreamID, opts LoadManyOptions, limiter *bucket) (Player, error) {
+	if err := id.Valid(); err != nil {
+		return Player{}, err
+	}
+
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	deadline, _ := ctx.Deadline()
+
+	backoff := NewRetryingTransport(c.Transport)
+
+	for attempt := 0; ; attempt++ {
+		if limiter != nil {
+			if err := limiter.take(ctx, 1); err != nil {
+				return Player{}, err
+			}
+		}
+
+		if err := ctx.Err(); err != nil {
+			return Player{}, err
+		}
+
+		player, err := c.LoadDeadline(id, deadline)
+		if err == nil || attempt >= opts.MaxRetries {
+			return player, err
+		}
+
+		if errors.Is(err, ErrRateLimited) || errors.Is(err, ErrConsentRequired) || !IsRetryable(err) {
+			return player, err
+		}
+
+		if err := sleep(ctx, backoff.delay(attempt+1, err)); err != nil {
+			return player, err
+		}
+	}
+}
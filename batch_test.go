@@ -0,0 +1,174 @@
+package youtube
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// stutteringTransport fails the first request made to every url, and delegates all other requests.
+type stutteringTransport struct {
+	Transport
+
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+func (t *stutteringTransport) DownloadBytesDeadline(dst []byte, url string, deadline time.Time) ([]byte, error) {
+	t.mu.Lock()
+	seen := t.seen[url]
+	t.seen[url] = true
+	t.mu.Unlock()
+
+	if !seen {
+		return dst, fmt.Errorf("read tcp: %w", syscall.ECONNRESET)
+	}
+
+	return t.Transport.DownloadBytesDeadline(dst, url, deadline)
+}
+
+// gatedTransport holds back requests to urls containing gated, if set, until gate is closed, and counts all requests
+// made.
+type gatedTransport struct {
+	Transport
+
+	gated    string
+	gate     chan struct{}
+	requests int32
+}
+
+func (t *gatedTransport) DownloadBytesDeadline(dst []byte, url string, deadline time.Time) ([]byte, error) {
+	atomic.AddInt32(&t.requests, 1)
+
+	if t.gated != "" && strings.Contains(url, t.gated) {
+		<-t.gate
+	}
+
+	return t.Transport.DownloadBytesDeadline(dst, url, deadline)
+}
+
+func TestLoadManyOrderedWindow(t *testing.T) {
+	response, err := ioutil.ReadFile("testdata/player_response.json")
+	require.NoError(t, err)
+
+	page := newTestWatchPage(t, response)
+
+	ids := make([]StreamID, 32)
+	pages := make(map[StreamID][]byte)
+
+	for i := range ids {
+		ids[i] = StreamID(fmt.Sprintf("aaaaaaaaa%02d", i))
+		pages[ids[i]] = page
+	}
+
+	transport := &gatedTransport{Transport: watchTransport{pages: pages}, gated: string(ids[0]), gate: make(chan struct{})}
+	client := WrapClient(transport)
+
+	results := client.LoadMany(context.Background(), ids, LoadManyOptions{NumWorkers: 2, Ordered: true})
+
+	// While the first player is held back, workers may only load up to twice as many players as there are workers.
+
+	time.Sleep(100 * time.Millisecond)
+	require.EqualValues(t, 4, atomic.LoadInt32(&transport.requests))
+
+	close(transport.gate)
+
+	i := 0
+	for r := range results {
+		require.NoError(t, r.Err)
+		require.Equal(t, ids[i], r.ID)
+		i++
+	}
+
+	require.Len(t, ids, i)
+}
+
+func TestLoadMany(t *testing.T) {
+	response, err := ioutil.ReadFile("testdata/player_response.json")
+	require.NoError(t, err)
+
+	page := newTestWatchPage(t, response)
+
+	ids := []StreamID{"aaaaaaaaaaa", "bbbbbbbbbbb", "ccccccccccc", "ddddddddddd", "eeeeeeeeeee", "fffffffffff"}
+
+	pages := make(map[StreamID][]byte)
+	for _, id := range ids {
+		pages[id] = page
+	}
+
+	ids = append(ids, "zzzzzzzzzzz", "invalid")
+
+	client := WrapClient(watchTransport{pages: pages})
+
+	var results []LoadResult
+	for r := range client.LoadMany(context.Background(), ids, LoadManyOptions{NumWorkers: 3, Ordered: true}) {
+		results = append(results, r)
+	}
+
+	require.Len(t, results, len(ids))
+
+	for i, r := range results {
+		require.Equal(t, ids[i], r.ID)
+
+		if i < len(pages) {
+			require.NoError(t, r.Err)
+			require.Equal(t, "The Glitch Mob - Animus Vox", r.Player.Title())
+		} else {
+			require.Error(t, r.Err)
+		}
+	}
+
+	// Every first attempt at loading a player fails, and is retried.
+
+	client = WrapClient(&stutteringTransport{Transport: watchTransport{pages: pages}, seen: make(map[string]bool)})
+
+	loaded := make(map[StreamID]bool)
+	for r := range client.LoadMany(context.Background(), ids[:len(pages)], LoadManyOptions{MaxRetries: 1}) {
+		require.NoError(t, r.Err)
+		loaded[r.ID] = true
+	}
+
+	require.Len(t, loaded, len(pages))
+
+	// Loading is not retried should YouTube be rate limiting us, or should loading fail with a fatal error.
+
+	sorry := []byte(`<html><body>Our systems have detected unusual traffic from your computer network.</body></html>`)
+
+	for _, test := range []struct {
+		inner    Transport
+		requests int32
+	}{
+		{inner: watchTransport{pages: map[StreamID][]byte{ids[0]: sorry}}, requests: 1},
+		{inner: &scriptedTransport{errs: []error{&StatusError{StatusCode: http.StatusNotFound}}}, requests: 3},
+	} {
+		transport := &gatedTransport{Transport: test.inner}
+		client = WrapClient(transport)
+
+		for r := range client.LoadMany(context.Background(), ids[:1], LoadManyOptions{MaxRetries: 3}) {
+			require.Error(t, r.Err)
+		}
+
+		require.Equal(t, test.requests, atomic.LoadInt32(&transport.requests))
+	}
+
+	// Loading stops once the context is cancelled.
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	n := 0
+	for range client.LoadMany(ctx, ids, LoadManyOptions{NumWorkers: 1, PlayersPerSecond: 1}) {
+		cancel()
+		n++
+	}
+
+	require.Less(t, n, len(ids))
+}
@@ -0,0 +1,294 @@
+// Package bmff reads and writes boxes of the ISO base media file format (ISO/IEC 14496-12), which MP4 and M4A files
+// are comprised of.
+package bmff
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Containers lists the types of boxes whose payloads consist of child boxes. The payload of a 'meta' box is prefixed
+// with a 4-byte full box header, which is kept in the box's Data.
+var Containers = map[string]bool{
+	"moov": true,
+	"trak": true,
+	"mdia": true,
+	"minf": true,
+	"stbl": true,
+	"mvex": true,
+	"edts": true,
+	"dinf": true,
+	"moof": true,
+	"traf": true,
+	"mfra": true,
+	"udta": true,
+	"meta": true,
+	"ilst": true,
+}
+
+// Box is a box. Leaf boxes hold their payload in Data. Container boxes hold their children in Children, and any
+// bytes preceding their children in Data.
+type Box struct {
+	Type     string
+	Data     []byte
+	Children []*Box
+}
+
+// New instantiates a new box of type typ.
+func New(typ string, data []byte, children ...*Box) *Box {
+	return &Box{Type: typ, Data: data, Children: children}
+}
+
+// Size returns the size of the box in bytes once encoded, including its header.
+func (b *Box) Size() uint64 {
+	size := uint64(len(b.Data))
+	for _, child := range b.Children {
+		size += child.Size()
+	}
+
+	if size+8 > math.MaxUint32 {
+		return size + 16
+	}
+
+	return size + 8
+}
+
+// Child returns the first child of the box of type typ, or nil if there is none.
+func (b *Box) Child(typ string) *Box {
+	for _, child := range b.Children {
+		if child.Type == typ {
+			return child
+		}
+	}
+	return nil
+}
+
+// Find walks down the children of the box following the given path of box types, returning nil if the path does
+// not exist.
+func (b *Box) Find(path ...string) *Box {
+	for _, typ := range path {
+		if b = b.Child(typ); b == nil {
+			return nil
+		}
+	}
+	return b
+}
+
+// All returns all children of the box of type typ.
+func (b *Box) All(typ string) []*Box {
+	var boxes []*Box
+	for _, child := range b.Children {
+		if child.Type == typ {
+			boxes = append(boxes, child)
+		}
+	}
+	return boxes
+}
+
+// Remove removes all children of the box of type typ.
+func (b *Box) Remove(typ string) {
+	children := b.Children[:0]
+	for _, child := range b.Children {
+		if child.Type != typ {
+			children = append(children, child)
+		}
+	}
+	b.Children = children
+}
+
+// AppendTo appends the encoded box to dst.
+func (b *Box) AppendTo(dst []byte) []byte {
+	dst = AppendHeader(dst, b.Type, b.Size())
+	dst = append(dst, b.Data...)
+	for _, child := range b.Children {
+		dst = child.AppendTo(dst)
+	}
+	return dst
+}
+
+// WriteTo writes the encoded box to w.
+func (b *Box) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(b.AppendTo(nil))
+	return int64(n), err
+}
+
+// Version returns the version of a full box.
+func (b *Box) Version() uint8 {
+	if len(b.Data) < 4 {
+		return 0
+	}
+	return b.Data[0]
+}
+
+// Flags returns the flags of a full box.
+func (b *Box) Flags() uint32 {
+	if len(b.Data) < 4 {
+		return 0
+	}
+	return binary.BigEndian.Uint32(b.Data[0:4]) & 0xffffff
+}
+
+// AppendHeader appends the header of a box of type typ comprised of size bytes, including its header, to dst.
+func AppendHeader(dst []byte, typ string, size uint64) []byte {
+	if size > math.MaxUint32 {
+		dst = append(dst, 0, 0, 0, 1)
+		dst = append(dst, typ...)
+		return appendUint64(dst, size)
+	}
+
+	dst = appendUint32(dst, uint32(size))
+	return append(dst, typ...)
+}
+
+// HeaderSize returns the size of the header of a box comprised of size bytes, including its header.
+func HeaderSize(size uint64) uint64 {
+	if size > math.MaxUint32 {
+		return 16
+	}
+	return 8
+}
+
+// ReadHeader reads the header of a box from r, returning the box's type, the size of its payload, and the size of
+// its header. A box whose size is unspecified extends to the end of the file, and has a payload size of
+// math.MaxUint64.
+func ReadHeader(r io.Reader) (typ string, size uint64, n int, err error) {
+	var buf [16]byte
+
+	if _, err := io.ReadFull(r, buf[:8]); err != nil {
+		return "", 0, 0, err
+	}
+
+	typ = string(buf[4:8])
+	size = uint64(binary.BigEndian.Uint32(buf[0:4]))
+	n = 8
+
+	switch size {
+	case 0:
+		return typ, math.MaxUint64, n, nil
+	case 1:
+		if _, err := io.ReadFull(r, buf[8:16]); err != nil {
+			return "", 0, 0, unexpected(err)
+		}
+		size = binary.BigEndian.Uint64(buf[8:16])
+		n = 16
+	}
+
+	if size < uint64(n) {
+		return "", 0, 0, fmt.Errorf("box %q has invalid size %d", typ, size)
+	}
+
+	return typ, size - uint64(n), n, nil
+}
+
+// Read reads an entire box, including all of its children, from r. The payload of the box may be at most max bytes.
+func Read(r io.Reader, max uint64) (*Box, error) {
+	typ, size, _, err := ReadHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if size > max {
+		return nil, fmt.Errorf("box %q of %d byte(s) exceeds limit of %d byte(s)", typ, size, max)
+	}
+
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, unexpected(err)
+	}
+
+	return ParsePayload(typ, buf)
+}
+
+// Parse parses the box at the start of buf, returning the box and its total size in bytes.
+func Parse(buf []byte) (*Box, int, error) {
+	if len(buf) < 8 {
+		return nil, 0, errors.New("box header is truncated")
+	}
+
+	typ := string(buf[4:8])
+	size := uint64(binary.BigEndian.Uint32(buf[0:4]))
+	n := uint64(8)
+
+	switch size {
+	case 0:
+		size = uint64(len(buf))
+	case 1:
+		if len(buf) < 16 {
+			return nil, 0, errors.New("box header is truncated")
+		}
+		size = binary.BigEndian.Uint64(buf[8:16])
+		n = 16
+	}
+
+	if size < n || size > uint64(len(buf)) {
+		return nil, 0, fmt.Errorf("box %q of %d byte(s) is truncated", typ, size)
+	}
+
+	box, err := ParsePayload(typ, buf[n:size])
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return box, int(size), nil
+}
+
+// ParseAll parses buf as a sequence of boxes.
+func ParseAll(buf []byte) ([]*Box, error) {
+	var boxes []*Box
+
+	for len(buf) > 0 {
+		box, n, err := Parse(buf)
+		if err != nil {
+			return nil, err
+		}
+		boxes = append(boxes, box)
+		buf = buf[n:]
+	}
+
+	return boxes, nil
+}
+
+// ParsePayload parses buf as the payload of a box of type typ. The box references buf rather than copying it.
+func ParsePayload(typ string, buf []byte) (*Box, error) {
+	if !Containers[typ] {
+		return &Box{Type: typ, Data: buf}, nil
+	}
+
+	box := &Box{Type: typ}
+
+	// A 'meta' box is a full box in ISO/IEC 14496-12, but not in QuickTime. Tell them apart by checking whether or
+	// not its payload immediately starts with a child box.
+
+	if typ == "meta" && !(len(buf) >= 8 && binary.BigEndian.Uint32(buf) >= 8 && string(buf[4:8]) == "hdlr") {
+		if len(buf) < 4 {
+			return nil, errors.New("meta box is truncated")
+		}
+		box.Data, buf = buf[:4], buf[4:]
+	}
+
+	children, err := ParseAll(buf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse children of box %q: %w", typ, err)
+	}
+	box.Children = children
+
+	return box, nil
+}
+
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func appendUint32(dst []byte, v uint32) []byte {
+	return append(dst, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+func appendUint64(dst []byte, v uint64) []byte {
+	return appendUint32(appendUint32(dst, uint32(v>>32)), uint32(v))
+}
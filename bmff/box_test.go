@@ -0,0 +1,63 @@
+package bmff
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/require"
+	"math"
+	"testing"
+)
+
+func TestBoxRoundTrip(t *testing.T) {
+	moov := New("moov", nil,
+		New("mvhd", []byte{0, 0, 0, 0, 1, 2, 3, 4}),
+		New("udta", nil,
+			New("meta", []byte{0, 0, 0, 0}, New("hdlr", []byte("mdirappl")), New("ilst", nil)),
+		),
+	)
+
+	buf := moov.AppendTo(nil)
+	require.EqualValues(t, len(buf), moov.Size())
+
+	parsed, n, err := Parse(buf)
+	require.NoError(t, err)
+	require.Equal(t, len(buf), n)
+	require.Equal(t, buf, parsed.AppendTo(nil))
+
+	require.NotNil(t, parsed.Find("udta", "meta", "ilst"))
+	require.Equal(t, []byte{0, 0, 0, 0}, parsed.Find("udta", "meta").Data)
+	require.Nil(t, parsed.Find("udta", "ilst"))
+
+	read, err := Read(bytes.NewReader(buf), math.MaxUint32)
+	require.NoError(t, err)
+	require.Equal(t, buf, read.AppendTo(nil))
+
+	_, err = Read(bytes.NewReader(buf), 8)
+	require.Error(t, err)
+
+	_, _, err = Parse(buf[:len(buf)-1])
+	require.Error(t, err)
+}
+
+func TestQuickTimeMeta(t *testing.T) {
+	meta := New("meta", nil, New("hdlr", []byte("mdirappl")))
+
+	parsed, _, err := Parse(meta.AppendTo(nil))
+	require.NoError(t, err)
+	require.Empty(t, parsed.Data)
+	require.Len(t, parsed.Children, 1)
+}
+
+func TestReadHeader(t *testing.T) {
+	typ, size, n, err := ReadHeader(bytes.NewReader(AppendHeader(nil, "mdat", math.MaxUint32+16)))
+	require.NoError(t, err)
+	require.Equal(t, "mdat", typ)
+	require.EqualValues(t, math.MaxUint32, size)
+	require.Equal(t, 16, n)
+
+	_, size, _, err = ReadHeader(bytes.NewReader([]byte("\x00\x00\x00\x00mdat")))
+	require.NoError(t, err)
+	require.EqualValues(t, uint64(math.MaxUint64), size)
+
+	_, _, _, err = ReadHeader(bytes.NewReader([]byte("\x00\x00\x00\x04mdat")))
+	require.Error(t, err)
+}
@@ -0,0 +1,86 @@
+package youtube
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// bucket is a token bucket refilling at a fixed rate of tokens per second up to a maximum capacity. Tokens may be
+// taken in advance, in which case the taker waits until the bucket has refilled enough to pay off its debt.
+type bucket struct {
+	mu       sync.Mutex
+	rate     float64
+	capacity float64
+	tokens   float64
+	last     time.Time
+}
+
+func newBucket(rate, capacity float64) *bucket {
+	return &bucket{rate: rate, capacity: capacity, tokens: capacity, last: time.Now()}
+}
+
+// take takes n tokens from the bucket, blocking until the bucket has refilled enough to afford them or until ctx is
+// done. Should ctx be done first, the tokens are put back into the bucket.
+func (b *bucket) take(ctx context.Context, n float64) error {
+	b.mu.Lock()
+
+	b.refill()
+	b.tokens -= n
+
+	var wait time.Duration
+	if b.tokens < 0 {
+		wait = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+
+	b.mu.Unlock()
+
+	if err := sleep(ctx, wait); err != nil {
+		// The tokens were never spent, so they must not slow down those waiting on the bucket after us.
+
+		b.put(n)
+		return err
+	}
+
+	return nil
+}
+
+// put puts n tokens back into the bucket.
+func (b *bucket) put(n float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill()
+	b.tokens += n
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+}
+
+// refill adds the tokens accumulated since the bucket was last refilled. It must be called with b.mu held.
+func (b *bucket) refill() {
+	now := time.Now()
+
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.last = now
+}
+
+// sleep pauses the current goroutine for at least duration d, or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
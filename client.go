@@ -1,6 +1,7 @@
 package youtube
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"github.com/lithdew/bytesutil"
@@ -8,19 +9,52 @@ import (
 	"github.com/valyala/fasthttp"
 	"github.com/valyala/fastjson"
 	"golang.org/x/sync/errgroup"
+	"net/http"
+	"net/http/cookiejar"
 	"net/url"
 	"time"
 )
 
 var zeroTime time.Time
 
+var (
+	// ErrRateLimited is returned when YouTube responds with a 429 status code, or with a page asking to prove that
+	// requests are not automated in place of the page requested.
+	ErrRateLimited = errors.New("rate limited by youtube")
+
+	// ErrConsentRequired is returned when YouTube responds with a page asking for consent to its use of cookies in
+	// place of the page requested. See Client.AcceptConsent.
+	ErrConsentRequired = errors.New("youtube requires consent to the use of cookies")
+)
+
 // Transport represents the transport client used for youtube.Client.
 type Transport interface {
 	DownloadBytesDeadline(dst []byte, url string, deadline time.Time) ([]byte, error)
 }
 
+// StatusError may be returned by a Transport when a server responds with a non-2xx status code.
+type StatusError struct {
+	URL        string
+	StatusCode int
+
+	// How long the server asked for the client to wait before retrying through a 'Retry-After' header. Zero if the
+	// server did not ask.
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("got status code %d downloading %q", e.StatusCode, e.URL)
+}
+
 type Client struct {
 	Transport
+
+	// Header, if non-nil, holds headers sent with every request made by the client, such as 'Accept-Language'.
+	Header http.Header
+
+	// Jar, if non-nil, holds the cookies sent with every request made by the client, and is updated with cookies set
+	// by responses. Cookies may be loaded from a Netscape cookies.txt file using LoadCookiesFile.
+	Jar http.CookieJar
 }
 
 func NewClient() Client {
@@ -32,6 +66,93 @@ func WrapClient(transport Transport) Client {
 	return Client{Transport: transport}
 }
 
+// DownloadBytesDeadline downloads the contents of url through the client's transport, and appends them to dst.
+// Should the client have any headers or a cookie jar, or should its transport be a RoundTripper or Doer such as
+// *nicehttp.Client, the request is sent through the client's transport adapted using AdaptTransport instead, and a
+// *StatusError is returned should the response have a non-2xx status code.
+func (c *Client) DownloadBytesDeadline(dst []byte, url string, deadline time.Time) ([]byte, error) {
+	if c.Header != nil || c.Jar != nil {
+		return roundTripBytesDeadline(c, dst, url, nil, deadline)
+	}
+
+	switch c.Transport.(type) {
+	case RoundTripper, Doer:
+		return roundTripBytesDeadline(AdaptTransport(c.Transport), dst, url, nil, deadline)
+	}
+
+	return c.Transport.DownloadBytesDeadline(dst, url, deadline)
+}
+
+// RoundTripDeadline sends req through the client's transport adapted using AdaptTransport. The client's headers are
+// sent along with req unless req sets them itself, and cookies are sent and stored should the client have a cookie
+// jar.
+func (c *Client) RoundTripDeadline(req *Request, res *Response, deadline time.Time) error {
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return fmt.Errorf("bad url %q: %w", req.URL, err)
+	}
+
+	r := *req
+	r.Header = make(http.Header, len(c.Header)+len(req.Header)+1)
+
+	for key, values := range c.Header {
+		r.Header[key] = values
+	}
+	for key, values := range req.Header {
+		r.Header[key] = values
+	}
+
+	if c.Jar != nil {
+		h := http.Request{Header: r.Header}
+		for _, cookie := range c.Jar.Cookies(u) {
+			h.AddCookie(cookie)
+		}
+	}
+
+	if err := AdaptTransport(c.Transport).RoundTripDeadline(&r, res, deadline); err != nil {
+		return err
+	}
+
+	if c.Jar != nil {
+		if cookies := (&http.Response{Header: res.Header}).Cookies(); len(cookies) > 0 {
+			c.Jar.SetCookies(u, cookies)
+		}
+	}
+
+	return nil
+}
+
+// snapshot returns a copy of the client holding copies of its headers and of its cookies for YouTube, such that the
+// copy is unaffected by later changes to the client.
+func (c *Client) snapshot() *Client {
+	s := &Client{Transport: c.Transport, Header: c.Header.Clone()}
+
+	if c.Jar != nil {
+		u := &url.URL{Scheme: "https", Host: "www.youtube.com"}
+
+		s.Jar, _ = cookiejar.New(nil)
+		s.Jar.SetCookies(u, c.Jar.Cookies(u))
+	}
+
+	return s
+}
+
+// AcceptConsent stores a cookie in the client's cookie jar that consents to YouTube's use of cookies, which stops
+// YouTube from responding with a consent page in the EU. A cookie jar is created should the client not have one.
+func (c *Client) AcceptConsent() {
+	if c.Jar == nil {
+		c.Jar, _ = cookiejar.New(nil)
+	}
+
+	c.Jar.SetCookies(&url.URL{Scheme: "https", Host: "www.youtube.com"}, []*http.Cookie{{
+		Name:    "CONSENT",
+		Value:   "YES+",
+		Path:    "/",
+		Domain:  ".youtube.com",
+		Expires: time.Now().AddDate(1, 0, 0),
+	}})
+}
+
 func (c *Client) Load(id StreamID) (Player, error) {
 	return c.LoadDeadline(id, zeroTime)
 }
@@ -57,6 +178,12 @@ func (c *Client) LoadDeadline(id StreamID, deadline time.Time) (Player, error) {
 		return player, nil
 	}
 
+	// Should YouTube be rate limiting us, loading the embedded player would only make matters worse.
+
+	if errors.Is(err, ErrRateLimited) {
+		return player, fmt.Errorf("failed to load player: %w", err)
+	}
+
 	// If it fails, attempt to grab the embedded player second.
 
 	player, err = c.LoadEmbedPlayerDeadline(id, deadline)
@@ -148,7 +275,7 @@ func (c *Client) LoadWatchPlayerTimeout(id StreamID, timeout time.Duration) (Pla
 }
 
 func (c *Client) LoadWatchPlayerDeadline(id StreamID, deadline time.Time) (Player, error) {
-	player := Player{Transport: c.Transport}
+	player := Player{Transport: c.Transport, loader: c.snapshot()}
 
 	if err := id.Valid(); err != nil {
 		return player, err
@@ -158,11 +285,16 @@ func (c *Client) LoadWatchPlayerDeadline(id StreamID, deadline time.Time) (Playe
 
 	buf, err := c.DownloadBytesDeadline(nil, "https://www.youtube.com/watch?v="+string(id), deadline)
 	if err != nil {
-		return player, err
+		return player, detectRateLimit(err)
 	}
 
+	player.LoadedAt = time.Now()
+
 	matches := RegexWatchPlayerConfig.FindSubmatch(buf)
 	if matches == nil {
+		if err := detectBlockPage(buf); err != nil {
+			return player, err
+		}
 		return player, errors.New("could not find watch video player config in html page")
 	}
 
@@ -180,7 +312,7 @@ func (c *Client) LoadWatchPlayerDeadline(id StreamID, deadline time.Time) (Playe
 		return player, fmt.Errorf("failed to parse json response: %w", err)
 	}
 
-	player.Streams = Streams{v: val}
+	player.Streams = newStreams(id, val)
 
 	if player.Streams.Status() != "OK" {
 		return player, fmt.Errorf("unable to get streaming info for id %q: status is %q (reason: %q)", id, player.Streams.Status(), player.Streams.Reason())
@@ -202,11 +334,14 @@ func (c *Client) LoadEmbedPlayerAssetsDeadline(id StreamID, deadline time.Time)
 
 	buf, err := c.DownloadBytesDeadline(nil, "https://www.youtube.com/embed/"+string(id), deadline)
 	if err != nil {
-		return assets, fmt.Errorf("failed to download html of embed player: %w", err)
+		return assets, fmt.Errorf("failed to download html of embed player: %w", detectRateLimit(err))
 	}
 
 	matches := RegexEmbedPlayerConfig.FindSubmatch(buf)
 	if matches == nil {
+		if err := detectBlockPage(buf); err != nil {
+			return assets, err
+		}
 		return assets, errors.New("could not find embed player config in html page")
 	}
 
@@ -253,7 +388,7 @@ func (c *Client) LoadEmbedPlayerStreamsDeadline(id StreamID, deadline time.Time)
 		return streams, fmt.Errorf("failed to parse json response: %w", err)
 	}
 
-	streams.v = val
+	streams = newStreams(id, val)
 
 	if streams.Status() != "OK" {
 		return streams, fmt.Errorf("unable to get streaming info for id %q: status is %q (reason: %q)", id, streams.Status(), streams.Reason())
@@ -271,7 +406,7 @@ func (c *Client) LoadEmbedPlayerTimeout(id StreamID, timeout time.Duration) (Pla
 }
 
 func (c *Client) LoadEmbedPlayerDeadline(id StreamID, deadline time.Time) (Player, error) {
-	player := Player{Transport: c.Transport}
+	player := Player{Transport: c.Transport, loader: c.snapshot()}
 
 	if err := id.Valid(); err != nil {
 		return player, err
@@ -279,6 +414,8 @@ func (c *Client) LoadEmbedPlayerDeadline(id StreamID, deadline time.Time) (Playe
 
 	var g errgroup.Group
 
+	player.LoadedAt = time.Now()
+
 	// Download embed player HTML.
 
 	g.Go(func() error {
@@ -311,3 +448,42 @@ func (c *Client) LoadEmbedPlayerDeadline(id StreamID, deadline time.Time) (Playe
 
 	return player, nil
 }
+
+var (
+	rateLimitMarkers = [][]byte{
+		[]byte("Our systems have detected unusual traffic"),
+		[]byte("www.google.com/recaptcha"),
+		[]byte("g-recaptcha"),
+		[]byte("/sorry/index"),
+	}
+
+	consentMarkers = [][]byte{
+		[]byte("consent.youtube.com"),
+		[]byte("consent.google.com"),
+	}
+)
+
+// detectRateLimit wraps err with ErrRateLimited should err be a *StatusError reporting a 429 status code.
+func detectRateLimit(err error) error {
+	var status *StatusError
+	if errors.As(err, &status) && status.StatusCode == http.StatusTooManyRequests {
+		return fmt.Errorf("%w: %v", ErrRateLimited, err)
+	}
+	return err
+}
+
+// detectBlockPage returns ErrRateLimited should page be one asking to prove that requests are not automated, or
+// ErrConsentRequired should page be one asking for consent to the use of cookies. It returns nil otherwise.
+func detectBlockPage(page []byte) error {
+	for _, marker := range rateLimitMarkers {
+		if bytes.Contains(page, marker) {
+			return ErrRateLimited
+		}
+	}
+	for _, marker := range consentMarkers {
+		if bytes.Contains(page, marker) {
+			return ErrConsentRequired
+		}
+	}
+	return nil
+}
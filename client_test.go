@@ -1,11 +1,22 @@
 package youtube
 
 import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/lithdew/nicehttp"
 	"github.com/stretchr/testify/require"
 	"github.com/valyala/fasthttp"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoadEmbedPlayer(t *testing.T) {
@@ -58,3 +69,102 @@ func BenchmarkURLValues(b *testing.B) {
 		)
 	}
 }
+
+// newTestWatchPage renders a watch page embedding a player config whose player response is the raw JSON response.
+func newTestWatchPage(t testing.TB, response []byte) []byte {
+	args, err := json.Marshal(map[string]string{"player_response": string(response)})
+	require.NoError(t, err)
+
+	config := `{"assets":{"css":"/yts/cssbin/player.css","js":"/yts/jsbin/player/base.js"},"args":` + string(args) + `}`
+
+	return []byte(`<html><body><script>var ytplayer = ytplayer || {};ytplayer.config = ` + config + `;ytplayer.web_player_context_config = {};</script></body></html>`)
+}
+
+// watchTransport serves watch pages of the player responses it holds, and delegates all other requests.
+type watchTransport struct {
+	Transport
+	pages map[StreamID][]byte
+}
+
+func (t watchTransport) DownloadBytesDeadline(dst []byte, uri string, deadline time.Time) ([]byte, error) {
+	if id := StreamID(strings.TrimPrefix(uri, "https://www.youtube.com/watch?v=")); string(id) != uri {
+		page, exists := t.pages[id]
+		if !exists {
+			return dst, fmt.Errorf("no watch page for stream id %q", id)
+		}
+		return append(dst, page...), nil
+	}
+
+	if t.Transport == nil {
+		return dst, fmt.Errorf("unexpected request to %q", uri)
+	}
+
+	return t.Transport.DownloadBytesDeadline(dst, uri, deadline)
+}
+
+func TestLoadWatchPlayerFromPage(t *testing.T) {
+	response, err := ioutil.ReadFile("testdata/player_response.json")
+	require.NoError(t, err)
+
+	client := WrapClient(watchTransport{pages: map[StreamID][]byte{"pAsDzfbLM8Y": newTestWatchPage(t, response)}})
+
+	player, err := client.LoadWatchPlayer("pAsDzfbLM8Y")
+	require.NoError(t, err)
+
+	require.EqualValues(t, "pAsDzfbLM8Y", player.ID())
+	require.Equal(t, "The Glitch Mob - Animus Vox", player.Title())
+	require.Equal(t, "/yts/jsbin/player/base.js", player.Assets.JS)
+	require.Len(t, player.SourceFormats(), 12)
+	require.False(t, player.LoadedAt.IsZero())
+
+	_, err = client.LoadWatchPlayer("aaaaaaaaaaa")
+	require.Error(t, err)
+}
+
+func TestDetectBlockPage(t *testing.T) {
+	sorry := []byte(`<html><body>Our systems have detected unusual traffic from your computer network.<div class="g-recaptcha"></div></body></html>`)
+	consent := []byte(`<html><body><form action="https://consent.youtube.com/s" method="POST"></form></body></html>`)
+
+	client := WrapClient(watchTransport{pages: map[StreamID][]byte{"aaaaaaaaaaa": sorry, "bbbbbbbbbbb": consent}})
+
+	_, err := client.LoadWatchPlayer("aaaaaaaaaaa")
+	require.True(t, errors.Is(err, ErrRateLimited))
+
+	_, err = client.Load("aaaaaaaaaaa")
+	require.True(t, errors.Is(err, ErrRateLimited))
+
+	_, err = client.LoadWatchPlayer("bbbbbbbbbbb")
+	require.True(t, errors.Is(err, ErrConsentRequired))
+
+	client = WrapClient(&scriptedTransport{errs: []error{&StatusError{StatusCode: 429}}})
+
+	_, err = client.Load("ccccccccccc")
+	require.True(t, errors.Is(err, ErrRateLimited))
+
+	require.NoError(t, detectBlockPage([]byte(`<html></html>`)))
+}
+
+func TestDetectRateLimitStatus(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte("<html><body>Too many requests.</body></html>"))
+	}))
+	defer srv.Close()
+
+	// Requests to YouTube are sent to the test server through the default transport, which ignores status codes.
+
+	inner := nicehttp.WrapClient(&fasthttp.Client{
+		Dial:      func(string) (net.Conn, error) { return net.Dial("tcp", srv.Listener.Addr().String()) },
+		TLSConfig: &tls.Config{InsecureSkipVerify: true},
+	})
+
+	for _, transport := range []Transport{&inner, &RateLimitedTransport{Transport: &inner}} {
+		client := WrapClient(transport)
+
+		_, err := client.LoadWatchPlayer("aaaaaaaaaaa")
+		require.True(t, errors.Is(err, ErrRateLimited), err)
+
+		_, err = client.LoadEmbedPlayerAssets("aaaaaaaaaaa")
+		require.True(t, errors.Is(err, ErrRateLimited), err)
+	}
+}
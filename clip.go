@@ -0,0 +1,79 @@
+package youtube
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// DownloadClip writes to w the initialization segment of a fragmented MP4 or WebM format followed by only the media
+// segments overlapping the time window [start, end). A zero end denotes the end of the format. Segments start at
+// keyframes, so the clip written may begin slightly before start and end slightly after end.
+func (p Player) DownloadClip(w io.Writer, f Format, start, end time.Duration) error {
+	return p.DownloadClipDeadline(w, f, start, end, zeroTime)
+}
+
+func (p Player) DownloadClipTimeout(w io.Writer, f Format, start, end time.Duration, timeout time.Duration) error {
+	return p.DownloadClipDeadline(w, f, start, end, time.Now().Add(timeout))
+}
+
+func (p Player) DownloadClipDeadline(w io.Writer, f Format, start, end time.Duration, deadline time.Time) error {
+	url, err := p.ResolveURLDeadline(f, deadline)
+	if err != nil {
+		return err
+	}
+
+	init, segments, err := p.loadSegmentsDeadline(f, url, deadline)
+	if err != nil {
+		return err
+	}
+
+	segments = SegmentsWithin(segments, start, end)
+	if len(segments) == 0 {
+		return fmt.Errorf("no segments overlap the time window [%s, %s)", start, end)
+	}
+
+	if _, err := w.Write(init); err != nil {
+		return err
+	}
+
+	var buf []byte
+
+	for _, s := range segments {
+		buf, err = downloadRangeDeadline(p.Transport, buf[:0], url, s.Range(), deadline)
+		if err != nil {
+			return fmt.Errorf("failed to download segment at %s: %w", s.Start, err)
+		}
+
+		if _, err := w.Write(buf); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// downloadRangeDeadline appends byte range r of the media at url to dst. It makes use of the 'range' query parameter
+// supported by YouTube's video servers.
+func downloadRangeDeadline(t Transport, dst []byte, url string, r ByteRange, deadline time.Time) ([]byte, error) {
+	sep := "?"
+	if strings.Contains(url, "?") {
+		sep = "&"
+	}
+
+	url += sep + "range=" + r.String()
+
+	n := len(dst)
+
+	dst, err := roundTripBytesDeadline(AdaptTransport(t), dst, url, nil, deadline)
+	if err != nil {
+		return dst, err
+	}
+
+	if got, expected := uint64(len(dst)-n), r.Len(); got != expected {
+		return dst, fmt.Errorf("expected %d byte(s) in range %s, but got %d byte(s)", expected, r, got)
+	}
+
+	return dst, nil
+}
@@ -0,0 +1,180 @@
+package youtube
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"github.com/lithdew/youtube/ebml"
+	"github.com/stretchr/testify/require"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rangeTransport serves byte ranges of data requested through the 'range' query parameter.
+type rangeTransport struct {
+	data []byte
+}
+
+func (t rangeTransport) DownloadBytesDeadline(dst []byte, uri string, _ time.Time) ([]byte, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return dst, err
+	}
+
+	r := u.Query().Get("range")
+	if r == "" {
+		return append(dst, t.data...), nil
+	}
+
+	bounds := strings.SplitN(r, "-", 2)
+
+	start, err := strconv.Atoi(bounds[0])
+	if err != nil {
+		return dst, err
+	}
+
+	end, err := strconv.Atoi(bounds[1])
+	if err != nil {
+		return dst, err
+	}
+
+	if start > end || end >= len(t.data) {
+		return dst, fmt.Errorf("range %q is not satisfiable", r)
+	}
+
+	return append(dst, t.data[start:end+1]...), nil
+}
+
+func appendSIDX(dst []byte, timescale uint32, first uint32, sizes []uint32, durations []uint32) []byte {
+	body := []byte{0, 0, 0, 0, 0, 0, 0, 1}
+	body = append(body, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(body[8:], timescale)
+	body = append(body, 0, 0, 0, 0)
+	body = append(body, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(body[16:], first)
+	body = append(body, 0, 0, byte(len(sizes)>>8), byte(len(sizes)))
+
+	for i := range sizes {
+		entry := make([]byte, 12)
+		binary.BigEndian.PutUint32(entry[0:], sizes[i])
+		binary.BigEndian.PutUint32(entry[4:], durations[i])
+		binary.BigEndian.PutUint32(entry[8:], 0x90000000)
+		body = append(body, entry...)
+	}
+
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header, uint32(8+len(body)))
+	copy(header[4:], "sidx")
+
+	return append(append(dst, header...), body...)
+}
+
+func ebmlElement(id uint32, data ...[]byte) []byte {
+	var body []byte
+	for _, d := range data {
+		body = append(body, d...)
+	}
+
+	var buf []byte
+	for shift := 24; shift >= 0; shift -= 8 {
+		if b := byte(id >> uint(shift)); b != 0 || len(buf) > 0 {
+			buf = append(buf, b)
+		}
+	}
+
+	size := make([]byte, 8)
+	binary.BigEndian.PutUint64(size, uint64(len(body)))
+	size[0] = 0x01
+
+	return append(append(buf, size...), body...)
+}
+
+func ebmlUint(id uint32, v uint64) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, v)
+	return ebmlElement(id, buf)
+}
+
+func TestDownloadClipMP4(t *testing.T) {
+	init := append([]byte("\x00\x00\x00\x10ftypdash\x00\x00\x00\x00"), bytes.Repeat([]byte{'m'}, 24)...)
+
+	sizes := []uint32{100, 200, 300, 400}
+	durations := []uint32{10000, 10000, 10000, 10000}
+
+	index := appendSIDX(nil, 1000, 0, sizes, durations)
+
+	data := append(append([]byte{}, init...), index...)
+	for i, size := range sizes {
+		data = append(data, bytes.Repeat([]byte{byte('a' + i)}, int(size))...)
+	}
+
+	segments, err := ParseSIDX(index, uint64(len(init)))
+	require.NoError(t, err)
+	require.Len(t, segments, 4)
+	require.EqualValues(t, len(init)+len(index)+300, segments[2].Offset)
+	require.Equal(t, 20*time.Second, segments[2].Start)
+	require.Equal(t, 10*time.Second, segments[2].Duration)
+
+	uri := "https://example.com/videoplayback?itag=137"
+
+	f := Format{
+		ITag:          137,
+		MIMEType:      `video/mp4; codecs="avc1.640028"`,
+		URL:           &uri,
+		ContentLength: strconv.Itoa(len(data)),
+		InitRange:     &ByteRange{Start: 0, End: uint64(len(init) - 1)},
+		IndexRange:    &ByteRange{Start: uint64(len(init)), End: uint64(len(init) + len(index) - 1)},
+	}
+
+	player := Player{Transport: rangeTransport{data: data}}
+
+	var buf bytes.Buffer
+	require.NoError(t, player.DownloadClip(&buf, f, 15*time.Second, 25*time.Second))
+
+	expected := append(append([]byte{}, init...), bytes.Repeat([]byte{'b'}, 200)...)
+	expected = append(expected, bytes.Repeat([]byte{'c'}, 300)...)
+	require.Equal(t, expected, buf.Bytes())
+
+	buf.Reset()
+	require.NoError(t, player.DownloadClip(&buf, f, 35*time.Second, 0))
+	require.Equal(t, append(append([]byte{}, init...), bytes.Repeat([]byte{'d'}, 400)...), buf.Bytes())
+
+	require.Error(t, player.DownloadClip(&buf, f, time.Minute, 0))
+}
+
+func TestParseCues(t *testing.T) {
+	duration := make([]byte, 8)
+	binary.BigEndian.PutUint64(duration, math.Float64bits(30000))
+
+	info := ebmlElement(ebml.IDInfo, ebmlUint(ebml.IDTimecodeScale, 1000000), ebmlElement(ebml.IDDuration, duration))
+
+	header := ebmlElement(ebml.IDHeader, ebmlUint(0x4282, 0))
+	segmentHeader := []byte{0x18, 0x53, 0x80, 0x67, 0x01, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+
+	init := append(append(append([]byte{}, header...), segmentHeader...), info...)
+	origin := uint64(len(header) + len(segmentHeader))
+
+	cue := func(t, pos uint64) []byte {
+		return ebmlElement(ebml.IDCuePoint,
+			ebmlUint(ebml.IDCueTime, t),
+			ebmlElement(ebml.IDCueTrackPositions, ebmlUint(0xF7, 1), ebmlUint(ebml.IDCueClusterPosition, pos)),
+		)
+	}
+
+	index := ebmlElement(ebml.IDCues, cue(0, 1000), cue(10000, 3000), cue(20000, 6000))
+
+	segments, err := ParseCues(init, index, origin+10000)
+	require.NoError(t, err)
+	require.Len(t, segments, 3)
+
+	require.Equal(t, Segment{Start: 0, Duration: 10 * time.Second, Offset: origin + 1000, Size: 2000}, segments[0])
+	require.Equal(t, Segment{Start: 10 * time.Second, Duration: 10 * time.Second, Offset: origin + 3000, Size: 3000}, segments[1])
+	require.Equal(t, Segment{Start: 20 * time.Second, Duration: 10 * time.Second, Offset: origin + 6000, Size: 4000}, segments[2])
+
+	require.Equal(t, segments[1:], SegmentsWithin(segments, 15*time.Second, 0))
+	require.Equal(t, segments[:1], SegmentsWithin(segments, 0, 10*time.Second))
+}
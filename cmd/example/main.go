@@ -1,18 +1,68 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
-	"github.com/lithdew/nicehttp"
 	"github.com/lithdew/youtube"
+	"github.com/lithdew/youtube/mux"
+	"os"
+	"strings"
 )
 
+var selector = flag.String("f", "", "additionally download the formats picked by a format selector, e.g. 'bestvideo[height<=1080][ext=mp4]+bestaudio[ext=m4a]/best'")
+
 func check(err error) {
 	if err != nil {
 		panic(err)
 	}
 }
 
+func download(player youtube.Player, stream youtube.Format, filename string) {
+	check(player.DownloadFile(context.Background(), stream, filename))
+}
+
+// downloadSelected downloads the formats picked by selector expr. A pair of video-only and audio-only formats is
+// muxed into a single file.
+func downloadSelected(player youtube.Player, expr string) {
+	s, err := youtube.ParseSelector(expr)
+	check(err)
+
+	selected, ok := s.Select(player.Formats())
+	if !ok {
+		check(fmt.Errorf("no streams match the format selector %q", expr))
+	}
+
+	if len(selected) == 1 {
+		download(player, selected[0], "selected."+selected[0].FileExtension())
+		return
+	}
+
+	video, audio := selected[0], selected[1]
+
+	// Both formats must share a container in order to be muxed.
+
+	muxer, filename := mux.DownloadWebM, "selected.webm"
+
+	switch {
+	case strings.Contains(video.MIMEType, "/mp4") && strings.Contains(audio.MIMEType, "/mp4"):
+		muxer, filename = mux.DownloadMP4, "selected.mp4"
+	case strings.Contains(video.MIMEType, "/webm") && strings.Contains(audio.MIMEType, "/webm"):
+		muxer, filename = mux.DownloadWebM, "selected.webm"
+	default:
+		check(fmt.Errorf("unable to mux %s video with %s audio; add [ext=...] to the format selector %q such that both streams share a container", video.FileExtension(), audio.FileExtension(), expr))
+	}
+
+	file, err := os.Create(filename)
+	check(err)
+	defer file.Close()
+
+	check(muxer(context.Background(), player, video, audio, file))
+}
+
 func main() {
+	flag.Parse()
+
 	// Search for the song Animus Vox by The Glitch Mob.
 
 	results, err := youtube.Search("animus vox", 0)
@@ -46,49 +96,55 @@ func main() {
 
 	// Fetch audio-only direct link.
 
-	stream, ok := player.SourceFormats().AudioOnly().BestAudio()
+	audioOnly, ok := player.SourceFormats().AudioOnly().BestAudio()
 	if !ok {
 		check(fmt.Errorf("no audio-only stream available"))
 	}
 
-	audioOnlyFilename := "audio." + stream.FileExtension()
+	audioOnlyFilename := "audio." + audioOnly.FileExtension()
 
-	audioOnlyURL, err := player.ResolveURL(stream)
+	audioOnlyURL, err := player.ResolveURL(audioOnly)
 	check(err)
 
 	fmt.Printf("Audio-only direct link: %q\n", audioOnlyURL)
 
 	// Fetch video-only direct link.
 
-	stream, ok = player.SourceFormats().VideoOnly().BestVideo()
+	videoOnly, ok := player.SourceFormats().VideoOnly().BestVideo()
 	if !ok {
 		check(fmt.Errorf("no video-only stream available"))
 	}
 
-	videoOnlyFilename := "video." + stream.FileExtension()
+	videoOnlyFilename := "video." + videoOnly.FileExtension()
 
-	videoOnlyURL, err := player.ResolveURL(stream)
+	videoOnlyURL, err := player.ResolveURL(videoOnly)
 	check(err)
 
 	fmt.Printf("Video-only direct link: %q\n", videoOnlyURL)
 
 	// Fetch muxed video/audio direct link.
 
-	stream, ok = player.MuxedFormats().BestVideo()
+	muxed, ok := player.MuxedFormats().BestVideo()
 	if !ok {
 		check(fmt.Errorf("no muxed stream available"))
 	}
 
-	muxedFilename := "muxed." + stream.FileExtension()
+	muxedFilename := "muxed." + muxed.FileExtension()
 
-	muxedURL, err := player.ResolveURL(stream)
+	muxedURL, err := player.ResolveURL(muxed)
 	check(err)
 
 	fmt.Printf("Muxed (video/audio) direct link: %q\n", muxedURL)
 
-	// Download all the links.
+	// Download all the streams.
+
+	download(player, audioOnly, audioOnlyFilename)
+	download(player, videoOnly, videoOnlyFilename)
+	download(player, muxed, muxedFilename)
 
-	check(nicehttp.DownloadFile(audioOnlyFilename, audioOnlyURL))
-	check(nicehttp.DownloadFile(videoOnlyFilename, videoOnlyURL))
-	check(nicehttp.DownloadFile(muxedFilename, muxedURL))
+	// Download the streams picked by the format selector passed in through the -f flag, if any.
+
+	if *selector != "" {
+		downloadSelected(player, *selector)
+	}
 }
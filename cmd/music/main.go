@@ -1,14 +1,23 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
-	"github.com/lithdew/nicehttp"
 	"github.com/lithdew/youtube"
+	"github.com/lithdew/youtube/mux"
+	"github.com/lithdew/youtube/tag"
 	"log"
+	"os"
 	"path"
 	"regexp"
 	"strings"
+	"time"
+)
+
+var (
+	container = flag.String("container", "", "rewrap audio without transcoding into either 'm4a', 'opus', or 'ogg' (default: keep the original container)")
+	selector  = flag.String("f", "", "format selector picking the audio stream to download (default: the best audio-only stream in the container passed to -container)")
 )
 
 var (
@@ -35,37 +44,166 @@ func check(err error) {
 	}
 }
 
-func main() {
-	flag.Parse()
+func download(client *youtube.Client, id youtube.StreamID) {
+	player, err := client.Load(id)
+	check(err)
+
+	info := player.TrackInfo()
+
+	fmt.Printf(
+		"Title: %q\nAuthor: %q\nView Count: %s\n\nArtist: %q\nTrack: %q\nAlbum: %q\n\n",
+		player.Title(),
+		player.Author(),
+		player.ViewCount(),
+		info.Artist,
+		info.Track,
+		info.Album,
+	)
+
+	expr, ext := "bestaudio", ""
+
+	switch *container {
+	case "":
+	case "m4a":
+		expr, ext = "bestaudio[ext=m4a]", "m4a"
+	case "opus", "ogg":
+		expr, ext = "bestaudio[ext=webm]", *container
+	default:
+		check(fmt.Errorf("unknown container %q", *container))
+	}
 
-	client := youtube.NewClient()
+	if *selector != "" {
+		expr = *selector
+	}
 
-	for _, src := range flag.Args() {
-		id, err := youtube.ExtractStreamID(src)
-		check(err)
+	s, err := youtube.ParseSelector(expr)
+	check(err)
 
-		player, err := client.Load(id)
-		check(err)
+	selected, ok := s.Select(player.Formats())
+	if !ok {
+		check(fmt.Errorf("no audio matching %q available for video id %q", expr, id))
+	}
+
+	if len(selected) != 1 {
+		check(fmt.Errorf("format selector %q must pick a single stream", expr))
+	}
+
+	stream := selected[0]
 
-		fmt.Printf(
-			"Title: %q\nAuthor: %q\nView Count: %s\n\n",
-			player.Title(),
-			player.Author(),
-			player.ViewCount(),
-		)
+	url, err := player.ResolveURL(stream)
+	check(err)
 
-		stream, ok := player.SourceFormats().AudioOnly().BestAudio()
-		if !ok {
-			check(fmt.Errorf("no audio available for video id %q", id))
+	if ext == "" {
+		ext = stream.FileExtension()
+	}
+
+	filename := normalizeFileName(info.Artist+" - "+info.Track) + "." + ext
+
+	fmt.Printf("Stream URL: %q\n\nDownloading %q...\n", url, filename)
+
+	if *container == "" {
+		check(player.DownloadFile(context.Background(), stream, filename))
+	} else {
+		check(remux(player, stream, filename))
+	}
+
+	fmt.Printf("Tagging %q...\n", filename)
+
+	tags := tag.FromStreams(player.Streams)
+
+	if thumbnail, ok := player.BestThumbnail(); ok {
+		// The audio has already been downloaded, so tag it without a cover should the thumbnail fail to download.
+
+		cover, err := player.Transport.DownloadBytesDeadline(nil, thumbnail.URL, time.Now().Add(10*time.Second))
+		if err != nil {
+			log.Printf("Failed to download cover of %q: %v", filename, err)
+		} else {
+			tags.Cover = cover
 		}
+	}
 
-		url, err := player.ResolveURL(stream)
+	check(tag.WriteFile(filename, tags))
+}
+
+// remux downloads format f of player p, and rewraps it into the container named by the extension of filename.
+func remux(p youtube.Player, f youtube.Format, filename string) error {
+	r, err := p.Open(context.Background(), f)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	if path.Ext(filename) == ".m4a" {
+		err = mux.M4A(file, r)
+	} else {
+		err = mux.Opus(file, r)
+	}
+
+	if err != nil {
+		file.Close()
+		os.Remove(filename)
+		return err
+	}
+
+	return file.Close()
+}
+
+func downloadPlaylist(client *youtube.Client, id string) {
+	seen := make(map[youtube.StreamID]struct{})
+
+	for offset := uint(0); ; {
+		result, err := client.LoadPlaylist(id, offset)
 		check(err)
 
-		filename := normalizeFileName(player.Title()) + "." + stream.FileExtension()
+		fresh := 0
+
+		for _, item := range result.Items {
+			if _, exists := seen[item.ID]; exists {
+				continue
+			}
+			seen[item.ID] = struct{}{}
+			fresh++
+
+			download(client, item.ID)
+		}
+
+		if fresh == 0 {
+			return
+		}
+
+		offset += uint(len(result.Items))
+	}
+}
+
+func main() {
+	flag.Parse()
 
-		fmt.Printf("Stream URL: %q\n\nDownloading %q...\n", url, filename)
+	client := youtube.NewClient()
 
-		check(nicehttp.DownloadFile(filename, url))
+	for _, src := range flag.Args() {
+		ref, err := youtube.ParseURL(src)
+		check(err)
+
+		switch ref := ref.(type) {
+		case youtube.VideoRef:
+			download(&client, ref.ID)
+		case youtube.PlaylistRef:
+			downloadPlaylist(&client, ref.ID)
+		case youtube.SearchRef:
+			results, err := client.Search(ref.Query, 0)
+			check(err)
+
+			if len(results.Items) == 0 {
+				check(fmt.Errorf("got zero search results for %q", ref.Query))
+			}
+
+			download(&client, results.Items[0].ID)
+		default:
+			check(fmt.Errorf("downloading from %q is not supported", ref.URL()))
+		}
 	}
 }
@@ -0,0 +1,88 @@
+package youtube
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LoadCookiesFile stores the cookies listed in the Netscape cookies.txt file titled filename in jar.
+func LoadCookiesFile(jar http.CookieJar, filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return fmt.Errorf("failed to open cookies file: %w", err)
+	}
+	defer f.Close()
+
+	if err := ReadCookiesFile(jar, f); err != nil {
+		return fmt.Errorf("failed to read cookies file %q: %w", filename, err)
+	}
+
+	return nil
+}
+
+// ReadCookiesFile stores the cookies listed in a Netscape cookies.txt file, as exported by browser extensions and by
+// tools such as curl and youtube-dl, in jar. Each line of the file lists the domain, whether the cookie applies to
+// subdomains, the path, whether the cookie is secure, the expiry as a Unix timestamp, the name, and the value of a
+// cookie separated by tabs. Lines starting with '#HttpOnly_' list HTTP-only cookies.
+func ReadCookiesFile(jar http.CookieJar, r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+
+		httpOnly := strings.HasPrefix(text, "#HttpOnly_")
+		if httpOnly {
+			text = strings.TrimPrefix(text, "#HttpOnly_")
+		}
+
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Split(text, "\t")
+		if len(fields) != 7 {
+			return fmt.Errorf("line %d: expected 7 tab-separated fields, but got %d", line, len(fields))
+		}
+
+		host := strings.TrimPrefix(fields[0], ".")
+
+		cookie := &http.Cookie{
+			Name:     fields[5],
+			Value:    fields[6],
+			Path:     fields[2],
+			Secure:   strings.EqualFold(fields[3], "TRUE"),
+			HttpOnly: httpOnly,
+		}
+
+		// Cookies that do not apply to subdomains are left without a domain so that they are host-only.
+
+		if strings.EqualFold(fields[1], "TRUE") {
+			cookie.Domain = host
+		}
+
+		expiry, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return fmt.Errorf("line %d: bad expiry %q: %w", line, fields[4], err)
+		}
+
+		if expiry > 0 {
+			cookie.Expires = time.Unix(expiry, 0)
+		}
+
+		scheme := "http"
+		if cookie.Secure {
+			scheme = "https"
+		}
+
+		jar.SetCookies(&url.URL{Scheme: scheme, Host: host, Path: cookie.Path}, []*http.Cookie{cookie})
+	}
+
+	return scanner.Err()
+}
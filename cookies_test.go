@@ -0,0 +1,44 @@
+package youtube
+
+import (
+	"github.com/stretchr/testify/require"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+const testCookiesFile = `# Netscape HTTP Cookie File
+# This is a generated file! Do not edit.
+
+.youtube.com	TRUE	/	TRUE	2147483647	CONSENT	YES+cb
+#HttpOnly_.youtube.com	TRUE	/	TRUE	2147483647	LOGIN_INFO	secret
+www.youtube.com	FALSE	/	FALSE	0	PREF	hl=en
+.youtube.com	TRUE	/	FALSE	1	EXPIRED	gone
+`
+
+func TestReadCookiesFile(t *testing.T) {
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+
+	require.NoError(t, ReadCookiesFile(jar, strings.NewReader(testCookiesFile)))
+
+	cookiesOf := func(uri string) map[string]string {
+		u, err := url.Parse(uri)
+		require.NoError(t, err)
+
+		cookies := make(map[string]string)
+		for _, cookie := range jar.Cookies(u) {
+			cookies[cookie.Name] = cookie.Value
+		}
+
+		return cookies
+	}
+
+	require.Equal(t, map[string]string{"CONSENT": "YES+cb", "LOGIN_INFO": "secret", "PREF": "hl=en"}, cookiesOf("https://www.youtube.com/watch"))
+	require.Equal(t, map[string]string{"CONSENT": "YES+cb", "LOGIN_INFO": "secret"}, cookiesOf("https://music.youtube.com/"))
+	require.Equal(t, map[string]string{"PREF": "hl=en"}, cookiesOf("http://www.youtube.com/"))
+
+	require.Error(t, ReadCookiesFile(jar, strings.NewReader("youtube.com\tTRUE\t/\n")))
+	require.Error(t, ReadCookiesFile(jar, strings.NewReader(".youtube.com\tTRUE\t/\tTRUE\tnever\tA\tB\n")))
+}
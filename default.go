@@ -3,7 +3,10 @@
 
 package youtube
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 var defaultClient = NewClient()
 
@@ -19,6 +22,10 @@ func LoadDeadline(id StreamID, deadline time.Time) (Player, error) {
 	return defaultClient.LoadDeadline(id, deadline)
 }
 
+func LoadMany(ctx context.Context, ids []StreamID, opts LoadManyOptions) <-chan LoadResult {
+	return defaultClient.LoadMany(ctx, ids, opts)
+}
+
 func LoadPlaylist(id string, offset uint) (PlaylistResult, error) {
 	return defaultClient.LoadPlaylist(id, offset)
 }
@@ -0,0 +1,261 @@
+package youtube
+
+import (
+	"context"
+	"fmt"
+	"golang.org/x/sync/errgroup"
+	"io"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Downloader downloads formats using parallel, fixed-sized ranged requests.
+type Downloader struct {
+	// The transport used to download chunks.
+	Transport Transport
+
+	// The number of workers that are to be spawned for downloading chunks in parallel.
+	NumWorkers int
+
+	// Size of individual byte chunks downloaded.
+	ChunkSize int
+
+	// Max number of times downloading a chunk is retried before the download is marked to have failed.
+	MaxRetries int
+
+	// Max number of bytes downloaded per second across all workers. Zero disables bandwidth limiting.
+	BytesPerSecond int
+
+	// Decide whether or not signed URLs that expire mid-download are refreshed by reloading the player of the
+	// stream being downloaded.
+	RefreshURLs bool
+
+	// Progress, if non-nil, is called serially every time a chunk is downloaded with the number of bytes downloaded
+	// so far and the total number of bytes to be downloaded. The total is zero if unknown.
+	Progress func(downloaded, total uint64)
+}
+
+// NewDownloader instantiates a new downloader on top of t with sane configuration defaults.
+func NewDownloader(t Transport) Downloader {
+	return Downloader{
+		Transport: t,
+
+		// Default to the number of available CPUs.
+		NumWorkers: runtime.NumCPU(),
+
+		// 10 MiB chunks.
+		ChunkSize: 10 * 1024 * 1024,
+
+		// Retry a chunk 3 times at most.
+		MaxRetries: 3,
+
+		// Transparently refresh expired URLs.
+		RefreshURLs: true,
+	}
+}
+
+// Download downloads format f using a downloader with default settings on top of the player's transport, and writes
+// it to w.
+func (p Player) Download(ctx context.Context, f Format, w io.WriterAt) error {
+	d := NewDownloader(p.Transport)
+	return d.Download(ctx, p, f, w)
+}
+
+// Download resolves the URL to format f using player p, and writes the format to w. If the format's content length
+// is known, it is downloaded in parallel chunks. The download is aborted once ctx is done, though chunks that are
+// already in flight are only aborted once ctx's deadline passes.
+//
+// If d.RefreshURLs is set and the format's signed URL expires mid-download, the player is reloaded to resolve a
+// fresh URL to the format with the same itag, and the download continues where it left off.
+func (d *Downloader) Download(ctx context.Context, p Player, f Format, w io.WriterAt) error {
+	deadline, _ := ctx.Deadline()
+
+	url, err := p.ResolveURLDeadline(f, deadline)
+	if err != nil {
+		return fmt.Errorf("failed to resolve url of format with itag %d: %w", f.ITag, err)
+	}
+
+	size := f.Size()
+
+	return d.download(ctx, newStreamURL(p.reloader(d.Transport), p.ID(), f.ITag, size, url, p.ExpiresAt()), size, w)
+}
+
+// download downloads size bytes from src and writes them to w. If size is zero, the contents at src are downloaded
+// in a single request.
+func (d *Downloader) download(ctx context.Context, src *streamURL, size uint64, w io.WriterAt) error {
+	if size == 0 {
+		deadline, _ := ctx.Deadline()
+
+		url, _ := src.get()
+
+		buf, err := d.Transport.DownloadBytesDeadline(nil, url, deadline)
+		if err != nil {
+			return fmt.Errorf("failed to download %q: %w", url, err)
+		}
+
+		if _, err := w.WriteAt(buf, 0); err != nil {
+			return fmt.Errorf("failed to write %d byte(s): %w", len(buf), err)
+		}
+
+		if d.Progress != nil {
+			d.Progress(uint64(len(buf)), 0)
+		}
+
+		return nil
+	}
+
+	return d.downloadSpans(ctx, src, size, []ByteRange{{Start: 0, End: size - 1}}, w, nil)
+}
+
+// downloadSpans downloads the given spans of the size bytes at src in chunks, and writes them to w. done, if
+// non-nil, is called serially after each chunk is written.
+func (d *Downloader) downloadSpans(ctx context.Context, src *streamURL, size uint64, spans []ByteRange, w io.WriterAt, done func(ByteRange) error) error {
+	var (
+		limiter *bucket
+		mu      sync.Mutex
+		total   = size
+	)
+
+	for _, s := range spans {
+		total -= s.Len()
+	}
+
+	if d.BytesPerSecond > 0 {
+		limiter = newBucket(float64(d.BytesPerSecond), float64(d.BytesPerSecond))
+	}
+
+	complete := func(s ByteRange) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		total += s.Len()
+
+		if d.Progress != nil {
+			d.Progress(total, size)
+		}
+
+		if done != nil {
+			return done(s)
+		}
+
+		return nil
+	}
+
+	chunkSize := uint64(d.ChunkSize)
+	if chunkSize == 0 {
+		chunkSize = size
+	}
+
+	workers := d.NumWorkers
+	if workers <= 0 {
+		workers = 1
+	}
+
+	g, ctx := errgroup.WithContext(ctx)
+
+	ch := make(chan ByteRange, workers)
+
+	// Spawn workers that will download and write chunks.
+
+	for i := 0; i < workers; i++ {
+		i := i
+
+		g.Go(func() error {
+			var buf []byte
+
+			for s := range ch {
+				if limiter != nil {
+					if err := limiter.take(ctx, float64(s.Len())); err != nil {
+						return err
+					}
+				}
+
+				var err error
+
+				buf, err = d.downloadChunk(ctx, buf[:0], src, s)
+				if err != nil {
+					return fmt.Errorf("worker %d failed to download bytes %s: %w", i, s, err)
+				}
+
+				if _, err := w.WriteAt(buf, int64(s.Start)); err != nil {
+					return fmt.Errorf("worker %d failed to write at offset %d: %w", i, s.Start, err)
+				}
+
+				if err := complete(s); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	}
+
+	// Feed workers the chunks to download.
+
+	g.Go(func() error {
+		defer close(ch)
+
+		for _, s := range spans {
+			for start := s.Start; start <= s.End; start += chunkSize {
+				chunk := ByteRange{Start: start, End: start + chunkSize - 1}
+				if chunk.End > s.End {
+					chunk.End = s.End
+				}
+
+				select {
+				case ch <- chunk:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}
+
+		return nil
+	})
+
+	return g.Wait()
+}
+
+// downloadChunk downloads byte range r of src, retrying up to d.MaxRetries times. If src has expired, it is refreshed
+// without counting towards the number of retries.
+func (d *Downloader) downloadChunk(ctx context.Context, dst []byte, src *streamURL, r ByteRange) ([]byte, error) {
+	deadline, _ := ctx.Deadline()
+
+	var (
+		err       error
+		refreshed bool
+	)
+
+	for attempt := 0; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return dst, err
+		}
+
+		url, version := src.get()
+
+		dst, err = downloadRangeDeadline(d.Transport, dst[:0], url, r, deadline)
+		if err == nil {
+			return dst, nil
+		}
+
+		if d.RefreshURLs && !refreshed && src.expired(err) {
+			if err := src.refresh(ctx, version); err != nil {
+				return dst, fmt.Errorf("failed to refresh expired url: %w", err)
+			}
+
+			refreshed = true
+			attempt--
+
+			continue
+		}
+
+		if attempt >= d.MaxRetries {
+			return dst, err
+		}
+
+		if err := sleep(ctx, time.Duration(attempt+1)*250*time.Millisecond); err != nil {
+			return dst, err
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package youtube
+
+import (
+	"bytes"
+	"context"
+	"github.com/lithdew/nicehttp"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newRangeServer serves byte ranges of data requested through the 'range' query parameter. Every fail-th request
+// for a range fails with a 500 status code.
+func newRangeServer(data []byte, fail int) *httptest.Server {
+	var (
+		mu       sync.Mutex
+		attempts = make(map[string]int)
+	)
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := data
+
+		if rng := r.URL.Query().Get("range"); rng != "" {
+			bounds := strings.SplitN(rng, "-", 2)
+
+			start, _ := strconv.Atoi(bounds[0])
+			end, _ := strconv.Atoi(bounds[1])
+
+			if start > end || end >= len(data) {
+				w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+				return
+			}
+
+			body = data[start : end+1]
+
+			if r.Method == http.MethodGet && fail > 0 {
+				mu.Lock()
+				attempts[rng]++
+				n := attempts[rng]
+				mu.Unlock()
+
+				if n%fail == 1 {
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+			}
+		}
+
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(body))
+	}))
+}
+
+func TestDownload(t *testing.T) {
+	data := make([]byte, 1<<20+123)
+	rand.New(rand.NewSource(0)).Read(data)
+
+	server := newRangeServer(data, 2)
+	defer server.Close()
+
+	uri := server.URL + "/videoplayback?itag=140"
+
+	player := Player{Transport: HTTPTransport{}}
+	format := Format{ITag: 140, URL: &uri, ContentLength: strconv.Itoa(len(data))}
+
+	d := NewDownloader(player.Transport)
+	d.NumWorkers = 4
+	d.ChunkSize = 64 * 1024
+	d.MaxRetries = 1
+
+	var (
+		calls, last uint64
+		monotonic   = true
+	)
+
+	d.Progress = func(downloaded, total uint64) {
+		monotonic = monotonic && downloaded > last && total == uint64(len(data))
+
+		calls++
+		last = downloaded
+	}
+
+	w := nicehttp.NewWriteBuffer(make([]byte, len(data)))
+
+	require.NoError(t, d.Download(context.Background(), player, format, w))
+	require.Equal(t, data, w.Bytes())
+
+	require.EqualValues(t, (len(data)+d.ChunkSize-1)/d.ChunkSize, calls)
+	require.EqualValues(t, len(data), last)
+	require.True(t, monotonic)
+
+	d.Progress = nil
+	d.MaxRetries = 0
+	require.Error(t, d.Download(context.Background(), player, format, nicehttp.NewWriteBuffer(make([]byte, len(data)))))
+}
+
+func TestDownloadBandwidthLimit(t *testing.T) {
+	data := make([]byte, 64*1024)
+
+	uri := "https://example.com/videoplayback?itag=140"
+
+	player := Player{Transport: rangeTransport{data: data}}
+	format := Format{ITag: 140, URL: &uri, ContentLength: strconv.Itoa(len(data))}
+
+	d := NewDownloader(player.Transport)
+	d.ChunkSize = 8 * 1024
+	d.BytesPerSecond = 128 * 1024
+
+	w := nicehttp.NewWriteBuffer(make([]byte, len(data)))
+
+	start := time.Now()
+	require.NoError(t, d.Download(context.Background(), player, format, w))
+	require.Equal(t, data, w.Bytes())
+
+	// The first second's worth of bytes is available upfront as a burst. Nothing should have to wait here.
+
+	require.True(t, time.Since(start) < 250*time.Millisecond)
+
+	d.BytesPerSecond = 32 * 1024
+
+	start = time.Now()
+	require.NoError(t, d.Download(context.Background(), player, format, nicehttp.NewWriteBuffer(make([]byte, len(data)))))
+	require.True(t, time.Since(start) >= time.Second)
+}
+
+// expiringTransport responds with a 403 status code to requests for URLs signed with an expired signature.
+type expiringTransport struct {
+	Transport
+}
+
+func (t expiringTransport) DownloadBytesDeadline(dst []byte, url string, deadline time.Time) ([]byte, error) {
+	if strings.Contains(url, "sig=expired") {
+		return dst, &StatusError{URL: url, StatusCode: http.StatusForbidden}
+	}
+	return t.Transport.DownloadBytesDeadline(dst, url, deadline)
+}
+
+func TestDownloadRefreshesExpiredURL(t *testing.T) {
+	response, err := ioutil.ReadFile("testdata/player_response.json")
+	require.NoError(t, err)
+
+	streams, err := NewStreamsFromJSON(response)
+	require.NoError(t, err)
+
+	format, ok := findFormatByITag(Player{Streams: streams}, 249)
+	require.True(t, ok)
+
+	size, err := strconv.Atoi(format.ContentLength)
+	require.NoError(t, err)
+
+	data := make([]byte, size)
+	rand.New(rand.NewSource(0)).Read(data)
+
+	transport := expiringTransport{Transport: watchTransport{
+		Transport: rangeTransport{data: data},
+		pages:     map[StreamID][]byte{"pAsDzfbLM8Y": newTestWatchPage(t, response)},
+	}}
+
+	loadedAt := time.Now().Add(-time.Hour)
+
+	player := Player{Transport: transport, Streams: streams, LoadedAt: loadedAt}
+	require.Equal(t, loadedAt.Add(21540*time.Second), player.ExpiresAt())
+
+	expired := "https://example.com/videoplayback?itag=249&sig=expired"
+	format.URL = &expired
+
+	d := NewDownloader(transport)
+	d.ChunkSize = 256 * 1024
+
+	w := nicehttp.NewWriteBuffer(make([]byte, size))
+	require.NoError(t, d.Download(context.Background(), player, format, w))
+	require.Equal(t, data, w.Bytes())
+
+	d.RefreshURLs = false
+	d.MaxRetries = 0
+	require.Error(t, d.Download(context.Background(), player, format, nicehttp.NewWriteBuffer(make([]byte, size))))
+}
+
+// languageTransport only serves requests to YouTube sent with an 'Accept-Language' header through the transport it
+// wraps, and fails requests to other hosts that are sent with headers.
+type languageTransport struct {
+	Transport
+}
+
+func (t languageTransport) DownloadBytesDeadline(dst []byte, url string, deadline time.Time) ([]byte, error) {
+	return roundTripBytesDeadline(t, dst, url, nil, deadline)
+}
+
+func (t languageTransport) RoundTripDeadline(req *Request, res *Response, deadline time.Time) error {
+	if strings.HasPrefix(req.URL, "https://www.youtube.com/") != (req.Header.Get("Accept-Language") != "") {
+		res.StatusCode = http.StatusBadRequest
+		return nil
+	}
+	return AdaptTransport(t.Transport).RoundTripDeadline(req, res, deadline)
+}
+
+func TestDownloadRefreshesExpiredURLThroughClient(t *testing.T) {
+	response, err := ioutil.ReadFile("testdata/player_response.json")
+	require.NoError(t, err)
+
+	streams, err := NewStreamsFromJSON(response)
+	require.NoError(t, err)
+
+	format, ok := findFormatByITag(Player{Streams: streams}, 249)
+	require.True(t, ok)
+
+	data := make([]byte, format.Size())
+	rand.New(rand.NewSource(0)).Read(data)
+
+	client := WrapClient(languageTransport{Transport: expiringTransport{Transport: watchTransport{
+		Transport: rangeTransport{data: data},
+		pages:     map[StreamID][]byte{"pAsDzfbLM8Y": newTestWatchPage(t, response)},
+	}}})
+	client.Header = http.Header{"Accept-Language": {"en-US"}}
+
+	player, err := client.LoadWatchPlayer("pAsDzfbLM8Y")
+	require.NoError(t, err)
+
+	// Changes made to the client after the player is loaded do not apply to the player.
+
+	client.Header = nil
+
+	expired := "https://example.com/videoplayback?itag=249&sig=expired"
+	format.URL = &expired
+
+	// The player is reloaded with the client's headers once the URL expires, which are not sent to video servers.
+
+	w := nicehttp.NewWriteBuffer(make([]byte, len(data)))
+	require.NoError(t, player.Download(context.Background(), format, w))
+	require.Equal(t, data, w.Bytes())
+}
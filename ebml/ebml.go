@@ -0,0 +1,316 @@
+// Package ebml reads and writes elements of the Extensible Binary Meta Language (RFC 8794), which WebM and Matroska
+// files are comprised of.
+package ebml
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/bits"
+)
+
+// UnknownSize is the size of an element whose size is unknown, which extends up until the end of its parent.
+const UnknownSize uint64 = math.MaxUint64
+
+// IDs of the WebM elements this package and its users deal with.
+const (
+	IDHeader  = 0x1A45DFA3
+	IDSegment = 0x18538067
+
+	IDSeekHead     = 0x114D9B74
+	IDSeek         = 0x4DBB
+	IDSeekID       = 0x53AB
+	IDSeekPosition = 0x53AC
+
+	IDInfo          = 0x1549A966
+	IDTimecodeScale = 0x2AD7B1
+	IDDuration      = 0x4489
+
+	IDTracks            = 0x1654AE6B
+	IDTrackEntry        = 0xAE
+	IDTrackNumber       = 0xD7
+	IDCodecID           = 0x86
+	IDCodecPrivate      = 0x63A2
+	IDCodecDelay        = 0x56AA
+	IDAudio             = 0xE1
+	IDSamplingFrequency = 0xB5
+	IDChannels          = 0x9F
+
+	IDCues               = 0x1C53BB6B
+	IDCuePoint           = 0xBB
+	IDCueTime            = 0xB3
+	IDCueTrackPositions  = 0xB7
+	IDCueTrack           = 0xF7
+	IDCueClusterPosition = 0xF1
+
+	IDCluster        = 0x1F43B675
+	IDTimecode       = 0xE7
+	IDSimpleBlock    = 0xA3
+	IDBlockGroup     = 0xA0
+	IDBlock          = 0xA1
+	IDDiscardPadding = 0x75A2
+
+	IDTags      = 0x1254C367
+	IDTag       = 0x7373
+	IDTargets   = 0x63C0
+	IDSimpleTag = 0x67C8
+	IDTagName   = 0x45A3
+	IDTagString = 0x4487
+
+	IDVoid = 0xEC
+)
+
+// Element is an EBML element.
+type Element struct {
+	ID   uint32
+	Data []byte
+}
+
+// Uint decodes the data of the element as an unsigned integer.
+func (e Element) Uint() uint64 {
+	var v uint64
+	for _, b := range e.Data {
+		v = v<<8 | uint64(b)
+	}
+	return v
+}
+
+// Float decodes the data of the element as a 32-bit or 64-bit floating-point number.
+func (e Element) Float() float64 {
+	switch len(e.Data) {
+	case 4:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(e.Data)))
+	case 8:
+		return math.Float64frombits(binary.BigEndian.Uint64(e.Data))
+	default:
+		return 0
+	}
+}
+
+// Children parses the data of the element as a sequence of child elements.
+func (e Element) Children() ([]Element, error) {
+	return ParseAll(e.Data)
+}
+
+// AppendTo appends the encoded element to dst.
+func (e Element) AppendTo(dst []byte) []byte {
+	return append(AppendHeader(dst, e.ID, uint64(len(e.Data))), e.Data...)
+}
+
+// Size returns the size of the element in bytes once encoded, including its header.
+func (e Element) Size() uint64 {
+	return uint64(IDWidth(e.ID)+SizeWidth(uint64(len(e.Data)))) + uint64(len(e.Data))
+}
+
+// ParseHeader parses the ID and data size of the element at the start of buf, alongside the length of the element's
+// header.
+func ParseHeader(buf []byte) (id uint32, size uint64, n int, err error) {
+	if len(buf) == 0 {
+		return 0, 0, 0, errors.New("element is truncated")
+	}
+
+	width := bits.LeadingZeros8(buf[0]) + 1
+	if width > 4 || width > len(buf) {
+		return 0, 0, 0, errors.New("element id is malformed")
+	}
+
+	for _, b := range buf[:width] {
+		id = id<<8 | uint32(b)
+	}
+	n = width
+
+	size, width, err = ParseVint(buf[n:])
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("size of element %#x is malformed: %w", id, err)
+	}
+
+	return id, size, n + width, nil
+}
+
+// ParseVint parses the variable-length integer at the start of buf, returning its value and its width in bytes. A
+// variable-length integer with all of its value bits set decodes to UnknownSize.
+func ParseVint(buf []byte) (v uint64, n int, err error) {
+	if len(buf) == 0 {
+		return 0, 0, errors.New("vint is truncated")
+	}
+
+	n = bits.LeadingZeros8(buf[0]) + 1
+	if n > 8 {
+		return 0, 0, errors.New("vint is wider than 8 bytes")
+	}
+	if n > len(buf) {
+		return 0, 0, errors.New("vint is truncated")
+	}
+
+	v = uint64(buf[0]) & (0xff >> n)
+	unknown := v == 0xff>>n
+	for _, b := range buf[1:n] {
+		v = v<<8 | uint64(b)
+		unknown = unknown && b == 0xff
+	}
+
+	if unknown {
+		v = UnknownSize
+	}
+
+	return v, n, nil
+}
+
+// Parse parses the element at the start of buf, returning the element and its total length in bytes. The element
+// references buf rather than copying it.
+func Parse(buf []byte) (Element, int, error) {
+	id, size, n, err := ParseHeader(buf)
+	if err != nil {
+		return Element{}, 0, err
+	}
+
+	if size == UnknownSize {
+		size = uint64(len(buf) - n)
+	}
+
+	if size > uint64(len(buf)-n) {
+		return Element{}, 0, fmt.Errorf("element %#x is truncated", id)
+	}
+
+	return Element{ID: id, Data: buf[n : n+int(size)]}, n + int(size), nil
+}
+
+// ParseAll parses buf as a sequence of elements.
+func ParseAll(buf []byte) ([]Element, error) {
+	var elements []Element
+
+	for len(buf) > 0 {
+		e, n, err := Parse(buf)
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, e)
+		buf = buf[n:]
+	}
+
+	return elements, nil
+}
+
+// ReadHeader reads the ID and data size of an element from r, alongside the length of the element's header.
+func ReadHeader(r io.Reader) (id uint32, size uint64, n int, err error) {
+	var buf [12]byte
+
+	if _, err := io.ReadFull(r, buf[:1]); err != nil {
+		return 0, 0, 0, err
+	}
+
+	width := bits.LeadingZeros8(buf[0]) + 1
+	if width > 4 {
+		return 0, 0, 0, errors.New("element id is malformed")
+	}
+
+	if _, err := io.ReadFull(r, buf[1:width+1]); err != nil {
+		return 0, 0, 0, unexpected(err)
+	}
+
+	n = width + bits.LeadingZeros8(buf[width]) + 1
+	if n > width+8 {
+		return 0, 0, 0, errors.New("element size is malformed")
+	}
+
+	if _, err := io.ReadFull(r, buf[width+1:n]); err != nil {
+		return 0, 0, 0, unexpected(err)
+	}
+
+	return ParseHeader(buf[:n])
+}
+
+// IDWidth returns the width of an element ID in bytes.
+func IDWidth(id uint32) int {
+	switch {
+	case id > 0xffffff:
+		return 4
+	case id > 0xffff:
+		return 3
+	case id > 0xff:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// SizeWidth returns the minimum width in bytes of a variable-length integer encoding of size.
+func SizeWidth(size uint64) int {
+	n := 1
+	for n < 8 && size >= 1<<(7*uint(n))-1 {
+		n++
+	}
+	return n
+}
+
+// AppendID appends an element ID to dst.
+func AppendID(dst []byte, id uint32) []byte {
+	for shift := 8 * (IDWidth(id) - 1); shift >= 0; shift -= 8 {
+		dst = append(dst, byte(id>>uint(shift)))
+	}
+	return dst
+}
+
+// AppendVint appends v as a variable-length integer that is width bytes wide to dst. UnknownSize is encoded with
+// all of its value bits set.
+func AppendVint(dst []byte, v uint64, width int) []byte {
+	if v == UnknownSize {
+		v = 1<<(7*uint(width)) - 1
+	}
+
+	v |= 1 << (7 * uint(width))
+
+	for shift := 8 * (width - 1); shift >= 0; shift -= 8 {
+		dst = append(dst, byte(v>>uint(shift)))
+	}
+
+	return dst
+}
+
+// AppendHeader appends the header of an element with the given ID and data size to dst.
+func AppendHeader(dst []byte, id uint32, size uint64) []byte {
+	width := 8
+	if size != UnknownSize {
+		width = SizeWidth(size)
+	}
+	return AppendVint(AppendID(dst, id), size, width)
+}
+
+// AppendElement appends an element with the given ID and data to dst.
+func AppendElement(dst []byte, id uint32, data []byte) []byte {
+	return append(AppendHeader(dst, id, uint64(len(data))), data...)
+}
+
+// AppendUint appends an unsigned integer element to dst using as few bytes as possible.
+func AppendUint(dst []byte, id uint32, v uint64) []byte {
+	width := 1
+	for width < 8 && v >= 1<<(8*uint(width)) {
+		width++
+	}
+	return AppendUintWidth(dst, id, v, width)
+}
+
+// AppendUintWidth appends an unsigned integer element whose data is width bytes wide to dst.
+func AppendUintWidth(dst []byte, id uint32, v uint64, width int) []byte {
+	dst = AppendHeader(dst, id, uint64(width))
+	for shift := 8 * (width - 1); shift >= 0; shift -= 8 {
+		dst = append(dst, byte(v>>uint(shift)))
+	}
+	return dst
+}
+
+// AppendFloat appends a 64-bit floating-point element to dst.
+func AppendFloat(dst []byte, id uint32, v float64) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], math.Float64bits(v))
+	return AppendElement(dst, id, buf[:])
+}
+
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
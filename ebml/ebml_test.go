@@ -0,0 +1,64 @@
+package ebml
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestVint(t *testing.T) {
+	for _, v := range []uint64{0, 1, 126, 127, 16382, 16383, 1 << 40} {
+		width := SizeWidth(v)
+
+		buf := AppendVint(nil, v, width)
+		require.Len(t, buf, width)
+
+		parsed, n, err := ParseVint(buf)
+		require.NoError(t, err)
+		require.Equal(t, width, n)
+		require.Equal(t, v, parsed)
+	}
+
+	require.Equal(t, 1, SizeWidth(126))
+	require.Equal(t, 2, SizeWidth(127))
+
+	size, n, err := ParseVint([]byte{0x01, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+	require.NoError(t, err)
+	require.Equal(t, 8, n)
+	require.EqualValues(t, UnknownSize, size)
+
+	_, _, err = ParseVint([]byte{0x00})
+	require.Error(t, err)
+
+	_, _, err = ParseVint([]byte{0x40})
+	require.Error(t, err)
+}
+
+func TestElement(t *testing.T) {
+	var buf []byte
+	buf = AppendUint(buf, IDTimecodeScale, 1000000)
+	buf = AppendFloat(buf, IDDuration, 1234.5)
+	buf = AppendElement(nil, IDInfo, buf)
+
+	info, n, err := Parse(buf)
+	require.NoError(t, err)
+	require.Equal(t, len(buf), n)
+	require.EqualValues(t, IDInfo, info.ID)
+	require.EqualValues(t, len(buf), info.Size())
+	require.Equal(t, buf, info.AppendTo(nil))
+
+	children, err := info.Children()
+	require.NoError(t, err)
+	require.Len(t, children, 2)
+	require.EqualValues(t, 1000000, children[0].Uint())
+	require.Equal(t, 1234.5, children[1].Float())
+
+	id, size, n, err := ReadHeader(bytes.NewReader(buf))
+	require.NoError(t, err)
+	require.EqualValues(t, IDInfo, id)
+	require.EqualValues(t, len(info.Data), size)
+	require.Equal(t, len(buf)-len(info.Data), n)
+
+	_, _, err = Parse(buf[:len(buf)-1])
+	require.Error(t, err)
+}
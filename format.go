@@ -1,8 +1,13 @@
 package youtube
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/lithdew/bytesutil"
 	"github.com/valyala/fastjson"
+	"strconv"
+	"time"
 )
 
 type Format struct {
@@ -30,17 +35,147 @@ type Format struct {
 	AudioChannels   *uint   `json:"audioChannels,omitempty"`
 	AudioSampleRate *string `json:"audioSampleRate,omitempty"`
 
-	InitRange  *TimeRange `json:"initRange,omitempty"`
-	IndexRange *TimeRange `json:"indexRange,omitempty"`
+	InitRange  *ByteRange `json:"initRange,omitempty"`
+	IndexRange *ByteRange `json:"indexRange,omitempty"`
 
 	LastModified    string `json:"lastModified"`
 	HighReplication bool   `json:"highReplication,omitempty"`
 
 	ProjectionType string `json:"projectionType"`
+
+	// Media parsed out of MIMEType by ParseFormatJSON and UnmarshalJSON, so that ranking and filtering formats does
+	// not parse their MIME types over and over again.
+	media *formatMedia
+}
+
+// Size returns the size of the format in bytes. It returns zero if the size is unknown.
+func (f Format) Size() uint64 {
+	n, _ := strconv.ParseUint(f.ContentLength, 10, 64)
+	return n
+}
+
+// ApproxDuration returns the approximate duration of the format. It returns zero if the duration is unknown.
+func (f Format) ApproxDuration() time.Duration {
+	n, _ := strconv.ParseUint(f.ApproxDurationMs, 10, 64)
+	return time.Duration(n) * time.Millisecond
+}
+
+// SampleRate returns the audio sample rate of the format in hertz. It returns zero if the format carries no audio or
+// its sample rate is unknown.
+func (f Format) SampleRate() uint {
+	if f.AudioSampleRate == nil {
+		return 0
+	}
+	n, _ := strconv.ParseUint(*f.AudioSampleRate, 10, 32)
+	return uint(n)
+}
+
+// ModTime returns the time at which the format was last modified, which YouTube reports in microseconds since the
+// Unix epoch. It returns the zero time if the time is unknown.
+func (f Format) ModTime() time.Time {
+	n, err := strconv.ParseInt(f.LastModified, 10, 64)
+	if err != nil || n <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(n/1e6, n%1e6*1e3)
+}
+
+// formatJSON has the same fields as Format, but none of its methods.
+type formatJSON Format
+
+// formatWithNumbers overrides the fields of Format that are kept as strings so that they are encoded in JSON as
+// numbers.
+type formatWithNumbers struct {
+	*formatJSON
+
+	ApproxDurationMs numericString `json:"approxDurationMs,omitempty"`
+	ContentLength    numericString `json:"contentLength,omitempty"`
+	AudioSampleRate  numericString `json:"audioSampleRate,omitempty"`
+	LastModified     numericString `json:"lastModified,omitempty"`
+}
+
+// MarshalJSON encodes the format's content length, approximate duration, audio sample rate, and last modification
+// time as JSON numbers. Fields that are unknown are omitted.
+func (f Format) MarshalJSON() ([]byte, error) {
+	v := formatWithNumbers{
+		formatJSON:       (*formatJSON)(&f),
+		ApproxDurationMs: numericString(f.ApproxDurationMs),
+		ContentLength:    numericString(f.ContentLength),
+		LastModified:     numericString(f.LastModified),
+	}
+
+	if f.AudioSampleRate != nil {
+		v.AudioSampleRate = numericString(*f.AudioSampleRate)
+	}
+
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON decodes a format whose numeric fields are either given as strings, as done by YouTube, or as numbers.
+func (f *Format) UnmarshalJSON(buf []byte) error {
+	v := formatWithNumbers{formatJSON: (*formatJSON)(f)}
+
+	if err := json.Unmarshal(buf, &v); err != nil {
+		return err
+	}
+
+	f.ApproxDurationMs = string(v.ApproxDurationMs)
+	f.ContentLength = string(v.ContentLength)
+	f.LastModified = string(v.LastModified)
+
+	f.AudioSampleRate = nil
+	if v.AudioSampleRate != "" {
+		f.AudioSampleRate = func(s string) *string { return &s }(string(v.AudioSampleRate))
+	}
+
+	f.cacheMedia()
+
+	return nil
 }
 
+// numericString is a decimal number kept as a string. It is encoded in JSON as a number should it be one, and may be
+// decoded from either a JSON string or a JSON number.
+type numericString string
+
+func (s numericString) MarshalJSON() ([]byte, error) {
+	if _, err := strconv.ParseUint(string(s), 10, 64); err != nil {
+		return json.Marshal(string(s))
+	}
+	return []byte(s), nil
+}
+
+func (s *numericString) UnmarshalJSON(buf []byte) error {
+	v, err := fastjson.ParseBytes(buf)
+	if err != nil {
+		return err
+	}
+	*s = numericString(stringOrNumberJSON(v))
+	return nil
+}
+
+// stringOrNumberJSON returns the contents of v should it be a JSON string, or its textual representation should it be
+// a JSON number. It returns an empty string otherwise.
+func stringOrNumberJSON(v *fastjson.Value) string {
+	if v == nil {
+		return ""
+	}
+	switch v.Type() {
+	case fastjson.TypeString:
+		return bytesutil.String(v.GetStringBytes())
+	case fastjson.TypeNumber:
+		return v.String()
+	default:
+		return ""
+	}
+}
+
+// FileExtension returns the file extension conventionally used for the format's itag. It falls back to an extension
+// derived from the format's MIME type should its itag be unknown.
 func (f Format) FileExtension() string {
-	return ITags[f.ITag].Extension
+	if t, ok := Lookup(f.ITag); ok && t.Extension != "" {
+		return t.Extension
+	}
+	return f.parsedMedia().Extension()
 }
 
 type ColorInfo struct {
@@ -57,11 +192,93 @@ func ParseColorInfoJSON(v *fastjson.Value) ColorInfo {
 	}
 }
 
+// ByteRange is an inclusive range of bytes [Start, End]. It is encoded in JSON with its offsets as numbers, and may be
+// decoded with its offsets given either as strings, as done by YouTube, or as numbers.
+type ByteRange struct {
+	Start uint64 `json:"start"`
+	End   uint64 `json:"end"`
+}
+
+func (r *ByteRange) UnmarshalJSON(buf []byte) error {
+	v, err := fastjson.ParseBytes(buf)
+	if err != nil {
+		return err
+	}
+	parsed, err := ParseByteRangeJSON(v)
+	if err != nil {
+		return err
+	}
+	*r = parsed
+	return nil
+}
+
+// Len returns the number of bytes in the range.
+func (r ByteRange) Len() uint64 {
+	return r.End - r.Start + 1
+}
+
+// String formats the range as 'start-end', which is the syntax of the 'range' query parameter supported by YouTube's
+// video servers.
+func (r ByteRange) String() string {
+	return strconv.FormatUint(r.Start, 10) + "-" + strconv.FormatUint(r.End, 10)
+}
+
+// ParseByteRangeJSON parses a byte range whose offsets are either given as strings or as numbers.
+func ParseByteRangeJSON(v *fastjson.Value) (ByteRange, error) {
+	var (
+		r   ByteRange
+		err error
+	)
+
+	if r.Start, err = parseUintJSON(v.Get("start")); err != nil {
+		return r, fmt.Errorf("bad range start: %w", err)
+	}
+	if r.End, err = parseUintJSON(v.Get("end")); err != nil {
+		return r, fmt.Errorf("bad range end: %w", err)
+	}
+	if r.End < r.Start {
+		return r, fmt.Errorf("range end %d is before start %d", r.End, r.Start)
+	}
+
+	return r, nil
+}
+
+// parseUintJSON parses an unsigned integer given either as a JSON string or as a JSON number.
+func parseUintJSON(v *fastjson.Value) (uint64, error) {
+	if v == nil {
+		return 0, errors.New("value is missing")
+	}
+	if v.Type() == fastjson.TypeString {
+		return strconv.ParseUint(bytesutil.String(v.GetStringBytes()), 10, 64)
+	}
+	return v.Uint64()
+}
+
+// TimeRange is a byte range with its offsets kept as strings.
+//
+// Deprecated: Format.InitRange and Format.IndexRange are now parsed as a ByteRange.
 type TimeRange struct {
 	Start string `json:"start"`
 	End   string `json:"end"`
 }
 
+// ByteRange parses the offsets of the range.
+func (r TimeRange) ByteRange() (ByteRange, error) {
+	start, err := strconv.ParseUint(r.Start, 10, 64)
+	if err != nil {
+		return ByteRange{}, err
+	}
+	end, err := strconv.ParseUint(r.End, 10, 64)
+	if err != nil {
+		return ByteRange{}, err
+	}
+	if end < start {
+		return ByteRange{}, fmt.Errorf("range end %d is before start %d", end, start)
+	}
+	return ByteRange{Start: start, End: end}, nil
+}
+
+// Deprecated: Use ParseByteRangeJSON.
 func ParseTimeRangeJSON(v *fastjson.Value) TimeRange {
 	return TimeRange{
 		Start: string(v.GetStringBytes("start")),
@@ -73,8 +290,8 @@ func ParseFormatJSON(v *fastjson.Value) Format {
 	var format Format
 
 	format.AverageBitrate = v.GetUint("averageBitrate")
-	format.ApproxDurationMs = bytesutil.String(v.GetStringBytes("approxDurationMs"))
-	format.ContentLength = bytesutil.String(v.GetStringBytes("contentLength"))
+	format.ApproxDurationMs = stringOrNumberJSON(v.Get("approxDurationMs"))
+	format.ContentLength = stringOrNumberJSON(v.Get("contentLength"))
 	format.Bitrate = v.GetUint("bitrate")
 
 	if u := v.GetStringBytes("url"); len(u) > 0 {
@@ -94,7 +311,9 @@ func ParseFormatJSON(v *fastjson.Value) Format {
 	format.Width = v.GetUint("width")
 	format.Height = v.GetUint("height")
 
-	format.FPS = func(u uint) *uint { return &u }(v.GetUint("fps"))
+	if v.Exists("fps") {
+		format.FPS = func(u uint) *uint { return &u }(v.GetUint("fps"))
+	}
 
 	if colorInfo := v.Get("colorInfo"); colorInfo != nil {
 		format.ColorInfo = func(i ColorInfo) *ColorInfo { return &i }(ParseColorInfoJSON(colorInfo))
@@ -104,24 +323,32 @@ func ParseFormatJSON(v *fastjson.Value) Format {
 		format.AudioQuality = func(s string) *string { return &s }(string(audioQuality))
 	}
 
-	format.AudioChannels = func(u uint) *uint { return &u }(v.GetUint("audioChannels"))
+	if v.Exists("audioChannels") {
+		format.AudioChannels = func(u uint) *uint { return &u }(v.GetUint("audioChannels"))
+	}
 
-	if audioSampleRate := v.GetStringBytes("audioSampleRate"); len(audioSampleRate) > 0 {
-		format.AudioSampleRate = func(s string) *string { return &s }(string(audioSampleRate))
+	if audioSampleRate := stringOrNumberJSON(v.Get("audioSampleRate")); audioSampleRate != "" {
+		format.AudioSampleRate = func(s string) *string { return &s }(audioSampleRate)
 	}
 
 	if initRange := v.Get("initRange"); initRange != nil {
-		format.InitRange = func(t TimeRange) *TimeRange { return &t }(ParseTimeRangeJSON(initRange))
+		if r, err := ParseByteRangeJSON(initRange); err == nil {
+			format.InitRange = &r
+		}
 	}
 
 	if indexRange := v.Get("indexRange"); indexRange != nil {
-		format.IndexRange = func(t TimeRange) *TimeRange { return &t }(ParseTimeRangeJSON(indexRange))
+		if r, err := ParseByteRangeJSON(indexRange); err == nil {
+			format.IndexRange = &r
+		}
 	}
 
 	format.HighReplication = v.GetBool("highReplication")
-	format.LastModified = bytesutil.String(v.GetStringBytes("lastModified"))
+	format.LastModified = stringOrNumberJSON(v.Get("lastModified"))
 
 	format.ProjectionType = bytesutil.String(v.GetStringBytes("projectionType"))
 
+	format.cacheMedia()
+
 	return format
 }
@@ -0,0 +1,85 @@
+package youtube
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fastjson"
+	"testing"
+	"time"
+)
+
+func TestParseByteRangeJSON(t *testing.T) {
+	for _, buf := range []string{`{"start":"0","end":"219"}`, `{"start":0,"end":219}`} {
+		r, err := ParseByteRangeJSON(fastjson.MustParse(buf))
+		require.NoError(t, err)
+		require.Equal(t, ByteRange{Start: 0, End: 219}, r)
+		require.EqualValues(t, 220, r.Len())
+		require.Equal(t, "0-219", r.String())
+	}
+
+	for _, buf := range []string{`{"start":"10","end":"9"}`, `{"start":"a","end":"9"}`, `{"end":9}`} {
+		_, err := ParseByteRangeJSON(fastjson.MustParse(buf))
+		require.Error(t, err)
+	}
+
+	format := loadTestStreams(t).Formats().ByITag(137)[0]
+	require.Equal(t, &ByteRange{Start: 0, End: 740}, format.InitRange)
+	require.Equal(t, &ByteRange{Start: 741, End: 1419}, format.IndexRange)
+
+	buf, err := json.Marshal(format.InitRange)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"start":0,"end":740}`, string(buf))
+
+	var decoded ByteRange
+	require.NoError(t, json.Unmarshal([]byte(`{"start":"0","end":"740"}`), &decoded))
+	require.Equal(t, *format.InitRange, decoded)
+	require.Error(t, json.Unmarshal([]byte(`{"start":"740","end":"0"}`), &decoded))
+
+	r, err := TimeRange{Start: "741", End: "1419"}.ByteRange()
+	require.NoError(t, err)
+	require.Equal(t, *format.IndexRange, r)
+}
+
+func TestFormatTypedFields(t *testing.T) {
+	format := loadTestStreams(t).Formats().ByITag(140)[0]
+
+	require.EqualValues(t, 4543022, format.Size())
+	require.Equal(t, 280705*time.Millisecond, format.ApproxDuration())
+	require.EqualValues(t, 44100, format.SampleRate())
+	require.Equal(t, time.Unix(1575451553, 830744000), format.ModTime())
+
+	require.Zero(t, Format{}.Size())
+	require.Zero(t, Format{}.ApproxDuration())
+	require.Zero(t, Format{}.SampleRate())
+	require.True(t, Format{}.ModTime().IsZero())
+}
+
+func TestFormatJSON(t *testing.T) {
+	format := loadTestStreams(t).Formats().ByITag(140)[0]
+
+	buf, err := json.Marshal(format)
+	require.NoError(t, err)
+
+	v := fastjson.MustParseBytes(buf)
+	require.EqualValues(t, 4543022, v.GetUint64("contentLength"))
+	require.EqualValues(t, 280705, v.GetUint64("approxDurationMs"))
+	require.EqualValues(t, 44100, v.GetUint64("audioSampleRate"))
+	require.EqualValues(t, 1575451553830744, v.GetUint64("lastModified"))
+	require.EqualValues(t, 140, v.GetUint("itag"))
+	require.EqualValues(t, 632, v.GetUint64("indexRange", "start"))
+
+	var decoded Format
+	require.NoError(t, json.Unmarshal(buf, &decoded))
+	require.Equal(t, format, decoded)
+	require.Equal(t, format, ParseFormatJSON(v))
+
+	require.NoError(t, json.Unmarshal([]byte(`{"itag":251,"contentLength":"4721398","audioSampleRate":"48000"}`), &decoded))
+	require.EqualValues(t, 251, decoded.ITag)
+	require.EqualValues(t, 4721398, decoded.Size())
+	require.EqualValues(t, 48000, decoded.SampleRate())
+	require.Empty(t, decoded.ApproxDurationMs)
+
+	buf, err = json.Marshal(Format{ITag: 18})
+	require.NoError(t, err)
+	require.False(t, fastjson.MustParseBytes(buf).Exists("contentLength"))
+}
@@ -1,7 +1,7 @@
 package youtube
 
 import (
-	"sort"
+	"strings"
 )
 
 var AudioQuality = map[string]int{
@@ -10,31 +10,125 @@ var AudioQuality = map[string]int{
 	"AUDIO_QUALITY_HIGH":   2,
 }
 
+// VideoQuality ranks the quality labels YouTube gives formats from lowest to highest.
+//
+// Deprecated: Formats are ranked by VideoRanking, which weighs resolution, frame rate, dynamic range, codec, and
+// bitrate instead of quality labels.
 var VideoQuality = map[string]int{
-	"tiny":   0,
-	"low":    1,
-	"medium": 2,
-	"large":  3,
-	"hd1440": 4,
-	"hd2160": 5,
+	"tiny":    0,
+	"low":     1,
+	"small":   1,
+	"medium":  2,
+	"large":   3,
+	"hd720":   4,
+	"hd1080":  5,
+	"hd1440":  6,
+	"hd2160":  7,
+	"highres": 8,
 }
 
+// Formats is a list of formats. Methods on Formats that narrow down or reorder the list never modify it in place, and
+// instead return a new list, so that they may be safely chained from multiple goroutines.
 type Formats []Format
 
-func (f Formats) VideoOnly() Formats {
+// Where returns the formats for which keep returns true.
+func (f Formats) Where(keep func(f Format) bool) Formats {
+	var filtered Formats
+	for _, format := range f {
+		if keep(format) {
+			filtered = append(filtered, format)
+		}
+	}
+	return filtered
+}
+
+// ByITag returns the formats with any of the given itags.
+func (f Formats) ByITag(itags ...uint) Formats {
+	return f.Where(func(f Format) bool {
+		for _, itag := range itags {
+			if f.ITag == itag {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// ByMIME returns the formats of the given media type, e.g. 'audio/mp4'. Given only a top-level type, e.g. 'audio',
+// it returns the formats of any of its subtypes.
+func (f Formats) ByMIME(mime string) Formats {
+	return f.Where(func(f Format) bool {
+		typ := f.parsedMedia().Type
+
+		if strings.Contains(mime, "/") {
+			return typ == mime
+		}
+
+		return strings.HasPrefix(typ, mime+"/")
+	})
+}
+
+// ByCodec returns the formats encoded with the given codec, given either as a full RFC 6381 codec string such as
+// 'avc1.640028', or as just its four-character code or name such as 'avc1' or 'opus'.
+func (f Formats) ByCodec(codec string) Formats {
+	return f.Where(func(f Format) bool {
+		for _, c := range f.parsedMedia().Codecs {
+			if c.Raw == codec || c.Name == codec {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// MaxHeight returns the formats with video that is at most height pixels tall.
+func (f Formats) MaxHeight(height uint) Formats {
+	return f.Where(func(f Format) bool { return hasVideo(f) && f.Height <= height })
+}
+
+// MinBitrate returns the formats with a bitrate of at least bitrate bits per second.
+func (f Formats) MinBitrate(bitrate uint) Formats {
+	return f.Where(func(f Format) bool { return f.Bitrate >= bitrate })
+}
+
+// WithAudio returns the formats that carry audio, including premuxed formats.
+func (f Formats) WithAudio() Formats {
+	return FilterAudioStreams(f)
+}
+
+// WithVideo returns the formats that carry video, including premuxed formats.
+func (f Formats) WithVideo() Formats {
 	return FilterVideoStreams(f)
 }
 
+// HDR returns the formats with video of a high dynamic range.
+func (f Formats) HDR() Formats {
+	return f.Where(formatHDR)
+}
+
+// Progressive returns the premuxed formats that carry both video and audio.
+func (f Formats) Progressive() Formats {
+	return f.Where(func(f Format) bool { return hasVideo(f) && hasAudio(f) })
+}
+
+// VideoOnly returns the formats that carry video but no audio.
+func (f Formats) VideoOnly() Formats {
+	return f.Where(func(f Format) bool { return hasVideo(f) && !hasAudio(f) })
+}
+
+// AudioOnly returns the formats that carry audio but no video.
 func (f Formats) AudioOnly() Formats {
-	return FilterAudioStreams(f)
+	return f.Where(func(f Format) bool { return hasAudio(f) && !hasVideo(f) })
 }
 
+// SortByVideoQuality returns a copy of the formats sorted from highest to lowest video quality.
 func (f Formats) SortByVideoQuality() Formats {
-	return SortByVideoQuality(f)
+	return SortByVideoQuality(append(Formats(nil), f...))
 }
 
+// SortByAudioQuality returns a copy of the formats sorted from highest to lowest audio quality.
 func (f Formats) SortByAudioQuality() Formats {
-	return SortByAudioQuality(f)
+	return SortByAudioQuality(append(Formats(nil), f...))
 }
 
 func (f Formats) BestVideo() (Format, bool) {
@@ -46,74 +140,29 @@ func (f Formats) BestAudio() (Format, bool) {
 }
 
 func SearchForBestVideoQuality(formats Formats) (Format, bool) {
-	formats = SortByVideoQuality(formats)
-	if len(formats) == 0 {
-		return Format{}, false
-	}
-
-	return formats[0], true
+	return VideoRanking.Best(formats)
 }
 
 func SearchForBestAudioQuality(formats Formats) (Format, bool) {
-	formats = SortByAudioQuality(formats)
-	if len(formats) == 0 {
-		return Format{}, false
-	}
-
-	return formats[0], true
+	return AudioRanking.Best(formats)
 }
 
+// FilterAudioStreams returns the formats that carry audio, including premuxed formats.
 func FilterAudioStreams(formats Formats) Formats {
-	filtered := formats[:0]
-	for _, format := range formats {
-		if format.AudioQuality == nil {
-			continue
-		}
-		filtered = append(filtered, format)
-	}
-	return filtered
+	return formats.Where(hasAudio)
 }
 
+// FilterVideoStreams returns the formats that carry video, including premuxed formats.
 func FilterVideoStreams(formats Formats) Formats {
-	filtered := formats[:0]
-	for _, format := range formats {
-		if format.FPS == nil {
-			continue
-		}
-		filtered = append(filtered, format)
-	}
-	return filtered
+	return formats.Where(hasVideo)
 }
 
+// SortByVideoQuality sorts formats in place from highest to lowest video quality as ranked by VideoRanking.
 func SortByVideoQuality(formats Formats) Formats {
-	sort.Slice(formats, func(i, j int) bool {
-		if formats[i].Bitrate > formats[j].Bitrate {
-			return true
-		}
-		return VideoQuality[formats[i].Quality] > VideoQuality[formats[j].Quality]
-	})
-
-	return formats
+	return VideoRanking.Sort(formats)
 }
 
+// SortByAudioQuality sorts formats in place from highest to lowest audio quality as ranked by AudioRanking.
 func SortByAudioQuality(formats Formats) Formats {
-	sort.Slice(formats, func(i, j int) bool {
-		if formats[i].Bitrate > formats[j].Bitrate {
-			return true
-		}
-
-		a, b := 0, 0
-
-		if ap := formats[i].AudioQuality; ap != nil {
-			a = AudioQuality[*ap]
-		}
-
-		if bp := formats[j].AudioQuality; bp != nil {
-			b = AudioQuality[*bp]
-		}
-
-		return a > b
-	})
-
-	return formats
+	return AudioRanking.Sort(formats)
 }
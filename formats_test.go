@@ -0,0 +1,105 @@
+package youtube
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+func itagsOf(formats Formats) []uint {
+	var itags []uint
+	for _, f := range formats {
+		itags = append(itags, f.ITag)
+	}
+	return itags
+}
+
+func TestFormatsQueries(t *testing.T) {
+	formats := loadTestStreams(t).Formats()
+	original := append(Formats(nil), formats...)
+
+	require.Equal(t, []uint{140, 249, 250, 251}, itagsOf(formats.AudioOnly()))
+	require.Equal(t, []uint{137, 248, 399, 337, 302, 136, 247, 135}, itagsOf(formats.VideoOnly()))
+	require.Equal(t, []uint{18, 22}, itagsOf(formats.Progressive()))
+	require.Equal(t, []uint{18, 22, 140, 249, 250, 251}, itagsOf(formats.WithAudio()))
+	require.Equal(t, []uint{18, 22, 137, 248, 399, 337, 302, 136, 247, 135}, itagsOf(formats.WithVideo()))
+	require.Equal(t, []uint{337}, itagsOf(formats.HDR()))
+
+	require.Equal(t, []uint{22, 140}, itagsOf(formats.ByITag(140, 22, 1)))
+	require.Equal(t, []uint{140}, itagsOf(formats.ByMIME("audio/mp4")))
+	require.Equal(t, []uint{140, 249, 250, 251}, itagsOf(formats.ByMIME("audio")))
+	require.Equal(t, []uint{248, 337, 302, 247}, itagsOf(formats.ByCodec("vp9")))
+	require.Equal(t, []uint{337}, itagsOf(formats.ByCodec("vp9.2")))
+	require.Equal(t, []uint{18, 22, 140}, itagsOf(formats.ByCodec("mp4a")))
+
+	require.Equal(t, []uint{136, 135}, itagsOf(formats.VideoOnly().MaxHeight(720).ByCodec("avc1")))
+	require.Equal(t, []uint{248, 337, 302}, itagsOf(formats.VideoOnly().MinBitrate(2500000).ByMIME("video/webm")))
+	require.Empty(t, formats.AudioOnly().MaxHeight(1080))
+
+	require.Equal(t, []uint{251, 140, 250, 249}, itagsOf(formats.AudioOnly().SortByAudioQuality()))
+
+	// None of the queries above should have modified the formats they were called on.
+
+	require.Equal(t, original, formats)
+}
+
+// videoInfoTransport serves the stream info of the player responses it holds in the url-encoded form returned by
+// YouTube's get_video_info endpoint.
+type videoInfoTransport struct {
+	responses map[StreamID][]byte
+}
+
+func (t videoInfoTransport) DownloadBytesDeadline(dst []byte, uri string, deadline time.Time) ([]byte, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return dst, err
+	}
+
+	response, exists := t.responses[StreamID(u.Query().Get("video_id"))]
+	if u.Path != "/get_video_info" || !exists {
+		return dst, fmt.Errorf("unexpected request to %q", uri)
+	}
+
+	return append(dst, url.Values{"status": {"ok"}, "player_response": {string(response)}}.Encode()...), nil
+}
+
+func TestFormatsConcurrentQueries(t *testing.T) {
+	response, err := ioutil.ReadFile("testdata/player_response.json")
+	require.NoError(t, err)
+
+	client := WrapClient(videoInfoTransport{responses: map[StreamID][]byte{"pAsDzfbLM8Y": response}})
+
+	embedded, err := client.LoadEmbedPlayerStreams("pAsDzfbLM8Y")
+	require.NoError(t, err)
+
+	for _, streams := range []Streams{loadTestStreams(t), embedded} {
+		player := Player{Streams: streams}
+
+		var wg sync.WaitGroup
+
+		results := make([][]uint, 8)
+
+		for i := range results {
+			wg.Add(1)
+
+			go func(i int) {
+				defer wg.Done()
+
+				formats := player.SourceFormats()
+				audio := formats.AudioOnly().SortByAudioQuality()
+
+				results[i] = append(itagsOf(audio), itagsOf(formats.VideoOnly().SortByVideoQuality())...)
+			}(i)
+		}
+
+		wg.Wait()
+
+		for _, result := range results {
+			require.Equal(t, []uint{251, 140, 250, 249, 337, 399, 248, 137, 302, 247, 136, 135}, result)
+		}
+	}
+}
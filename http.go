@@ -0,0 +1,94 @@
+package youtube
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// HTTPTransport is a Transport and RoundTripper that sends requests through a net/http client, which allows for
+// proxies, tracing, and custom TLS configurations to be set up using net/http. For example, players may be loaded
+// through client c using WrapClient(HTTPTransport{Client: c}).
+type HTTPTransport struct {
+	// The client requests are sent through. Defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+func (t HTTPTransport) DownloadBytes(dst []byte, url string) ([]byte, error) {
+	return t.DownloadBytesDeadline(dst, url, zeroTime)
+}
+
+func (t HTTPTransport) DownloadBytesTimeout(dst []byte, url string, timeout time.Duration) ([]byte, error) {
+	return t.DownloadBytesDeadline(dst, url, time.Now().Add(timeout))
+}
+
+// DownloadBytesDeadline downloads the contents of url, and appends them to dst. It returns a *StatusError should the
+// response have a non-2xx status code.
+func (t HTTPTransport) DownloadBytesDeadline(dst []byte, url string, deadline time.Time) ([]byte, error) {
+	return roundTripBytesDeadline(t, dst, url, nil, deadline)
+}
+
+// RoundTripDeadline sends req, and populates res with its response. The deadline is honored by sending req with a
+// context that is cancelled once the deadline passes.
+func (t HTTPTransport) RoundTripDeadline(req *Request, res *Response, deadline time.Time) error {
+	client := t.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	ctx := context.Background()
+
+	if !deadline.IsZero() {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+
+	method := req.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	var body io.Reader
+	if len(req.Body) > 0 {
+		body = bytes.NewReader(req.Body)
+	}
+
+	r, err := http.NewRequestWithContext(ctx, method, req.URL, body)
+	if err != nil {
+		return err
+	}
+
+	for key, values := range req.Header {
+		r.Header[key] = append([]string(nil), values...)
+	}
+
+	w, err := client.Do(r)
+	if err != nil {
+		return err
+	}
+	defer w.Body.Close()
+
+	res.StatusCode = w.StatusCode
+	res.Header = w.Header
+
+	// Report the content length the same way other transports do, as net/http may strip it from the headers.
+
+	if w.ContentLength >= 0 && res.Header.Get("Content-Length") == "" {
+		res.Header.Set("Content-Length", strconv.FormatInt(w.ContentLength, 10))
+	}
+
+	buf := bytes.NewBuffer(res.Body)
+	if w.ContentLength > 0 {
+		buf.Grow(int(w.ContentLength))
+	}
+
+	_, err = buf.ReadFrom(w.Body)
+	res.Body = buf.Bytes()
+
+	return err
+}
@@ -0,0 +1,76 @@
+package youtube
+
+import (
+	"errors"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHTTPTransport(t *testing.T) {
+	srv := newEchoServer()
+	defer srv.Close()
+
+	transport := HTTPTransport{Client: srv.Client()}
+
+	res := Response{Body: []byte("prefix:")}
+
+	req := Request{
+		Method: http.MethodPost,
+		URL:    srv.URL + "/youtubei/v1/player",
+		Header: http.Header{"Accept-Language": {"en-US"}},
+		Body:   []byte(`{"videoId":"pAsDzfbLM8Y"}`),
+	}
+
+	require.NoError(t, transport.RoundTripDeadline(&req, &res, zeroTime))
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Equal(t, "POST", res.Header.Get("X-Method"))
+	require.Equal(t, "en-US", res.Header.Get("X-Language"))
+	require.Equal(t, "25", res.Header.Get("Content-Length"))
+	require.Equal(t, `prefix:{"videoId":"pAsDzfbLM8Y"}`, string(res.Body))
+
+	buf, err := transport.DownloadBytes([]byte("prefix:"), srv.URL)
+	require.NoError(t, err)
+	require.Equal(t, "prefix:", string(buf))
+
+	_, err = transport.DownloadBytes(nil, srv.URL+"/expired")
+
+	var status *StatusError
+	require.True(t, errors.As(err, &status))
+	require.Equal(t, http.StatusForbidden, status.StatusCode)
+
+	// Cookies are sent and stored by clients wrapping the transport.
+
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+
+	client := WrapClient(transport)
+	client.Jar = jar
+
+	_, err = client.DownloadBytesDeadline(nil, srv.URL+"/login", zeroTime)
+	require.NoError(t, err)
+
+	res = Response{}
+
+	require.NoError(t, client.RoundTripDeadline(&Request{URL: srv.URL}, &res, zeroTime))
+	require.Equal(t, "SID=session", res.Header.Get("X-Cookies"))
+}
+
+func TestHTTPTransportDeadline(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	start := time.Now()
+
+	_, err := HTTPTransport{}.DownloadBytesTimeout(nil, srv.URL, 50*time.Millisecond)
+	require.Error(t, err)
+	require.True(t, time.Since(start) < 500*time.Millisecond)
+}
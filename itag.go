@@ -1,442 +1,870 @@
 package youtube
 
+import (
+	"sync"
+)
+
+// ITag describes the container and encoding of the formats YouTube serves under a given itag.
 type ITag struct {
-	Extension     string
-	Resolution    string
+	// File extension conventionally used for the format, e.g. 'mp4', 'm4a', or 'webm'.
+	Extension string
+
+	// Container of the format, e.g. 'mp4', 'webm', 'flv', '3gp', or 'ts'.
+	Container string
+
+	// Nominal resolution of the format's video, e.g. '1080p'.
+	Resolution string
+
+	// Human-readable name of the video encoding, e.g. 'H.264', and its short codec name as
+	// reported by Codec.Name, e.g. 'avc1'.
 	VideoEncoding string
+	VideoCodec    string
+
+	// Human-readable name of the audio encoding, e.g. 'aac', and its short codec name as
+	// reported by Codec.Name, e.g. 'mp4a'.
 	AudioEncoding string
-	AudioBitrate  int
-	FPS           int
+	AudioCodec    string
+
+	// Nominal audio bitrate in kilobits per second.
+	AudioBitrate int
+
+	// Number of audio channels, if not stereo.
+	AudioChannels int
+
+	// Frame rate of the video, if higher than 30 frames per second.
+	FPS int
+
+	// Whether the video has a high dynamic range.
+	HDR bool
+
+	// Whether the video is stereoscopic 3D.
+	ThreeD bool
+
+	// Whether the format is served only for livestreams over HLS.
+	Live bool
+
+	// Whether the format is only served as a video-only or audio-only DASH stream.
+	DASH bool
 }
 
-var ITags = [...]ITag{
-	5: {
-		Extension:     "flv",
-		Resolution:    "240p",
-		VideoEncoding: "Sorenson H.283",
-		AudioEncoding: "mp3",
-		AudioBitrate:  64,
-	},
-	6: {
-		Extension:     "flv",
-		Resolution:    "270p",
-		VideoEncoding: "Sorenson H.263",
-		AudioEncoding: "mp3",
-		AudioBitrate:  64,
-	},
-	13: {
-		Extension:     "3gp",
-		VideoEncoding: "MPEG-4 Visual",
-		AudioEncoding: "aac",
-	},
-	17: {
-		Extension:     "3gp",
-		Resolution:    "144p",
-		VideoEncoding: "MPEG-4 Visual",
-		AudioEncoding: "aac",
-		AudioBitrate:  24,
-	},
-	18: {
-		Extension:     "mp4",
-		Resolution:    "360p",
-		VideoEncoding: "H.264",
-		AudioEncoding: "aac",
-		AudioBitrate:  96,
-	},
-	22: {
-		Extension:     "mp4",
-		Resolution:    "720p",
-		VideoEncoding: "H.264",
-		AudioEncoding: "aac",
-		AudioBitrate:  192,
-	},
-	34: {
-		Extension:     "flv",
-		Resolution:    "480p",
-		VideoEncoding: "H.264",
-		AudioEncoding: "aac",
-		AudioBitrate:  128,
-	},
-	35: {
-		Extension:     "flv",
-		Resolution:    "360p",
-		VideoEncoding: "H.264",
-		AudioEncoding: "aac",
-		AudioBitrate:  128,
-	},
-	36: {
-		Extension:     "3gp",
-		Resolution:    "240p",
-		VideoEncoding: "MPEG-4 Visual",
-		AudioEncoding: "aac",
-		AudioBitrate:  36,
-	},
-	37: {
-		Extension:     "mp4",
-		Resolution:    "1080p",
-		VideoEncoding: "H.264",
-		AudioEncoding: "aac",
-		AudioBitrate:  192,
-	},
-	38: {
-		Extension:     "mp4",
-		Resolution:    "3072p",
-		VideoEncoding: "H.264",
-		AudioEncoding: "aac",
-		AudioBitrate:  192,
-	},
-	43: {
-		Extension:     "webm",
-		Resolution:    "360p",
-		VideoEncoding: "VP8",
-		AudioEncoding: "vorbis",
-		AudioBitrate:  128,
-	},
-	44: {
-		Extension:     "webm",
-		Resolution:    "480p",
-		VideoEncoding: "VP8",
-		AudioEncoding: "vorbis",
-		AudioBitrate:  128,
-	},
-	45: {
-		Extension:     "webm",
-		Resolution:    "720p",
-		VideoEncoding: "VP8",
-		AudioEncoding: "vorbis",
-		AudioBitrate:  192,
-	},
-	46: {
-		Extension:     "webm",
-		Resolution:    "1080p",
-		VideoEncoding: "VP8",
-		AudioEncoding: "vorbis",
-		AudioBitrate:  192,
-	},
-	82: {
-		Extension:     "mp4",
-		Resolution:    "360p",
-		VideoEncoding: "H.264",
-		AudioBitrate:  96,
-	},
-	83: {
-		Extension:     "mp4",
-		Resolution:    "240p",
-		VideoEncoding: "H.264",
-		AudioEncoding: "aac",
-		AudioBitrate:  96,
-	},
-	84: {
-		Extension:     "mp4",
-		Resolution:    "720p",
-		VideoEncoding: "H.264",
-		AudioEncoding: "aac",
-		AudioBitrate:  192,
-	},
-	85: {
-		Extension:     "mp4",
-		Resolution:    "1080p",
-		VideoEncoding: "H.264",
-		AudioEncoding: "aac",
-		AudioBitrate:  192,
-	},
-	100: {
-		Extension:     "webm",
-		Resolution:    "360p",
-		VideoEncoding: "VP8",
-		AudioEncoding: "vorbis",
-		AudioBitrate:  128,
-	},
-	101: {
-		Extension:     "webm",
-		Resolution:    "360p",
-		VideoEncoding: "VP8",
-		AudioEncoding: "vorbis",
-		AudioBitrate:  192,
-	},
-	102: {
-		Extension:     "webm",
-		Resolution:    "720p",
-		VideoEncoding: "VP8",
-		AudioEncoding: "vorbis",
-		AudioBitrate:  192,
-	},
-	133: {
-		Extension:     "mp4",
-		Resolution:    "240p",
-		VideoEncoding: "H.264",
-	},
-	134: {
-		Extension:     "mp4",
-		Resolution:    "360p",
-		VideoEncoding: "H.264",
-	},
-	135: {
-		Extension:     "mp4",
-		Resolution:    "480p",
-		VideoEncoding: "H.264",
-	},
-	136: {
-		Extension:     "mp4",
-		Resolution:    "720p",
-		VideoEncoding: "H.264",
-	},
-	137: {
-		Extension:     "mp4",
-		Resolution:    "1080p",
-		VideoEncoding: "H.264",
-	},
-	138: {
-		Extension:     "mp4",
-		Resolution:    "2160p",
-		VideoEncoding: "H.264",
-	},
-	160: {
-		Extension:     "mp4",
-		Resolution:    "144p",
-		VideoEncoding: "H.264",
-	},
-	242: {
-		Extension:     "webm",
-		Resolution:    "240p",
-		VideoEncoding: "VP9",
-	},
-	243: {
-		Extension:     "webm",
-		Resolution:    "360p",
-		VideoEncoding: "VP9",
-	},
-	244: {
-		Extension:     "webm",
-		Resolution:    "480p",
-		VideoEncoding: "VP9",
-	},
-	247: {
-		Extension:     "webm",
-		Resolution:    "720p",
-		VideoEncoding: "VP9",
-	},
-	248: {
-		Extension:     "webm",
-		Resolution:    "1080p",
-		VideoEncoding: "VP9",
-		AudioBitrate:  9,
-	},
-	264: {
-		Extension:     "mp4",
-		Resolution:    "1440p",
-		VideoEncoding: "H.264",
-	},
-	266: {
-		Extension:     "mp4",
-		Resolution:    "2160p",
-		VideoEncoding: "H.264",
-	},
-	271: {
-		Extension:     "webm",
-		Resolution:    "1440p",
-		VideoEncoding: "VP9",
-	},
-	272: {
-		Extension:     "webm",
-		Resolution:    "2160p",
-		VideoEncoding: "VP9",
-	},
-	278: {
-		Extension:     "webm",
-		Resolution:    "144p",
-		VideoEncoding: "VP9",
-	},
-	298: {
-		Extension:     "mp4",
-		Resolution:    "720p",
-		VideoEncoding: "H.264",
-		FPS:           60,
-	},
-	299: {
-		Extension:     "mp4",
-		Resolution:    "1080p",
-		VideoEncoding: "H.264",
-		FPS:           60,
-	},
-	302: {
-		Extension:     "webm",
-		Resolution:    "720p",
-		VideoEncoding: "VP9",
-		FPS:           60,
-	},
-	303: {
-		Extension:     "webm",
-		Resolution:    "1080p",
-		VideoEncoding: "VP9",
-		FPS:           60,
-	},
-	308: {
-		Extension:     "webm",
-		Resolution:    "1440p",
-		VideoEncoding: "VP9",
-		FPS:           60,
-	},
-	313: {
-		Extension:     "webm",
-		Resolution:    "2160p",
-		VideoEncoding: "VP9",
-	},
-	315: {
-		Extension:     "webm",
-		Resolution:    "2160p",
-		VideoEncoding: "VP9",
-		FPS:           60,
-	},
-	139: {
-		Extension:     "mp4",
-		AudioEncoding: "aac",
-		AudioBitrate:  48,
-	},
-	140: {
-		Extension:     "mp4",
-		AudioEncoding: "aac",
-		AudioBitrate:  128,
-	},
-	141: {
-		Extension:     "mp4",
-		AudioEncoding: "aac",
-		AudioBitrate:  256,
-	},
-	171: {
-		Extension:     "webm",
-		AudioEncoding: "vorbis",
-		AudioBitrate:  128,
-	},
-	172: {
-		Extension:     "webm",
-		AudioEncoding: "vorbis",
-		AudioBitrate:  192,
-	},
-	249: {
-		Extension:     "webm",
-		AudioEncoding: "opus",
-		AudioBitrate:  50,
-	},
-	250: {
-		Extension:     "webm",
-		AudioEncoding: "opus",
-		AudioBitrate:  70,
-	},
-	251: {
-		Extension:     "webm",
-		AudioEncoding: "opus",
-		AudioBitrate:  160,
-	},
-	92: {
-		Extension:     "ts",
-		Resolution:    "240p",
-		VideoEncoding: "H.264",
-		AudioEncoding: "aac",
-		AudioBitrate:  48,
-	},
-	93: {
-		Extension:     "ts",
-		Resolution:    "480p",
-		VideoEncoding: "H.264",
-		AudioEncoding: "aac",
-		AudioBitrate:  128,
-	},
-	94: {
-		Extension:     "ts",
-		Resolution:    "720p",
-		VideoEncoding: "H.264",
-		AudioEncoding: "aac",
-		AudioBitrate:  128,
-	},
-	95: {
-		Extension:     "ts",
-		Resolution:    "1080p",
-		VideoEncoding: "H.264",
-		AudioEncoding: "aac",
-		AudioBitrate:  256,
-	},
-	96: {
-		Extension:     "ts",
-		Resolution:    "720p",
-		VideoEncoding: "H.264",
-		AudioEncoding: "aac",
-		AudioBitrate:  256,
-	},
-	120: {
-		Extension:     "flv",
-		Resolution:    "720p",
-		VideoEncoding: "H.264",
-		AudioEncoding: "aac",
-		AudioBitrate:  128,
-	},
-	127: {
-		Extension:     "ts",
-		AudioEncoding: "aac",
-		AudioBitrate:  96,
-	},
-	128: {
-		Extension:     "ts",
-		AudioEncoding: "aac",
-		AudioBitrate:  96,
-	},
-	132: {
-		Extension:     "ts",
-		Resolution:    "240p",
-		VideoEncoding: "H.264",
-		AudioEncoding: "aac",
-		AudioBitrate:  48,
-	},
-	151: {
-		Extension:     "ts",
-		Resolution:    "720p",
-		VideoEncoding: "H.264",
-		AudioEncoding: "aac",
-		AudioBitrate:  24,
-	},
-	394: {
-		Extension:     "mp4",
-		Resolution:    "144p",
-		VideoEncoding: "AV1",
-	},
-	395: {
-		Extension:     "mp4",
-		Resolution:    "240p",
-		VideoEncoding: "AV1",
-	},
-	396: {
-		Extension:     "mp4",
-		Resolution:    "360p",
-		VideoEncoding: "AV1",
-	},
-	397: {
-		Extension:     "mp4",
-		Resolution:    "480p",
-		VideoEncoding: "AV1",
-	},
-	398: {
-		Extension:     "mp4",
-		Resolution:    "720p",
-		VideoEncoding: "AV1",
-	},
-	399: {
-		Extension:     "mp4",
-		Resolution:    "1080p",
-		VideoEncoding: "AV1",
-	},
-	400: {
-		Extension:     "mp4",
-		Resolution:    "1440p",
-		VideoEncoding: "AV1",
-	},
-	401: {
-		Extension:     "mp4",
-		Resolution:    "2160p",
-		VideoEncoding: "AV1",
-	},
-	402: {
-		Extension:     "mp4",
-		Resolution:    "2880p",
-		VideoEncoding: "AV1",
-	},
+var (
+	itagsMu sync.RWMutex
+	itags   = map[uint]ITag{
+		5: {
+			Extension:     "flv",
+			Container:     "flv",
+			Resolution:    "240p",
+			VideoEncoding: "Sorenson H.263",
+			VideoCodec:    "flv1",
+			AudioEncoding: "mp3",
+			AudioCodec:    "mp3",
+			AudioBitrate:  64,
+		},
+		6: {
+			Extension:     "flv",
+			Container:     "flv",
+			Resolution:    "270p",
+			VideoEncoding: "Sorenson H.263",
+			VideoCodec:    "flv1",
+			AudioEncoding: "mp3",
+			AudioCodec:    "mp3",
+			AudioBitrate:  64,
+		},
+		13: {
+			Extension:     "3gp",
+			Container:     "3gp",
+			VideoEncoding: "MPEG-4 Visual",
+			VideoCodec:    "mp4v",
+			AudioEncoding: "aac",
+			AudioCodec:    "mp4a",
+		},
+		17: {
+			Extension:     "3gp",
+			Container:     "3gp",
+			Resolution:    "144p",
+			VideoEncoding: "MPEG-4 Visual",
+			VideoCodec:    "mp4v",
+			AudioEncoding: "aac",
+			AudioCodec:    "mp4a",
+			AudioBitrate:  24,
+		},
+		18: {
+			Extension:     "mp4",
+			Container:     "mp4",
+			Resolution:    "360p",
+			VideoEncoding: "H.264",
+			VideoCodec:    "avc1",
+			AudioEncoding: "aac",
+			AudioCodec:    "mp4a",
+			AudioBitrate:  96,
+		},
+		22: {
+			Extension:     "mp4",
+			Container:     "mp4",
+			Resolution:    "720p",
+			VideoEncoding: "H.264",
+			VideoCodec:    "avc1",
+			AudioEncoding: "aac",
+			AudioCodec:    "mp4a",
+			AudioBitrate:  192,
+		},
+		34: {
+			Extension:     "flv",
+			Container:     "flv",
+			Resolution:    "480p",
+			VideoEncoding: "H.264",
+			VideoCodec:    "avc1",
+			AudioEncoding: "aac",
+			AudioCodec:    "mp4a",
+			AudioBitrate:  128,
+		},
+		35: {
+			Extension:     "flv",
+			Container:     "flv",
+			Resolution:    "360p",
+			VideoEncoding: "H.264",
+			VideoCodec:    "avc1",
+			AudioEncoding: "aac",
+			AudioCodec:    "mp4a",
+			AudioBitrate:  128,
+		},
+		36: {
+			Extension:     "3gp",
+			Container:     "3gp",
+			Resolution:    "240p",
+			VideoEncoding: "MPEG-4 Visual",
+			VideoCodec:    "mp4v",
+			AudioEncoding: "aac",
+			AudioCodec:    "mp4a",
+			AudioBitrate:  36,
+		},
+		37: {
+			Extension:     "mp4",
+			Container:     "mp4",
+			Resolution:    "1080p",
+			VideoEncoding: "H.264",
+			VideoCodec:    "avc1",
+			AudioEncoding: "aac",
+			AudioCodec:    "mp4a",
+			AudioBitrate:  192,
+		},
+		38: {
+			Extension:     "mp4",
+			Container:     "mp4",
+			Resolution:    "3072p",
+			VideoEncoding: "H.264",
+			VideoCodec:    "avc1",
+			AudioEncoding: "aac",
+			AudioCodec:    "mp4a",
+			AudioBitrate:  192,
+		},
+		43: {
+			Extension:     "webm",
+			Container:     "webm",
+			Resolution:    "360p",
+			VideoEncoding: "VP8",
+			VideoCodec:    "vp8",
+			AudioEncoding: "vorbis",
+			AudioCodec:    "vorbis",
+			AudioBitrate:  128,
+		},
+		44: {
+			Extension:     "webm",
+			Container:     "webm",
+			Resolution:    "480p",
+			VideoEncoding: "VP8",
+			VideoCodec:    "vp8",
+			AudioEncoding: "vorbis",
+			AudioCodec:    "vorbis",
+			AudioBitrate:  128,
+		},
+		45: {
+			Extension:     "webm",
+			Container:     "webm",
+			Resolution:    "720p",
+			VideoEncoding: "VP8",
+			VideoCodec:    "vp8",
+			AudioEncoding: "vorbis",
+			AudioCodec:    "vorbis",
+			AudioBitrate:  192,
+		},
+		46: {
+			Extension:     "webm",
+			Container:     "webm",
+			Resolution:    "1080p",
+			VideoEncoding: "VP8",
+			VideoCodec:    "vp8",
+			AudioEncoding: "vorbis",
+			AudioCodec:    "vorbis",
+			AudioBitrate:  192,
+		},
+		82: {
+			Extension:     "mp4",
+			Container:     "mp4",
+			Resolution:    "360p",
+			VideoEncoding: "H.264",
+			VideoCodec:    "avc1",
+			AudioEncoding: "aac",
+			AudioCodec:    "mp4a",
+			AudioBitrate:  96,
+			ThreeD:        true,
+		},
+		83: {
+			Extension:     "mp4",
+			Container:     "mp4",
+			Resolution:    "240p",
+			VideoEncoding: "H.264",
+			VideoCodec:    "avc1",
+			AudioEncoding: "aac",
+			AudioCodec:    "mp4a",
+			AudioBitrate:  96,
+			ThreeD:        true,
+		},
+		84: {
+			Extension:     "mp4",
+			Container:     "mp4",
+			Resolution:    "720p",
+			VideoEncoding: "H.264",
+			VideoCodec:    "avc1",
+			AudioEncoding: "aac",
+			AudioCodec:    "mp4a",
+			AudioBitrate:  192,
+			ThreeD:        true,
+		},
+		85: {
+			Extension:     "mp4",
+			Container:     "mp4",
+			Resolution:    "1080p",
+			VideoEncoding: "H.264",
+			VideoCodec:    "avc1",
+			AudioEncoding: "aac",
+			AudioCodec:    "mp4a",
+			AudioBitrate:  192,
+			ThreeD:        true,
+		},
+		92: {
+			Extension:     "ts",
+			Container:     "ts",
+			Resolution:    "240p",
+			VideoEncoding: "H.264",
+			VideoCodec:    "avc1",
+			AudioEncoding: "aac",
+			AudioCodec:    "mp4a",
+			AudioBitrate:  48,
+			Live:          true,
+		},
+		93: {
+			Extension:     "ts",
+			Container:     "ts",
+			Resolution:    "480p",
+			VideoEncoding: "H.264",
+			VideoCodec:    "avc1",
+			AudioEncoding: "aac",
+			AudioCodec:    "mp4a",
+			AudioBitrate:  128,
+			Live:          true,
+		},
+		94: {
+			Extension:     "ts",
+			Container:     "ts",
+			Resolution:    "720p",
+			VideoEncoding: "H.264",
+			VideoCodec:    "avc1",
+			AudioEncoding: "aac",
+			AudioCodec:    "mp4a",
+			AudioBitrate:  128,
+			Live:          true,
+		},
+		95: {
+			Extension:     "ts",
+			Container:     "ts",
+			Resolution:    "1080p",
+			VideoEncoding: "H.264",
+			VideoCodec:    "avc1",
+			AudioEncoding: "aac",
+			AudioCodec:    "mp4a",
+			AudioBitrate:  256,
+			Live:          true,
+		},
+		96: {
+			Extension:     "ts",
+			Container:     "ts",
+			Resolution:    "720p",
+			VideoEncoding: "H.264",
+			VideoCodec:    "avc1",
+			AudioEncoding: "aac",
+			AudioCodec:    "mp4a",
+			AudioBitrate:  256,
+			Live:          true,
+		},
+		100: {
+			Extension:     "webm",
+			Container:     "webm",
+			Resolution:    "360p",
+			VideoEncoding: "VP8",
+			VideoCodec:    "vp8",
+			AudioEncoding: "vorbis",
+			AudioCodec:    "vorbis",
+			AudioBitrate:  128,
+			ThreeD:        true,
+		},
+		101: {
+			Extension:     "webm",
+			Container:     "webm",
+			Resolution:    "360p",
+			VideoEncoding: "VP8",
+			VideoCodec:    "vp8",
+			AudioEncoding: "vorbis",
+			AudioCodec:    "vorbis",
+			AudioBitrate:  192,
+			ThreeD:        true,
+		},
+		102: {
+			Extension:     "webm",
+			Container:     "webm",
+			Resolution:    "720p",
+			VideoEncoding: "VP8",
+			VideoCodec:    "vp8",
+			AudioEncoding: "vorbis",
+			AudioCodec:    "vorbis",
+			AudioBitrate:  192,
+			ThreeD:        true,
+		},
+		120: {
+			Extension:     "flv",
+			Container:     "flv",
+			Resolution:    "720p",
+			VideoEncoding: "H.264",
+			VideoCodec:    "avc1",
+			AudioEncoding: "aac",
+			AudioCodec:    "mp4a",
+			AudioBitrate:  128,
+			Live:          true,
+		},
+		127: {
+			Extension:     "ts",
+			Container:     "ts",
+			AudioEncoding: "aac",
+			AudioCodec:    "mp4a",
+			AudioBitrate:  96,
+			Live:          true,
+		},
+		128: {
+			Extension:     "ts",
+			Container:     "ts",
+			AudioEncoding: "aac",
+			AudioCodec:    "mp4a",
+			AudioBitrate:  96,
+			Live:          true,
+		},
+		132: {
+			Extension:     "ts",
+			Container:     "ts",
+			Resolution:    "240p",
+			VideoEncoding: "H.264",
+			VideoCodec:    "avc1",
+			AudioEncoding: "aac",
+			AudioCodec:    "mp4a",
+			AudioBitrate:  48,
+			Live:          true,
+		},
+		133: {
+			Extension:     "mp4",
+			Container:     "mp4",
+			Resolution:    "240p",
+			VideoEncoding: "H.264",
+			VideoCodec:    "avc1",
+			DASH:          true,
+		},
+		134: {
+			Extension:     "mp4",
+			Container:     "mp4",
+			Resolution:    "360p",
+			VideoEncoding: "H.264",
+			VideoCodec:    "avc1",
+			DASH:          true,
+		},
+		135: {
+			Extension:     "mp4",
+			Container:     "mp4",
+			Resolution:    "480p",
+			VideoEncoding: "H.264",
+			VideoCodec:    "avc1",
+			DASH:          true,
+		},
+		136: {
+			Extension:     "mp4",
+			Container:     "mp4",
+			Resolution:    "720p",
+			VideoEncoding: "H.264",
+			VideoCodec:    "avc1",
+			DASH:          true,
+		},
+		137: {
+			Extension:     "mp4",
+			Container:     "mp4",
+			Resolution:    "1080p",
+			VideoEncoding: "H.264",
+			VideoCodec:    "avc1",
+			DASH:          true,
+		},
+		138: {
+			Extension:     "mp4",
+			Container:     "mp4",
+			Resolution:    "2160p",
+			VideoEncoding: "H.264",
+			VideoCodec:    "avc1",
+			DASH:          true,
+		},
+		139: {
+			Extension:     "m4a",
+			Container:     "mp4",
+			AudioEncoding: "aac",
+			AudioCodec:    "mp4a",
+			AudioBitrate:  48,
+			DASH:          true,
+		},
+		140: {
+			Extension:     "m4a",
+			Container:     "mp4",
+			AudioEncoding: "aac",
+			AudioCodec:    "mp4a",
+			AudioBitrate:  128,
+			DASH:          true,
+		},
+		141: {
+			Extension:     "m4a",
+			Container:     "mp4",
+			AudioEncoding: "aac",
+			AudioCodec:    "mp4a",
+			AudioBitrate:  256,
+			DASH:          true,
+		},
+		151: {
+			Extension:     "ts",
+			Container:     "ts",
+			Resolution:    "720p",
+			VideoEncoding: "H.264",
+			VideoCodec:    "avc1",
+			AudioEncoding: "aac",
+			AudioCodec:    "mp4a",
+			AudioBitrate:  24,
+			Live:          true,
+		},
+		160: {
+			Extension:     "mp4",
+			Container:     "mp4",
+			Resolution:    "144p",
+			VideoEncoding: "H.264",
+			VideoCodec:    "avc1",
+			DASH:          true,
+		},
+		171: {
+			Extension:     "webm",
+			Container:     "webm",
+			AudioEncoding: "vorbis",
+			AudioCodec:    "vorbis",
+			AudioBitrate:  128,
+			DASH:          true,
+		},
+		172: {
+			Extension:     "webm",
+			Container:     "webm",
+			AudioEncoding: "vorbis",
+			AudioCodec:    "vorbis",
+			AudioBitrate:  192,
+			DASH:          true,
+		},
+		242: {
+			Extension:     "webm",
+			Container:     "webm",
+			Resolution:    "240p",
+			VideoEncoding: "VP9",
+			VideoCodec:    "vp9",
+			DASH:          true,
+		},
+		243: {
+			Extension:     "webm",
+			Container:     "webm",
+			Resolution:    "360p",
+			VideoEncoding: "VP9",
+			VideoCodec:    "vp9",
+			DASH:          true,
+		},
+		244: {
+			Extension:     "webm",
+			Container:     "webm",
+			Resolution:    "480p",
+			VideoEncoding: "VP9",
+			VideoCodec:    "vp9",
+			DASH:          true,
+		},
+		247: {
+			Extension:     "webm",
+			Container:     "webm",
+			Resolution:    "720p",
+			VideoEncoding: "VP9",
+			VideoCodec:    "vp9",
+			DASH:          true,
+		},
+		248: {
+			Extension:     "webm",
+			Container:     "webm",
+			Resolution:    "1080p",
+			VideoEncoding: "VP9",
+			VideoCodec:    "vp9",
+			DASH:          true,
+		},
+		249: {
+			Extension:     "webm",
+			Container:     "webm",
+			AudioEncoding: "opus",
+			AudioCodec:    "opus",
+			AudioBitrate:  50,
+			DASH:          true,
+		},
+		250: {
+			Extension:     "webm",
+			Container:     "webm",
+			AudioEncoding: "opus",
+			AudioCodec:    "opus",
+			AudioBitrate:  70,
+			DASH:          true,
+		},
+		251: {
+			Extension:     "webm",
+			Container:     "webm",
+			AudioEncoding: "opus",
+			AudioCodec:    "opus",
+			AudioBitrate:  160,
+			DASH:          true,
+		},
+		256: {
+			Extension:     "m4a",
+			Container:     "mp4",
+			AudioEncoding: "aac",
+			AudioCodec:    "mp4a",
+			AudioBitrate:  192,
+			AudioChannels: 6,
+			DASH:          true,
+		},
+		258: {
+			Extension:     "m4a",
+			Container:     "mp4",
+			AudioEncoding: "aac",
+			AudioCodec:    "mp4a",
+			AudioBitrate:  384,
+			AudioChannels: 6,
+			DASH:          true,
+		},
+		264: {
+			Extension:     "mp4",
+			Container:     "mp4",
+			Resolution:    "1440p",
+			VideoEncoding: "H.264",
+			VideoCodec:    "avc1",
+			DASH:          true,
+		},
+		266: {
+			Extension:     "mp4",
+			Container:     "mp4",
+			Resolution:    "2160p",
+			VideoEncoding: "H.264",
+			VideoCodec:    "avc1",
+			DASH:          true,
+		},
+		271: {
+			Extension:     "webm",
+			Container:     "webm",
+			Resolution:    "1440p",
+			VideoEncoding: "VP9",
+			VideoCodec:    "vp9",
+			DASH:          true,
+		},
+		272: {
+			Extension:     "webm",
+			Container:     "webm",
+			Resolution:    "2160p",
+			VideoEncoding: "VP9",
+			VideoCodec:    "vp9",
+			DASH:          true,
+		},
+		278: {
+			Extension:     "webm",
+			Container:     "webm",
+			Resolution:    "144p",
+			VideoEncoding: "VP9",
+			VideoCodec:    "vp9",
+			DASH:          true,
+		},
+		298: {
+			Extension:     "mp4",
+			Container:     "mp4",
+			Resolution:    "720p",
+			VideoEncoding: "H.264",
+			VideoCodec:    "avc1",
+			FPS:           60,
+			DASH:          true,
+		},
+		299: {
+			Extension:     "mp4",
+			Container:     "mp4",
+			Resolution:    "1080p",
+			VideoEncoding: "H.264",
+			VideoCodec:    "avc1",
+			FPS:           60,
+			DASH:          true,
+		},
+		302: {
+			Extension:     "webm",
+			Container:     "webm",
+			Resolution:    "720p",
+			VideoEncoding: "VP9",
+			VideoCodec:    "vp9",
+			FPS:           60,
+			DASH:          true,
+		},
+		303: {
+			Extension:     "webm",
+			Container:     "webm",
+			Resolution:    "1080p",
+			VideoEncoding: "VP9",
+			VideoCodec:    "vp9",
+			FPS:           60,
+			DASH:          true,
+		},
+		308: {
+			Extension:     "webm",
+			Container:     "webm",
+			Resolution:    "1440p",
+			VideoEncoding: "VP9",
+			VideoCodec:    "vp9",
+			FPS:           60,
+			DASH:          true,
+		},
+		313: {
+			Extension:     "webm",
+			Container:     "webm",
+			Resolution:    "2160p",
+			VideoEncoding: "VP9",
+			VideoCodec:    "vp9",
+			DASH:          true,
+		},
+		315: {
+			Extension:     "webm",
+			Container:     "webm",
+			Resolution:    "2160p",
+			VideoEncoding: "VP9",
+			VideoCodec:    "vp9",
+			FPS:           60,
+			DASH:          true,
+		},
+		330: {
+			Extension:     "webm",
+			Container:     "webm",
+			Resolution:    "144p",
+			VideoEncoding: "VP9",
+			VideoCodec:    "vp9",
+			FPS:           60,
+			HDR:           true,
+			DASH:          true,
+		},
+		331: {
+			Extension:     "webm",
+			Container:     "webm",
+			Resolution:    "240p",
+			VideoEncoding: "VP9",
+			VideoCodec:    "vp9",
+			FPS:           60,
+			HDR:           true,
+			DASH:          true,
+		},
+		332: {
+			Extension:     "webm",
+			Container:     "webm",
+			Resolution:    "360p",
+			VideoEncoding: "VP9",
+			VideoCodec:    "vp9",
+			FPS:           60,
+			HDR:           true,
+			DASH:          true,
+		},
+		333: {
+			Extension:     "webm",
+			Container:     "webm",
+			Resolution:    "480p",
+			VideoEncoding: "VP9",
+			VideoCodec:    "vp9",
+			FPS:           60,
+			HDR:           true,
+			DASH:          true,
+		},
+		334: {
+			Extension:     "webm",
+			Container:     "webm",
+			Resolution:    "720p",
+			VideoEncoding: "VP9",
+			VideoCodec:    "vp9",
+			FPS:           60,
+			HDR:           true,
+			DASH:          true,
+		},
+		335: {
+			Extension:     "webm",
+			Container:     "webm",
+			Resolution:    "1080p",
+			VideoEncoding: "VP9",
+			VideoCodec:    "vp9",
+			FPS:           60,
+			HDR:           true,
+			DASH:          true,
+		},
+		336: {
+			Extension:     "webm",
+			Container:     "webm",
+			Resolution:    "1440p",
+			VideoEncoding: "VP9",
+			VideoCodec:    "vp9",
+			FPS:           60,
+			HDR:           true,
+			DASH:          true,
+		},
+		337: {
+			Extension:     "webm",
+			Container:     "webm",
+			Resolution:    "2160p",
+			VideoEncoding: "VP9",
+			VideoCodec:    "vp9",
+			FPS:           60,
+			HDR:           true,
+			DASH:          true,
+		},
+		394: {
+			Extension:     "mp4",
+			Container:     "mp4",
+			Resolution:    "144p",
+			VideoEncoding: "AV1",
+			VideoCodec:    "av01",
+			DASH:          true,
+		},
+		395: {
+			Extension:     "mp4",
+			Container:     "mp4",
+			Resolution:    "240p",
+			VideoEncoding: "AV1",
+			VideoCodec:    "av01",
+			DASH:          true,
+		},
+		396: {
+			Extension:     "mp4",
+			Container:     "mp4",
+			Resolution:    "360p",
+			VideoEncoding: "AV1",
+			VideoCodec:    "av01",
+			DASH:          true,
+		},
+		397: {
+			Extension:     "mp4",
+			Container:     "mp4",
+			Resolution:    "480p",
+			VideoEncoding: "AV1",
+			VideoCodec:    "av01",
+			DASH:          true,
+		},
+		398: {
+			Extension:     "mp4",
+			Container:     "mp4",
+			Resolution:    "720p",
+			VideoEncoding: "AV1",
+			VideoCodec:    "av01",
+			DASH:          true,
+		},
+		399: {
+			Extension:     "mp4",
+			Container:     "mp4",
+			Resolution:    "1080p",
+			VideoEncoding: "AV1",
+			VideoCodec:    "av01",
+			DASH:          true,
+		},
+		400: {
+			Extension:     "mp4",
+			Container:     "mp4",
+			Resolution:    "1440p",
+			VideoEncoding: "AV1",
+			VideoCodec:    "av01",
+			DASH:          true,
+		},
+		401: {
+			Extension:     "mp4",
+			Container:     "mp4",
+			Resolution:    "2160p",
+			VideoEncoding: "AV1",
+			VideoCodec:    "av01",
+			DASH:          true,
+		},
+		402: {
+			Extension:     "mp4",
+			Container:     "mp4",
+			Resolution:    "2880p",
+			VideoEncoding: "AV1",
+			VideoCodec:    "av01",
+			DASH:          true,
+		},
+		571: {
+			Extension:     "mp4",
+			Container:     "mp4",
+			Resolution:    "4320p",
+			VideoEncoding: "AV1",
+			VideoCodec:    "av01",
+			DASH:          true,
+		},
+		599: {
+			Extension:     "m4a",
+			Container:     "mp4",
+			AudioEncoding: "aac",
+			AudioCodec:    "mp4a",
+			AudioBitrate:  31,
+			DASH:          true,
+		},
+		600: {
+			Extension:     "webm",
+			Container:     "webm",
+			AudioEncoding: "opus",
+			AudioCodec:    "opus",
+			AudioBitrate:  32,
+			DASH:          true,
+		},
+	}
+)
+
+// Lookup returns the description of itag.
+func Lookup(itag uint) (ITag, bool) {
+	itagsMu.RLock()
+	defer itagsMu.RUnlock()
+
+	t, ok := itags[itag]
+	return t, ok
 }
+
+// Register registers the description of itag, replacing any existing description of it. It may be used to describe
+// itags that are not yet known to this package.
+func Register(itag uint, t ITag) {
+	itagsMu.Lock()
+	defer itagsMu.Unlock()
+
+	itags[itag] = t
+}
+
+// ITags holds the descriptions of the itags known to this package at initialization, indexed by itag. Itags above
+// 402 and itags registered using Register are not included.
+//
+// Deprecated: Use Lookup, which also describes itags above 402 and those registered using Register.
+var ITags = func() (t [403]ITag) {
+	for itag, desc := range itags {
+		if itag < uint(len(t)) {
+			t[itag] = desc
+		}
+	}
+	return t
+}()
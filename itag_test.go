@@ -0,0 +1,73 @@
+package youtube
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	tag, ok := Lookup(5)
+	require.True(t, ok)
+	require.Equal(t, "Sorenson H.263", tag.VideoEncoding)
+
+	tag, ok = Lookup(337)
+	require.True(t, ok)
+	require.True(t, tag.HDR)
+	require.True(t, tag.DASH)
+	require.Equal(t, 60, tag.FPS)
+	require.Equal(t, "vp9", tag.VideoCodec)
+
+	tag, ok = Lookup(140)
+	require.True(t, ok)
+	require.Equal(t, "m4a", tag.Extension)
+	require.Equal(t, "mp4", tag.Container)
+
+	tag, ok = Lookup(93)
+	require.True(t, ok)
+	require.True(t, tag.Live)
+	require.False(t, tag.DASH)
+
+	tag, ok = Lookup(83)
+	require.True(t, ok)
+	require.True(t, tag.ThreeD)
+
+	_, ok = Lookup(100000)
+	require.False(t, ok)
+}
+
+func TestITags(t *testing.T) {
+	for itag, desc := range ITags {
+		if desc == (ITag{}) {
+			continue
+		}
+
+		registered, ok := Lookup(uint(itag))
+		require.True(t, ok, itag)
+		require.Equal(t, registered, desc, itag)
+	}
+
+	require.Equal(t, "m4a", ITags[140].Extension)
+}
+
+func TestRegister(t *testing.T) {
+	const itag = 9999
+
+	f := Format{ITag: itag, MIMEType: `audio/webm; codecs="opus"`}
+
+	// Unknown itags above the highest known itag fall back to the extension derived from the MIME type.
+
+	require.Equal(t, "webm", f.FileExtension())
+	require.Equal(t, "", Format{ITag: itag}.FileExtension())
+
+	Register(itag, ITag{Extension: "opus", Container: "webm", AudioEncoding: "opus", AudioCodec: "opus", DASH: true})
+	defer func() {
+		itagsMu.Lock()
+		delete(itags, itag)
+		itagsMu.Unlock()
+	}()
+
+	tag, ok := Lookup(itag)
+	require.True(t, ok)
+	require.Equal(t, "opus", tag.AudioCodec)
+	require.Equal(t, "opus", f.FileExtension())
+}
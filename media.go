@@ -0,0 +1,359 @@
+package youtube
+
+import (
+	"fmt"
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// MediaKind denotes whether a format or codec carries audio, video, or both.
+type MediaKind uint8
+
+const (
+	MediaAudio MediaKind = 1 << iota
+	MediaVideo
+
+	MediaAudioVideo = MediaAudio | MediaVideo
+)
+
+func (k MediaKind) HasAudio() bool {
+	return k&MediaAudio != 0
+}
+
+func (k MediaKind) HasVideo() bool {
+	return k&MediaVideo != 0
+}
+
+func (k MediaKind) String() string {
+	switch k {
+	case MediaAudio:
+		return "audio"
+	case MediaVideo:
+		return "video"
+	case MediaAudioVideo:
+		return "audio+video"
+	default:
+		return "unknown"
+	}
+}
+
+// Media describes the container and codecs of a format as parsed from its MIME type.
+type Media struct {
+	// Media type without any parameters, e.g. 'video/webm'.
+	Type string
+
+	// Container of the format, e.g. 'mp4', 'webm', or '3gpp'.
+	Container string
+
+	// Whether the format carries audio, video, or both. Zero if unknown.
+	Kind MediaKind
+
+	// Codecs listed in the 'codecs' parameter of the MIME type.
+	Codecs []Codec
+}
+
+// ParseMIMEType parses a MIME type such as `video/mp4; codecs="avc1.64001F, mp4a.40.2"`. The kind of media is
+// derived from the codecs listed, and otherwise from the top-level media type.
+func ParseMIMEType(s string) (Media, error) {
+	var m Media
+
+	typ, params, err := mime.ParseMediaType(s)
+	if err != nil {
+		return m, fmt.Errorf("bad mime type %q: %w", s, err)
+	}
+
+	m.Type = typ
+
+	if i := strings.IndexByte(typ, '/'); i >= 0 {
+		m.Container = typ[i+1:]
+	}
+
+	if codecs := params["codecs"]; codecs != "" {
+		for _, codec := range strings.Split(codecs, ",") {
+			c := ParseCodec(strings.TrimSpace(codec))
+			m.Codecs = append(m.Codecs, c)
+			m.Kind |= c.Kind
+		}
+	}
+
+	if m.Kind == 0 {
+		switch {
+		case strings.HasPrefix(typ, "audio/"):
+			m.Kind = MediaAudio
+		case strings.HasPrefix(typ, "video/"):
+			m.Kind = MediaVideo
+		}
+	}
+
+	return m, nil
+}
+
+// Media returns the container and codecs of format f parsed from its MIME type. Should its MIME type not list any
+// codecs, a format of a video MIME type that reports an audio quality is taken to also carry audio.
+func (f Format) Media() Media {
+	m := f.parsedMedia()
+	m.Codecs = append([]Codec(nil), m.Codecs...)
+	return m
+}
+
+// formatMedia is the media of a format parsed out of its MIME type, along with the fields it was parsed from.
+type formatMedia struct {
+	mimeType string
+	audio    bool
+	media    Media
+}
+
+// cacheMedia parses the media of format f once, so that later calls to Media do not parse its MIME type again.
+func (f *Format) cacheMedia() {
+	f.media = &formatMedia{mimeType: f.MIMEType, audio: f.AudioQuality != nil, media: parseFormatMedia(*f)}
+}
+
+// parsedMedia returns the media of format f, which is only parsed should it not have been cached or should the
+// format have been modified since. The codecs returned must not be modified.
+func (f Format) parsedMedia() Media {
+	if c := f.media; c != nil && c.mimeType == f.MIMEType && c.audio == (f.AudioQuality != nil) {
+		return c.media
+	}
+	return parseFormatMedia(f)
+}
+
+func parseFormatMedia(f Format) Media {
+	m, _ := ParseMIMEType(f.MIMEType)
+	if len(m.Codecs) == 0 && f.AudioQuality != nil {
+		m.Kind |= MediaAudio
+	}
+	return m
+}
+
+// Audio returns the first audio codec listed.
+func (m Media) Audio() (Codec, bool) {
+	return m.codec(MediaAudio)
+}
+
+// Video returns the first video codec listed.
+func (m Media) Video() (Codec, bool) {
+	return m.codec(MediaVideo)
+}
+
+func (m Media) codec(kind MediaKind) (Codec, bool) {
+	for _, c := range m.Codecs {
+		if c.Kind == kind {
+			return c, true
+		}
+	}
+	return Codec{}, false
+}
+
+// Extension returns the file extension conventionally used for the media's container. Audio-only MP4 media is
+// given the extension 'm4a'.
+func (m Media) Extension() string {
+	switch m.Container {
+	case "mp4":
+		if m.Kind == MediaAudio {
+			return "m4a"
+		}
+	case "3gpp":
+		return "3gp"
+	case "x-flv":
+		return "flv"
+	}
+	return m.Container
+}
+
+// Codec is a codec decoded from an RFC 6381 codec string.
+type Codec struct {
+	// RFC 6381 codec string, e.g. 'avc1.640028'.
+	Raw string
+
+	// Four-character code or name of the codec, e.g. 'avc1', 'vp9', 'av01', 'mp4a', or 'opus'. Both 'vp9' and
+	// 'vp09' are reported as 'vp9'.
+	Name string
+
+	// Whether the codec encodes audio or video. Zero if unknown.
+	Kind MediaKind
+
+	// Profile of the codec, e.g. 'High' for AVC, '2' for VP9, 'Main' for AV1, or 'HE-AAC' for AAC. Empty if unknown.
+	Profile string
+
+	// Level of the codec, e.g. '4.0'. Empty if unknown.
+	Level string
+
+	// Bit depth of video samples. Zero if unknown.
+	BitDepth uint
+}
+
+var codecKinds = map[string]MediaKind{
+	"avc1":   MediaVideo,
+	"avc3":   MediaVideo,
+	"hev1":   MediaVideo,
+	"hvc1":   MediaVideo,
+	"mp4v":   MediaVideo,
+	"vp8":    MediaVideo,
+	"vp9":    MediaVideo,
+	"av01":   MediaVideo,
+	"mp4a":   MediaAudio,
+	"opus":   MediaAudio,
+	"vorbis": MediaAudio,
+	"flac":   MediaAudio,
+	"ac-3":   MediaAudio,
+	"ec-3":   MediaAudio,
+}
+
+var avcProfiles = map[uint64]string{
+	66:  "Baseline",
+	77:  "Main",
+	88:  "Extended",
+	100: "High",
+	110: "High 10",
+	122: "High 4:2:2",
+	244: "High 4:4:4 Predictive",
+}
+
+var av1Profiles = []string{"Main", "High", "Professional"}
+
+var aacProfiles = map[string]string{
+	"1":  "AAC Main",
+	"2":  "AAC-LC",
+	"3":  "AAC SSR",
+	"4":  "AAC LTP",
+	"5":  "HE-AAC",
+	"29": "HE-AACv2",
+	"34": "MP3",
+}
+
+// ParseCodec decodes an RFC 6381 codec string. Parts of the codec string that could not be decoded are left zero.
+func ParseCodec(s string) Codec {
+	fields := strings.Split(s, ".")
+
+	c := Codec{Raw: s, Name: strings.ToLower(fields[0])}
+	if c.Name == "vp09" {
+		c.Name = "vp9"
+	}
+
+	c.Kind = codecKinds[c.Name]
+
+	switch c.Name {
+	case "avc1", "avc3":
+		// avc1.PPCCLL with profile_idc PP, constraint flags CC, and level_idc LL in hex.
+
+		if len(fields) < 2 || len(fields[1]) != 6 {
+			break
+		}
+
+		v, err := strconv.ParseUint(fields[1], 16, 32)
+		if err != nil {
+			break
+		}
+
+		profile, constraints, level := v>>16, v>>8&0xff, v&0xff
+
+		c.Profile = avcProfiles[profile]
+		if profile == 66 && constraints&0x40 != 0 {
+			c.Profile = "Constrained Baseline"
+		}
+
+		c.Level = strconv.FormatUint(level/10, 10) + "." + strconv.FormatUint(level%10, 10)
+
+		switch profile {
+		case 66, 77, 88, 100:
+			c.BitDepth = 8
+		case 110, 122:
+			c.BitDepth = 10
+		}
+	case "vp9":
+		// Either the legacy vp9 or vp9.P with profile P, or vp09.PP.LL.DD with profile PP, level LL and bit depth DD.
+
+		profile := uint64(0)
+
+		if len(fields) >= 2 {
+			v, err := strconv.ParseUint(fields[1], 10, 8)
+			if err != nil {
+				break
+			}
+			profile = v
+		}
+
+		c.Profile = strconv.FormatUint(profile, 10)
+
+		if profile < 2 {
+			c.BitDepth = 8
+		} else {
+			c.BitDepth = 10
+		}
+
+		if len(fields) >= 4 {
+			if level, err := strconv.ParseUint(fields[2], 10, 8); err == nil {
+				c.Level = strconv.FormatUint(level/10, 10) + "." + strconv.FormatUint(level%10, 10)
+			}
+			if depth, err := strconv.ParseUint(fields[3], 10, 8); err == nil {
+				c.BitDepth = uint(depth)
+			}
+		}
+	case "av01":
+		// av01.P.LLT.DD with profile P, seq_level_idx LL, tier T and bit depth DD.
+
+		if len(fields) < 4 {
+			break
+		}
+
+		if profile, err := strconv.ParseUint(fields[1], 10, 8); err == nil && profile < uint64(len(av1Profiles)) {
+			c.Profile = av1Profiles[profile]
+		}
+
+		if len(fields[2]) == 3 {
+			if idx, err := strconv.ParseUint(fields[2][:2], 10, 8); err == nil {
+				c.Level = strconv.FormatUint(2+idx/4, 10) + "." + strconv.FormatUint(idx%4, 10)
+			}
+		}
+
+		if depth, err := strconv.ParseUint(fields[3], 10, 8); err == nil {
+			c.BitDepth = uint(depth)
+		}
+	case "mp4a":
+		// mp4a.OO.A with object type indication OO in hex, and audio object type A for MPEG-4 audio (OO = 40).
+
+		if len(fields) < 2 {
+			break
+		}
+
+		switch strings.ToLower(fields[1]) {
+		case "40":
+			if len(fields) >= 3 {
+				c.Profile = aacProfiles[fields[2]]
+			}
+		case "69", "6b":
+			c.Profile = "MP3"
+		}
+	}
+
+	return c
+}
+
+// hasVideo reports whether format f carries video.
+func hasVideo(f Format) bool {
+	return f.parsedMedia().Kind.HasVideo()
+}
+
+// hasAudio reports whether format f carries audio.
+func hasAudio(f Format) bool {
+	return f.parsedMedia().Kind.HasAudio()
+}
+
+// formatCodecs returns the RFC 6381 video and audio codec strings of format f. A codec that is absent is reported as
+// 'none'.
+func formatCodecs(f Format) (video, audio string) {
+	video, audio = "none", "none"
+
+	m := f.parsedMedia()
+
+	if c, ok := m.Video(); ok {
+		video = c.Raw
+	}
+
+	if c, ok := m.Audio(); ok {
+		audio = c.Raw
+	}
+
+	return video, audio
+}
@@ -0,0 +1,90 @@
+package youtube
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParseCodec(t *testing.T) {
+	cases := []Codec{
+		{Raw: "avc1.42001E", Name: "avc1", Kind: MediaVideo, Profile: "Baseline", Level: "3.0", BitDepth: 8},
+		{Raw: "avc1.42E01E", Name: "avc1", Kind: MediaVideo, Profile: "Constrained Baseline", Level: "3.0", BitDepth: 8},
+		{Raw: "avc1.4d401f", Name: "avc1", Kind: MediaVideo, Profile: "Main", Level: "3.1", BitDepth: 8},
+		{Raw: "avc1.640028", Name: "avc1", Kind: MediaVideo, Profile: "High", Level: "4.0", BitDepth: 8},
+		{Raw: "avc1.6E0033", Name: "avc1", Kind: MediaVideo, Profile: "High 10", Level: "5.1", BitDepth: 10},
+		{Raw: "vp9", Name: "vp9", Kind: MediaVideo, Profile: "0", BitDepth: 8},
+		{Raw: "vp9.2", Name: "vp9", Kind: MediaVideo, Profile: "2", BitDepth: 10},
+		{Raw: "vp09.02.51.12.01.09.16.09.00", Name: "vp9", Kind: MediaVideo, Profile: "2", Level: "5.1", BitDepth: 12},
+		{Raw: "av01.0.08M.08", Name: "av01", Kind: MediaVideo, Profile: "Main", Level: "4.0", BitDepth: 8},
+		{Raw: "av01.1.13H.10", Name: "av01", Kind: MediaVideo, Profile: "High", Level: "5.1", BitDepth: 10},
+		{Raw: "mp4a.40.2", Name: "mp4a", Kind: MediaAudio, Profile: "AAC-LC"},
+		{Raw: "mp4a.40.5", Name: "mp4a", Kind: MediaAudio, Profile: "HE-AAC"},
+		{Raw: "mp4a.40.29", Name: "mp4a", Kind: MediaAudio, Profile: "HE-AACv2"},
+		{Raw: "opus", Name: "opus", Kind: MediaAudio},
+		{Raw: "avc1", Name: "avc1", Kind: MediaVideo},
+		{Raw: "dvh1.05.06", Name: "dvh1"},
+	}
+
+	for _, expected := range cases {
+		require.Equal(t, expected, ParseCodec(expected.Raw), expected.Raw)
+	}
+}
+
+func TestFormatMedia(t *testing.T) {
+	formats := loadTestStreams(t).Formats()
+
+	muxed := formats.ByITag(22)[0].Media()
+	require.Equal(t, "video/mp4", muxed.Type)
+	require.Equal(t, "mp4", muxed.Container)
+	require.Equal(t, MediaAudioVideo, muxed.Kind)
+	require.Equal(t, "mp4", muxed.Extension())
+	require.Len(t, muxed.Codecs, 2)
+
+	video, ok := muxed.Video()
+	require.True(t, ok)
+	require.Equal(t, "High", video.Profile)
+
+	audio, ok := muxed.Audio()
+	require.True(t, ok)
+	require.Equal(t, "AAC-LC", audio.Profile)
+
+	hdr := formats.ByITag(337)[0].Media()
+	require.Equal(t, MediaVideo, hdr.Kind)
+	require.Equal(t, "webm", hdr.Extension())
+	require.EqualValues(t, 10, hdr.Codecs[0].BitDepth)
+
+	_, ok = hdr.Audio()
+	require.False(t, ok)
+
+	m4a := formats.ByITag(140)[0]
+	require.Equal(t, MediaAudio, m4a.Media().Kind)
+	require.Equal(t, "m4a", m4a.Media().Extension())
+	require.Nil(t, m4a.FPS)
+	require.NotNil(t, m4a.AudioChannels)
+
+	video137 := formats.ByITag(137)[0]
+	require.NotNil(t, video137.FPS)
+	require.Nil(t, video137.AudioChannels)
+
+	// Formats without any codecs listed fall back to their top-level media type and audio quality.
+
+	quality := "AUDIO_QUALITY_LOW"
+	require.Equal(t, MediaAudioVideo, Format{MIMEType: "video/3gpp", AudioQuality: &quality}.Media().Kind)
+	require.Equal(t, "3gp", Format{MIMEType: "video/3gpp"}.Media().Extension())
+	require.Equal(t, MediaKind(0), Format{}.Media().Kind)
+
+	// Media parsed once per format is neither affected by changes made to the codecs returned, nor kept should the
+	// format be modified afterwards.
+
+	require.NotNil(t, m4a.media)
+
+	m := m4a.Media()
+	m.Codecs[0].Name = "opus"
+	require.Equal(t, "mp4a", m4a.Media().Codecs[0].Name)
+
+	m4a.MIMEType = `audio/webm; codecs="opus"`
+	require.Equal(t, "opus", m4a.Media().Codecs[0].Name)
+
+	_, err := ParseMIMEType(`video/mp4; codecs="avc1`)
+	require.Error(t, err)
+}
@@ -0,0 +1,333 @@
+package youtube
+
+import (
+	"github.com/valyala/fastjson"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	RegexTitleBrackets  = regexp.MustCompile(`\s*[(\[【]([^)\]】]*)[)\]】]`)
+	RegexTitleSeparator = regexp.MustCompile(`\s+[-–—|]\s+`)
+	RegexFeaturing      = regexp.MustCompile(`(?i)\s*\b(?:feat\.?|ft\.?|featuring)(?:\s+|$)`)
+	RegexArtistList     = regexp.MustCompile(`(?i)\s*,\s*|\s+(?:&|and|x)\s+`)
+	RegexTitleNoise     = regexp.MustCompile(`(?i)^(?:official(?:\s+(?:music|lyrics?|audio|hd))?(?:\s+(?:video|audio|visuali[sz]er))?|(?:music|lyrics?|hd|4k)\s+video|lyrics?|audio|video|visuali[sz]er|hd|hq|4k|mv|m/v|explicit|clean)$`)
+	RegexVersionMarker  = regexp.MustCompile(`(?i)\b(?:remix|rmx|mix|edit|live|acoustic|unplugged|instrumental|remaster(?:ed)?|version|cover|demo|slowed|sped up|reverb|karaoke|bootleg|vip|rework|extended)\b`)
+	RegexAlbumMention   = regexp.MustCompile(`(?i)\bfrom (?:the|their|his|her) (?:new |debut |upcoming )?(?:album|ep|lp|record)\s*[:,]?\s*["“']?([^"”'\n.!]+)`)
+)
+
+// TrackInfo describes the piece of music played in a stream.
+type TrackInfo struct {
+	Artist   string   `json:"artist"`
+	Track    string   `json:"track"`
+	Featured []string `json:"featured,omitempty"`
+
+	// Descriptors of the version of the track, such as 'Live at Wembley' or 'Mord Fustang Remix'.
+	Versions []string `json:"versions,omitempty"`
+
+	Album      string    `json:"album,omitempty"`
+	LicensedBy string    `json:"licensed_by,omitempty"`
+	Released   time.Time `json:"released,omitempty"`
+}
+
+// TrackInfo returns info on the piece of music played in the stream. Structured music metadata is read from the
+// stream's player response should it list any. Otherwise, the description YouTube auto-generates for tracks provided
+// to it by labels and distributors is parsed. Should the stream have neither, the info is derived from the stream's
+// title, author, and description.
+func (s Streams) TrackInfo() TrackInfo {
+	provided, isProvided := ParseProvidedTrackInfo(s.ShortDescription())
+
+	if info, ok := ParseMetadataRowsJSON(findMetadataRows(s.v)); ok {
+		// Metadata rows do not list release dates, though descriptions of provided tracks do.
+
+		if info.Released.IsZero() {
+			info.Released = provided.Released
+		}
+
+		return info
+	}
+
+	if isProvided {
+		return provided
+	}
+
+	return ParseTrackInfo(s.Title(), s.Author(), s.ShortDescription())
+}
+
+// ParseMetadataRowsJSON parses the music metadata rows YouTube lists for streams that play a piece of music, which are
+// of the form:
+//
+//	{"metadataRowRenderer": {"title": {"simpleText": "Song"}, "contents": [{"runs": [{"text": "Animus Vox"}]}]}}
+//
+// Rows titled 'Song', 'Artist', 'Album', and 'Licensed to YouTube by' are read. It reports false if the rows do not
+// name both the song and its artist.
+func ParseMetadataRowsJSON(rows []*fastjson.Value) (TrackInfo, bool) {
+	var info TrackInfo
+
+	for _, row := range rows {
+		var values []string
+		for _, v := range row.GetArray("metadataRowRenderer", "contents") {
+			if text := strings.TrimSpace(parseTextJSON(v)); text != "" {
+				values = append(values, text)
+			}
+		}
+
+		if len(values) == 0 {
+			continue
+		}
+
+		switch parseTextJSON(row.Get("metadataRowRenderer", "title")) {
+		case "Song":
+			info.Track = values[0]
+		case "Artist":
+			// Every artist is given as a separate run or entry. Names such as 'Mumford & Sons' must not be split.
+
+			var artists []string
+			for _, v := range row.GetArray("metadataRowRenderer", "contents") {
+				artists = append(artists, parseTextRunsJSON(v)...)
+			}
+
+			if len(artists) > 0 {
+				info.Artist, info.Featured = artists[0], artists[1:]
+			}
+		case "Album":
+			info.Album = values[0]
+		case "Licensed to YouTube by":
+			info.LicensedBy = values[0]
+		}
+	}
+
+	if info.Track == "" || info.Artist == "" {
+		return TrackInfo{}, false
+	}
+
+	// Featured artists may also be named in the song, either in brackets or not. They are only kept should the
+	// artist row not list them already, and are kept whole as names such as 'Earth, Wind & Fire' must not be split.
+
+	var featured []string
+
+	for _, match := range RegexTitleBrackets.FindAllStringSubmatch(info.Track, -1) {
+		inner := strings.TrimSpace(match[1])
+		if loc := RegexFeaturing.FindStringIndex(inner); loc != nil && loc[0] == 0 {
+			featured = append(featured, strings.TrimSpace(inner[loc[1]:]))
+			info.Track = strings.TrimSpace(strings.Replace(info.Track, match[0], "", 1))
+		}
+	}
+
+	if parts := RegexFeaturing.Split(info.Track, 2); len(parts) == 2 {
+		info.Track = strings.TrimSpace(parts[0])
+		featured = append(featured, strings.TrimSpace(parts[1]))
+	}
+
+	if len(info.Featured) == 0 {
+		info.Featured = featured
+	}
+
+	return info, true
+}
+
+// findMetadataRows returns the rows of the first metadata row container found in v. Streaming data is skipped, as it
+// never holds any.
+func findMetadataRows(v *fastjson.Value) []*fastjson.Value {
+	if v == nil {
+		return nil
+	}
+
+	switch v.Type() {
+	case fastjson.TypeObject:
+		if container := v.Get("metadataRowContainerRenderer"); container != nil {
+			return container.GetArray("rows")
+		}
+
+		var rows []*fastjson.Value
+
+		v.GetObject().Visit(func(key []byte, v *fastjson.Value) {
+			if rows == nil && string(key) != "streamingData" {
+				rows = findMetadataRows(v)
+			}
+		})
+
+		return rows
+	case fastjson.TypeArray:
+		for _, item := range v.GetArray() {
+			if rows := findMetadataRows(item); rows != nil {
+				return rows
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseTextRunsJSON returns the runs of text of a formatted string, skipping runs that only separate others such as
+// ', ' or ' & '. Simple text is returned as a single run.
+func parseTextRunsJSON(v *fastjson.Value) []string {
+	if text := v.GetStringBytes("simpleText"); text != nil {
+		if text := strings.TrimSpace(string(text)); text != "" {
+			return []string{text}
+		}
+		return nil
+	}
+
+	var runs []string
+	for _, run := range v.GetArray("runs") {
+		text := strings.TrimSpace(string(run.GetStringBytes("text")))
+
+		switch strings.ToLower(text) {
+		case "", ",", "&", "and", "x", "·", "•":
+			continue
+		}
+
+		runs = append(runs, text)
+	}
+
+	return runs
+}
+
+// parseTextJSON returns the text of a formatted string, which is either given as simple text or as runs of text.
+func parseTextJSON(v *fastjson.Value) string {
+	if v == nil {
+		return ""
+	}
+
+	if text := v.GetStringBytes("simpleText"); text != nil {
+		return string(text)
+	}
+
+	var b strings.Builder
+	for _, run := range v.GetArray("runs") {
+		b.Write(run.GetStringBytes("text"))
+	}
+
+	return b.String()
+}
+
+// ParseTrackInfo derives info on a piece of music from the title, author, and description of a stream. Titles are
+// expected to be of the form 'Artist - Track (feat. Artist) [Version]'. Should the title not name an artist, the
+// author is used as the artist instead. Bracketed noise such as '(Official Video)' or '[HD]' is dropped.
+func ParseTrackInfo(title, author, description string) TrackInfo {
+	var info TrackInfo
+
+	for _, match := range RegexTitleBrackets.FindAllStringSubmatch(title, -1) {
+		inner := strings.TrimSpace(match[1])
+
+		switch {
+		case inner == "" || RegexTitleNoise.MatchString(inner):
+		case RegexFeaturing.MatchString(inner) && RegexFeaturing.FindStringIndex(inner)[0] == 0:
+			info.Featured = append(info.Featured, splitArtists(RegexFeaturing.ReplaceAllString(inner, ""))...)
+		case RegexVersionMarker.MatchString(inner):
+			info.Versions = append(info.Versions, inner)
+		default:
+			continue
+		}
+
+		title = strings.Replace(title, match[0], "", 1)
+	}
+
+	title = strings.TrimSpace(title)
+
+	if parts := RegexTitleSeparator.Split(title, 2); len(parts) == 2 {
+		info.Artist, info.Track = parts[0], parts[1]
+	} else {
+		info.Artist, info.Track = cleanAuthor(author), title
+	}
+
+	// Artists may be featured outside of brackets in both the artist and the track.
+
+	if parts := RegexFeaturing.Split(info.Artist, 2); len(parts) == 2 {
+		info.Artist = parts[0]
+		info.Featured = append(info.Featured, splitArtists(parts[1])...)
+	}
+
+	if parts := RegexFeaturing.Split(info.Track, 2); len(parts) == 2 {
+		info.Track = parts[0]
+		info.Featured = append(info.Featured, splitArtists(parts[1])...)
+	}
+
+	info.Artist = strings.TrimSpace(info.Artist)
+	info.Track = strings.Trim(strings.TrimSpace(info.Track), `"“”'`)
+
+	if match := RegexAlbumMention.FindStringSubmatch(description); match != nil {
+		info.Album = strings.TrimSpace(match[1])
+	}
+
+	return info
+}
+
+// ParseProvidedTrackInfo parses the description YouTube auto-generates for tracks provided to it by labels and
+// distributors, which is of the form:
+//
+//	Provided to YouTube by Label
+//
+//	Track · Artist · Featured Artist
+//
+//	Album
+//
+//	℗ 2010 Label
+//
+//	Released on: 2010-06-08
+//
+// It reports false if the description is not of this form.
+func ParseProvidedTrackInfo(description string) (TrackInfo, bool) {
+	var (
+		info  TrackInfo
+		lines []string
+	)
+
+	for _, line := range strings.Split(description, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	const prefix = "Provided to YouTube by "
+
+	if len(lines) < 2 || !strings.HasPrefix(lines[0], prefix) {
+		return info, false
+	}
+
+	info.LicensedBy = strings.TrimPrefix(lines[0], prefix)
+
+	fields := strings.Split(lines[1], " · ")
+	if len(fields) < 2 {
+		return info, false
+	}
+
+	info.Track, info.Artist = fields[0], fields[1]
+	info.Featured = fields[2:]
+
+	if parts := RegexFeaturing.Split(info.Track, 2); len(parts) == 2 {
+		info.Track = strings.TrimSpace(parts[0])
+		info.Featured = append(info.Featured, splitArtists(parts[1])...)
+	}
+
+	if len(lines) > 2 && !strings.HasPrefix(lines[2], "℗") && !strings.Contains(lines[2], ":") {
+		info.Album = lines[2]
+	}
+
+	for _, line := range lines[2:] {
+		if date := strings.TrimPrefix(line, "Released on: "); date != line {
+			info.Released, _ = time.Parse("2006-01-02", date)
+		}
+	}
+
+	return info, true
+}
+
+// cleanAuthor strips suffixes YouTube appends to the names of channels of artists.
+func cleanAuthor(author string) string {
+	author = strings.TrimSuffix(author, " - Topic")
+	author = strings.TrimSuffix(author, "VEVO")
+	return strings.TrimSpace(author)
+}
+
+func splitArtists(s string) []string {
+	var artists []string
+	for _, artist := range RegexArtistList.Split(strings.TrimSpace(s), -1) {
+		if artist = strings.TrimSpace(artist); artist != "" {
+			artists = append(artists, artist)
+		}
+	}
+	return artists
+}
@@ -0,0 +1,151 @@
+package youtube
+
+import (
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fastjson"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestParseTrackInfo(t *testing.T) {
+	tests := []struct {
+		title, author string
+		expected      TrackInfo
+	}{
+		{
+			title:    "The Glitch Mob - Animus Vox",
+			expected: TrackInfo{Artist: "The Glitch Mob", Track: "Animus Vox"},
+		},
+		{
+			title:    "Daft Punk - Get Lucky (Official Video) [HD] ft. Pharrell Williams, Nile Rodgers",
+			expected: TrackInfo{Artist: "Daft Punk", Track: "Get Lucky", Featured: []string{"Pharrell Williams", "Nile Rodgers"}},
+		},
+		{
+			title:    "Calvin Harris feat. Rihanna & Ne-Yo - This Is What You Came For (Official Music Video)",
+			expected: TrackInfo{Artist: "Calvin Harris", Track: "This Is What You Came For", Featured: []string{"Rihanna", "Ne-Yo"}},
+		},
+		{
+			title:    "The Glitch Mob – Animus Vox (Mord Fustang Remix) [Lyrics]",
+			expected: TrackInfo{Artist: "The Glitch Mob", Track: "Animus Vox", Versions: []string{"Mord Fustang Remix"}},
+		},
+		{
+			title:    `Nirvana - "Where Did You Sleep Last Night" (Live On MTV Unplugged)`,
+			expected: TrackInfo{Artist: "Nirvana", Track: "Where Did You Sleep Last Night", Versions: []string{"Live On MTV Unplugged"}},
+		},
+		{
+			title:    "Hurt (Acoustic) (feat. Someone)",
+			author:   "JohnnyCashVEVO",
+			expected: TrackInfo{Artist: "JohnnyCash", Track: "Hurt", Featured: []string{"Someone"}, Versions: []string{"Acoustic"}},
+		},
+		{
+			title:    "Animus Vox",
+			author:   "The Glitch Mob - Topic",
+			expected: TrackInfo{Artist: "The Glitch Mob", Track: "Animus Vox"},
+		},
+		{
+			title:    "Artist - Track (1999)",
+			expected: TrackInfo{Artist: "Artist", Track: "Track (1999)"},
+		},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.expected, ParseTrackInfo(test.title, test.author, ""), test.title)
+	}
+
+	info := ParseTrackInfo("The Glitch Mob - Animus Vox", "", "The Glitch Mob - Animus Vox from the album Drink The Sea.\n\nhttp://theglitchmob.com")
+	require.Equal(t, "Drink The Sea", info.Album)
+}
+
+func TestParseProvidedTrackInfo(t *testing.T) {
+	description := `Provided to YouTube by Glass Air
+
+Animus Vox · The Glitch Mob · Someone Else
+
+Drink The Sea
+
+℗ 2010 Glass Air
+
+Released on: 2010-06-08
+
+Auto-generated by YouTube.`
+
+	info, ok := ParseProvidedTrackInfo(description)
+	require.True(t, ok)
+	require.Equal(t, TrackInfo{
+		Artist:     "The Glitch Mob",
+		Track:      "Animus Vox",
+		Featured:   []string{"Someone Else"},
+		Album:      "Drink The Sea",
+		LicensedBy: "Glass Air",
+		Released:   time.Date(2010, 6, 8, 0, 0, 0, 0, time.UTC),
+	}, info)
+
+	_, ok = ParseProvidedTrackInfo("The Glitch Mob - Animus Vox from the album Drink The Sea.")
+	require.False(t, ok)
+}
+
+func TestStreamsTrackInfo(t *testing.T) {
+	info := loadTestStreams(t).TrackInfo()
+	require.Equal(t, TrackInfo{Artist: "The Glitch Mob", Track: "Animus Vox", Album: "Drink The Sea"}, info)
+}
+
+func TestStreamsTrackInfoFromMetadataRows(t *testing.T) {
+	response, err := ioutil.ReadFile("testdata/music_player_response.json")
+	require.NoError(t, err)
+
+	streams, err := NewStreamsFromJSON(response)
+	require.NoError(t, err)
+
+	// Metadata rows take precedence over the description, which only fills in the release date.
+
+	require.Equal(t, TrackInfo{
+		Artist:     "The Glitch Mob",
+		Track:      "Fire Spirit",
+		Featured:   []string{"Metric"},
+		Album:      "Drink The Sea (Deluxe Edition)",
+		LicensedBy: "Glass Air (on behalf of Glass Air)",
+		Released:   time.Date(2010, 5, 25, 0, 0, 0, 0, time.UTC),
+	}, streams.TrackInfo())
+
+	_, ok := ParseMetadataRowsJSON(nil)
+	require.False(t, ok)
+}
+
+func TestParseMetadataRowsJSON(t *testing.T) {
+	tests := []struct {
+		rows     string
+		expected TrackInfo
+	}{
+		{
+			rows: `[
+				{"metadataRowRenderer": {"title": {"simpleText": "Song"}, "contents": [{"simpleText": "Little Lion Man"}]}},
+				{"metadataRowRenderer": {"title": {"simpleText": "Artist"}, "contents": [{"runs": [{"text": "Mumford & Sons"}]}]}}
+			]`,
+			expected: TrackInfo{Artist: "Mumford & Sons", Track: "Little Lion Man"},
+		},
+		{
+			rows: `[
+				{"metadataRowRenderer": {"title": {"simpleText": "Song"}, "contents": [{"simpleText": "Lights (feat. Earth, Wind & Fire)"}]}},
+				{"metadataRowRenderer": {"title": {"simpleText": "Artist"}, "contents": [{"simpleText": "Simon & Garfunkel"}]}}
+			]`,
+			expected: TrackInfo{Artist: "Simon & Garfunkel", Track: "Lights", Featured: []string{"Earth, Wind & Fire"}},
+		},
+		{
+			rows: `[
+				{"metadataRowRenderer": {"title": {"simpleText": "Song"}, "contents": [{"simpleText": "Fire Spirit (feat. Metric)"}]}},
+				{"metadataRowRenderer": {"title": {"simpleText": "Artist"}, "contents": [{"runs": [{"text": "The Glitch Mob"}, {"text": ", "}, {"text": "Metric"}]}]}}
+			]`,
+			expected: TrackInfo{Artist: "The Glitch Mob", Track: "Fire Spirit", Featured: []string{"Metric"}},
+		},
+	}
+
+	for _, test := range tests {
+		v, err := fastjson.Parse(test.rows)
+		require.NoError(t, err)
+
+		info, ok := ParseMetadataRowsJSON(v.GetArray())
+		require.True(t, ok)
+		require.Equal(t, test.expected, info)
+	}
+}
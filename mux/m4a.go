@@ -0,0 +1,334 @@
+package mux
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/lithdew/youtube/bmff"
+	"io"
+	"math"
+	"math/bits"
+)
+
+// M4A reads a fragmented audio-only MP4 from r, and rewraps it without transcoding into a non-fragmented MP4 that is
+// written to w. Samples are written to w as they are read and are followed by a 'moov' box describing them, such
+// that only a single fragment and the sample tables are ever held in memory. The size of the 'mdat' box holding the
+// samples is patched in once all samples are written.
+func M4A(w io.WriteSeeker, r io.Reader) error {
+	in, err := openMP4(r)
+	if err != nil {
+		return err
+	}
+
+	out := &countingWriter{w: w}
+
+	if _, err := bmff.New("ftyp", []byte("M4A \x00\x00\x00\x00M4A mp42isom")).WriteTo(out); err != nil {
+		return err
+	}
+
+	// Always use a 64-bit size for the 'mdat' box, as its size is not known upfront.
+
+	mdat := out.n
+
+	if _, err := out.Write([]byte{0, 0, 0, 1, 'm', 'd', 'a', 't', 0, 0, 0, 0, 0, 0, 0, 0}); err != nil {
+		return err
+	}
+
+	var table sampleTable
+
+	trex := in.moov.Find("mvex", "trex")
+	if trex == nil || len(trex.Data) < 24 {
+		return errors.New("trex box is missing or truncated")
+	}
+
+	for {
+		if err := in.next(); err != nil {
+			return fmt.Errorf("failed to read fragment: %w", err)
+		}
+
+		if in.moof == nil {
+			break
+		}
+
+		for _, traf := range in.moof.All("traf") {
+			if err := table.add(out, in, trex, traf); err != nil {
+				return err
+			}
+		}
+	}
+
+	end := out.n
+
+	if _, err := w.Seek(int64(mdat+8), io.SeekStart); err != nil {
+		return err
+	}
+
+	var size [8]byte
+	binary.BigEndian.PutUint64(size[:], end-mdat)
+
+	if _, err := w.Write(size[:]); err != nil {
+		return err
+	}
+
+	if _, err := w.Seek(int64(end), io.SeekStart); err != nil {
+		return err
+	}
+
+	moov, err := table.movie(in)
+	if err != nil {
+		return err
+	}
+
+	_, err = moov.WriteTo(out)
+	return err
+}
+
+// sampleTable accumulates the sample tables of a track.
+type sampleTable struct {
+	stts []uint32 // Pairs of sample counts and durations.
+	stsc []uint32 // Triplets of first chunks, samples per chunk, and sample description indices.
+	stsz []uint32
+	stco []uint64
+
+	duration uint64
+}
+
+// add writes the samples of a track fragment to w, and records them in the table.
+func (t *sampleTable) add(w *countingWriter, in *mp4Input, trex, traf *bmff.Box) error {
+	tfhd := traf.Child("tfhd")
+	if tfhd == nil || len(tfhd.Data) < 8 {
+		return errors.New("traf box is missing its tfhd box")
+	}
+
+	var (
+		flags  = tfhd.Flags()
+		fields = tfhd.Data[8:]
+
+		base        = in.offset
+		description = binary.BigEndian.Uint32(trex.Data[8:12])
+		duration    = binary.BigEndian.Uint32(trex.Data[12:16])
+		size        = binary.BigEndian.Uint32(trex.Data[16:20])
+	)
+
+	next := func(n int) (uint64, error) {
+		if len(fields) < n {
+			return 0, errors.New("tfhd box is truncated")
+		}
+
+		var v uint64
+		for _, b := range fields[:n] {
+			v = v<<8 | uint64(b)
+		}
+		fields = fields[n:]
+
+		return v, nil
+	}
+
+	for _, field := range []struct {
+		flag  uint32
+		width int
+		dst   interface{}
+	}{
+		{0x1, 8, &base},
+		{0x2, 4, &description},
+		{0x8, 4, &duration},
+		{0x10, 4, &size},
+		{0x20, 4, nil},
+	} {
+		if flags&field.flag == 0 {
+			continue
+		}
+
+		v, err := next(field.width)
+		if err != nil {
+			return err
+		}
+
+		switch dst := field.dst.(type) {
+		case *uint64:
+			*dst = v
+		case *uint32:
+			*dst = uint32(v)
+		}
+	}
+
+	position := base
+
+	for _, trun := range traf.All("trun") {
+		if len(trun.Data) < 8 {
+			return errors.New("trun box is truncated")
+		}
+
+		flags := trun.Flags()
+		count := binary.BigEndian.Uint32(trun.Data[4:8])
+		fields := trun.Data[8:]
+
+		if flags&0x1 != 0 {
+			if len(fields) < 4 {
+				return errors.New("trun box is truncated")
+			}
+			position = uint64(int64(base) + int64(int32(binary.BigEndian.Uint32(fields))))
+			fields = fields[4:]
+		}
+
+		if flags&0x4 != 0 {
+			if len(fields) < 4 {
+				return errors.New("trun box is truncated")
+			}
+			fields = fields[4:]
+		}
+
+		var (
+			width = 4 * bits.OnesCount32(flags&0xf00)
+			total uint64
+		)
+
+		if uint64(len(fields)) < uint64(count)*uint64(width) {
+			return errors.New("trun box is truncated")
+		}
+
+		for i := uint32(0); i < count; i++ {
+			d, s := duration, size
+
+			if flags&0x100 != 0 {
+				d, fields = binary.BigEndian.Uint32(fields), fields[4:]
+			}
+			if flags&0x200 != 0 {
+				s, fields = binary.BigEndian.Uint32(fields), fields[4:]
+			}
+			if flags&0x400 != 0 {
+				fields = fields[4:]
+			}
+			if flags&0x800 != 0 {
+				fields = fields[4:]
+			}
+
+			t.sample(d, s)
+			total += uint64(s)
+		}
+
+		if count == 0 {
+			continue
+		}
+
+		data, err := in.data(position, total)
+		if err != nil {
+			return err
+		}
+
+		t.chunk(w.n, count, description)
+
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+
+		position += total
+	}
+
+	return nil
+}
+
+// data returns the size bytes of media data at the given offset within the input.
+func (in *mp4Input) data(offset, size uint64) ([]byte, error) {
+	for i, buf := range in.mdat {
+		start := in.offsets[i]
+		if offset >= start && offset+size <= start+uint64(len(buf)) {
+			return buf[offset-start : offset-start+size], nil
+		}
+	}
+	return nil, fmt.Errorf("samples at offset %d of %d byte(s) lie outside of the fragment's media data", offset, size)
+}
+
+func (t *sampleTable) sample(duration, size uint32) {
+	if n := len(t.stts); n > 0 && t.stts[n-1] == duration {
+		t.stts[n-2]++
+	} else {
+		t.stts = append(t.stts, 1, duration)
+	}
+
+	t.stsz = append(t.stsz, size)
+	t.duration += uint64(duration)
+}
+
+func (t *sampleTable) chunk(offset uint64, samples, description uint32) {
+	if n := len(t.stsc); n == 0 || t.stsc[n-2] != samples || t.stsc[n-1] != description {
+		t.stsc = append(t.stsc, uint32(len(t.stco)+1), samples, description)
+	}
+
+	t.stco = append(t.stco, offset)
+}
+
+// movie returns the 'moov' box describing the samples in the table, based on the 'moov' box of the input.
+func (t *sampleTable) movie(in *mp4Input) (*bmff.Box, error) {
+	stts := appendUint32s(fullBoxHeader(0, 0), uint32(len(t.stts)/2))
+	stts = appendUint32s(stts, t.stts...)
+
+	stsc := appendUint32s(fullBoxHeader(0, 0), uint32(len(t.stsc)/3))
+	stsc = appendUint32s(stsc, t.stsc...)
+
+	stsz := appendUint32s(fullBoxHeader(0, 0), 0, uint32(len(t.stsz)))
+	stsz = appendUint32s(stsz, t.stsz...)
+
+	chunks := bmff.New("stco", appendUint32s(fullBoxHeader(0, 0), uint32(len(t.stco))))
+	if n := len(t.stco); n > 0 && t.stco[n-1] > math.MaxUint32 {
+		chunks.Type = "co64"
+	}
+
+	for _, offset := range t.stco {
+		if chunks.Type == "co64" {
+			chunks.Data = appendUint32s(chunks.Data, uint32(offset>>32))
+		}
+		chunks.Data = appendUint32s(chunks.Data, uint32(offset))
+	}
+
+	stbl := in.trak.Find("mdia", "minf", "stbl")
+	if stbl == nil || stbl.Child("stsd") == nil {
+		return nil, errors.New("track has no sample description")
+	}
+
+	for _, typ := range []string{"stts", "ctts", "stss", "stsc", "stsz", "stz2", "stco", "co64", "sbgp"} {
+		stbl.Remove(typ)
+	}
+
+	stbl.Children = append(stbl.Children, bmff.New("stts", stts), bmff.New("stsc", stsc), bmff.New("stsz", stsz), chunks)
+
+	movieDuration := t.duration * uint64(in.movieTimescale) / uint64(in.mediaTimescale)
+
+	setDuration(in.moov.Child("mvhd"), 16, 24, movieDuration)
+	setDuration(in.trak.Child("tkhd"), 20, 28, movieDuration)
+	setDuration(in.trak.Find("mdia", "mdhd"), 16, 24, t.duration)
+
+	in.moov.Remove("mvex")
+
+	return in.moov, nil
+}
+
+// setDuration sets the duration of a full box, which lies at offset0 in version 0 of the box and at offset1 in
+// version 1 of the box.
+func setDuration(b *bmff.Box, offset0, offset1 int, duration uint64) {
+	if b == nil {
+		return
+	}
+
+	if b.Version() == 1 {
+		putUint64(b.Data, offset1, duration)
+		return
+	}
+
+	if duration > math.MaxUint32 {
+		duration = math.MaxUint32
+	}
+
+	putUint32(b.Data, offset0, uint32(duration))
+}
+
+func fullBoxHeader(version uint8, flags uint32) []byte {
+	return []byte{version, byte(flags >> 16), byte(flags >> 8), byte(flags)}
+}
+
+func appendUint32s(dst []byte, vs ...uint32) []byte {
+	for _, v := range vs {
+		dst = append(dst, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+	}
+	return dst
+}
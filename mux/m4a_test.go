@@ -0,0 +1,92 @@
+package mux
+
+import (
+	"encoding/binary"
+	"github.com/lithdew/youtube/bmff"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testAudioMP4 encodes a fragmented MP4 with a single audio track, with one fragment per given list of samples. Each
+// sample lasts 1024 units of the track's timescale.
+func testAudioMP4(fragments ...[]string) []byte {
+	buf := testMP4(1000, 44100, 0, false)
+
+	for i, samples := range fragments {
+		trun := fullBox(0, 0x301, uint32(len(samples)), uint32(0))
+		for _, sample := range samples {
+			trun = append(trun, fullBox(0, 0, uint32(1024), uint32(len(sample)))[4:]...)
+		}
+
+		moof := bmff.New("moof", nil,
+			bmff.New("mfhd", fullBox(0, 0, uint32(i+1))),
+			bmff.New("traf", nil,
+				bmff.New("tfhd", fullBox(0, 0x20000, uint32(1))),
+				bmff.New("tfdt", fullBox(1, 0, uint64(i*len(samples)*1024))),
+				bmff.New("trun", trun),
+			),
+		)
+
+		// The data offset of the run is relative to the start of the moof box.
+
+		binary.BigEndian.PutUint32(trun[8:], uint32(moof.Size()+8))
+
+		buf = moof.AppendTo(buf)
+		buf = bmff.New("mdat", []byte(strings.Join(samples, ""))).AppendTo(buf)
+	}
+
+	return buf
+}
+
+func TestM4A(t *testing.T) {
+	input := testAudioMP4([]string{"aa", "bbb", "c"}, []string{"dddd", "e"}, []string{"ff", "gg", "hh"})
+
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	f, err := os.Create(filepath.Join(dir, "audio.m4a"))
+	require.NoError(t, err)
+	defer f.Close()
+
+	require.NoError(t, M4A(f, strings.NewReader(string(input))))
+
+	buf, err := ioutil.ReadFile(f.Name())
+	require.NoError(t, err)
+
+	boxes, err := bmff.ParseAll(buf)
+	require.NoError(t, err)
+	require.Len(t, boxes, 3)
+
+	require.Equal(t, "ftyp", boxes[0].Type)
+	require.Equal(t, "mdat", boxes[1].Type)
+	require.Equal(t, "moov", boxes[2].Type)
+
+	require.Equal(t, "aabbbcddddeffgghh", string(boxes[1].Data))
+
+	moov := boxes[2]
+	require.Nil(t, moov.Child("mvex"))
+
+	stbl := moov.Find("trak", "mdia", "minf", "stbl")
+	require.NotNil(t, stbl.Child("stsd"))
+
+	// All samples share the same duration.
+
+	require.Equal(t, fullBox(0, 0, uint32(1), uint32(8), uint32(1024)), stbl.Child("stts").Data)
+
+	// Each fragment is a chunk. Consecutive chunks with the same number of samples share an entry.
+
+	require.Equal(t, fullBox(0, 0, uint32(3), uint32(1), uint32(3), uint32(1), uint32(2), uint32(2), uint32(1), uint32(3), uint32(3), uint32(1)), stbl.Child("stsc").Data)
+	require.Equal(t, fullBox(0, 0, uint32(0), uint32(8), uint32(2), uint32(3), uint32(1), uint32(4), uint32(1), uint32(2), uint32(2), uint32(2)), stbl.Child("stsz").Data)
+
+	mdat := boxes[0].Size() + 16
+	require.Equal(t, fullBox(0, 0, uint32(3), uint32(mdat), uint32(mdat+6), uint32(mdat+11)), stbl.Child("stco").Data)
+
+	require.EqualValues(t, 8*1024, binary.BigEndian.Uint32(moov.Find("trak", "mdia", "mdhd").Data[16:]))
+	require.EqualValues(t, 8*1024*1000/44100, binary.BigEndian.Uint32(moov.Child("mvhd").Data[16:]))
+	require.EqualValues(t, 8*1024*1000/44100, binary.BigEndian.Uint32(moov.Find("trak", "tkhd").Data[20:]))
+}
@@ -0,0 +1,445 @@
+package mux
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/lithdew/youtube"
+	"github.com/lithdew/youtube/bmff"
+	"io"
+	"io/ioutil"
+	"math"
+)
+
+// DownloadMP4 downloads the video-only MP4 format video and the audio-only MP4 format audio of player p segment by
+// segment, and muxes them into a single MP4 that is written to w as the download progresses.
+func DownloadMP4(ctx context.Context, p youtube.Player, video, audio youtube.Format, w io.Writer) error {
+	return download(ctx, p, video, audio, w, "/mp4", MP4)
+}
+
+// MP4 reads a fragmented video-only MP4 from video and a fragmented audio-only MP4 from audio, and writes a single
+// fragmented MP4 containing both tracks to w. Fragments from both inputs are interleaved by their decode time, and
+// only a single fragment per input is ever held in memory.
+func MP4(w io.Writer, video, audio io.Reader) error {
+	v, err := openMP4(video)
+	if err != nil {
+		return fmt.Errorf("failed to read video: %w", err)
+	}
+
+	a, err := openMP4(audio)
+	if err != nil {
+		return fmt.Errorf("failed to read audio: %w", err)
+	}
+
+	out := &countingWriter{w: w}
+
+	if _, err := mp4Header(v, a).WriteTo(out); err != nil {
+		return err
+	}
+
+	if _, err := mp4Movie(v, a).WriteTo(out); err != nil {
+		return err
+	}
+
+	if err := v.next(); err != nil {
+		return fmt.Errorf("failed to read video fragment: %w", err)
+	}
+
+	if err := a.next(); err != nil {
+		return fmt.Errorf("failed to read audio fragment: %w", err)
+	}
+
+	var sequence uint32
+
+	for v.moof != nil || a.moof != nil {
+		in, id := v, uint32(videoTrackID)
+		if v.moof == nil || (a.moof != nil && a.time() < v.time()) {
+			in, id = a, audioTrackID
+		}
+
+		sequence++
+
+		if err := in.writeFragment(out, id, sequence); err != nil {
+			return err
+		}
+
+		if err := in.next(); err != nil {
+			return fmt.Errorf("failed to read fragment: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// mp4Input is a fragmented MP4 with a single track that is read one fragment at a time.
+type mp4Input struct {
+	r    *countingReader
+	ftyp *bmff.Box
+	moov *bmff.Box
+	trak *bmff.Box
+
+	// Timescales of the movie and of the track's media.
+	movieTimescale uint32
+	mediaTimescale uint32
+
+	// The current fragment, its offset within the input, and its media data alongside the offsets of the media data
+	// within the input.
+	moof    *bmff.Box
+	offset  uint64
+	mdat    [][]byte
+	offsets []uint64
+
+	// A box header that was read past the end of the current fragment.
+	pending *boxHeader
+}
+
+type boxHeader struct {
+	typ    string
+	size   uint64
+	offset uint64
+}
+
+// openMP4 reads boxes from r up to and including its 'moov' box.
+func openMP4(r io.Reader) (*mp4Input, error) {
+	in := &mp4Input{r: &countingReader{r: r}}
+
+	for in.moov == nil {
+		h, err := in.header()
+		if err == io.EOF {
+			return nil, errors.New("no moov box found")
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch h.typ {
+		case "ftyp":
+			in.ftyp, err = in.read(h)
+		case "moov":
+			in.moov, err = in.read(h)
+		default:
+			err = in.skip(h)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	traks := in.moov.All("trak")
+	if len(traks) != 1 {
+		return nil, fmt.Errorf("expected exactly one track, but found %d", len(traks))
+	}
+	in.trak = traks[0]
+
+	mvhd, mdhd := in.moov.Child("mvhd"), in.trak.Find("mdia", "mdhd")
+	if mvhd == nil || mdhd == nil {
+		return nil, errors.New("moov box is missing its mvhd or mdhd box")
+	}
+
+	var ok bool
+
+	if in.movieTimescale, ok = timescaleOf(mvhd); !ok {
+		return nil, errors.New("mvhd box is truncated")
+	}
+
+	if in.mediaTimescale, ok = timescaleOf(mdhd); !ok {
+		return nil, errors.New("mdhd box is truncated")
+	}
+
+	if in.moov.Find("mvex", "trex") == nil {
+		return nil, errors.New("input is not a fragmented mp4")
+	}
+
+	return in, nil
+}
+
+func (in *mp4Input) header() (boxHeader, error) {
+	if in.pending != nil {
+		h := *in.pending
+		in.pending = nil
+		return h, nil
+	}
+
+	offset := in.r.n
+
+	typ, size, _, err := bmff.ReadHeader(in.r)
+	if err != nil {
+		return boxHeader{}, err
+	}
+
+	return boxHeader{typ: typ, size: size, offset: offset}, nil
+}
+
+func (in *mp4Input) payload(h boxHeader) ([]byte, error) {
+	if h.size == math.MaxUint64 {
+		return ioutil.ReadAll(io.LimitReader(in.r, int64(MaxBufferSize)+1))
+	}
+
+	if h.size > MaxBufferSize {
+		return nil, fmt.Errorf("box %q of %d byte(s) exceeds limit of %d byte(s)", h.typ, h.size, MaxBufferSize)
+	}
+
+	buf := make([]byte, h.size)
+	if _, err := io.ReadFull(in.r, buf); err != nil {
+		return nil, unexpected(err)
+	}
+
+	return buf, nil
+}
+
+func (in *mp4Input) read(h boxHeader) (*bmff.Box, error) {
+	buf, err := in.payload(h)
+	if err != nil {
+		return nil, err
+	}
+	return bmff.ParsePayload(h.typ, buf)
+}
+
+func (in *mp4Input) skip(h boxHeader) error {
+	if h.size == math.MaxUint64 {
+		_, err := io.Copy(ioutil.Discard, in.r)
+		return err
+	}
+
+	_, err := io.CopyN(ioutil.Discard, in.r, int64(h.size))
+	return unexpected(err)
+}
+
+// next reads the next 'moof' box and all 'mdat' boxes that follow it. in.moof is nil once there are no fragments
+// left to be read.
+func (in *mp4Input) next() error {
+	in.moof, in.mdat, in.offsets = nil, nil, nil
+
+	for {
+		h, err := in.header()
+		if err == io.EOF {
+			if in.moof != nil && len(in.mdat) == 0 {
+				return errors.New("moof box is not followed by a mdat box")
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case h.typ == "moof" && in.moof != nil:
+			in.pending = &h
+			return nil
+		case h.typ == "moof":
+			if in.moof, err = in.read(h); err != nil {
+				return err
+			}
+			in.offset = h.offset
+
+			if in.moof.Find("traf", "tfdt") == nil {
+				return errors.New("fragment is missing its tfdt box")
+			}
+		case h.typ == "mdat" && in.moof != nil:
+			offset := in.r.n
+
+			buf, err := in.payload(h)
+			if err != nil {
+				return err
+			}
+
+			in.mdat = append(in.mdat, buf)
+			in.offsets = append(in.offsets, offset)
+		default:
+			if err := in.skip(h); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// time returns the decode time of the current fragment in seconds.
+func (in *mp4Input) time() float64 {
+	tfdt := in.moof.Find("traf", "tfdt")
+
+	var t uint64
+
+	switch {
+	case tfdt.Version() == 1 && len(tfdt.Data) >= 12:
+		t = binary.BigEndian.Uint64(tfdt.Data[4:12])
+	case len(tfdt.Data) >= 8:
+		t = uint64(binary.BigEndian.Uint32(tfdt.Data[4:8]))
+	}
+
+	return float64(t) / float64(in.mediaTimescale)
+}
+
+// writeFragment writes the current fragment to w, renumbering its track to id and its sequence number to sequence.
+// Absolute data offsets are shifted by the distance the fragment has moved in between the input and the output.
+func (in *mp4Input) writeFragment(w *countingWriter, id, sequence uint32) error {
+	if mfhd := in.moof.Child("mfhd"); mfhd != nil && len(mfhd.Data) >= 8 {
+		binary.BigEndian.PutUint32(mfhd.Data[4:8], sequence)
+	}
+
+	for _, traf := range in.moof.All("traf") {
+		tfhd := traf.Child("tfhd")
+		if tfhd == nil || len(tfhd.Data) < 8 {
+			return errors.New("traf box is missing its tfhd box")
+		}
+
+		binary.BigEndian.PutUint32(tfhd.Data[4:8], id)
+
+		if tfhd.Flags()&0x1 != 0 {
+			if len(tfhd.Data) < 16 {
+				return errors.New("tfhd box is truncated")
+			}
+
+			base := binary.BigEndian.Uint64(tfhd.Data[8:16])
+			binary.BigEndian.PutUint64(tfhd.Data[8:16], base+w.n-in.offset)
+		}
+	}
+
+	if _, err := in.moof.WriteTo(w); err != nil {
+		return err
+	}
+
+	for _, buf := range in.mdat {
+		size := uint64(len(buf))
+		size += bmff.HeaderSize(size + 8)
+
+		if _, err := w.Write(bmff.AppendHeader(nil, "mdat", size)); err != nil {
+			return err
+		}
+		if _, err := w.Write(buf); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// mp4Header returns the 'ftyp' box of the output, preferring that of the video.
+func mp4Header(v, a *mp4Input) *bmff.Box {
+	if v.ftyp != nil {
+		return v.ftyp
+	}
+	if a.ftyp != nil {
+		return a.ftyp
+	}
+
+	return bmff.New("ftyp", []byte("isom\x00\x00\x02\x00isomiso5iso6mp41"))
+}
+
+// mp4Movie returns the 'moov' box of the output, which holds the tracks of both the video and the audio. The
+// movie's timescale is that of the video.
+func mp4Movie(v, a *mp4Input) *bmff.Box {
+	mvhd := v.moov.Child("mvhd")
+	putUint32(mvhd.Data, len(mvhd.Data)-4, audioTrackID+1)
+
+	tracks := []struct {
+		in *mp4Input
+		id uint32
+	}{{in: v, id: videoTrackID}, {in: a, id: audioTrackID}}
+
+	// Renumber the tracks, and rescale durations in the audio track expressed in the audio's movie timescale to the
+	// video's movie timescale.
+
+	for _, track := range tracks {
+		in, id := track.in, track.id
+
+		tkhd := in.trak.Child("tkhd")
+		if tkhd == nil {
+			continue
+		}
+
+		if tkhd.Version() == 1 {
+			putUint32(tkhd.Data, 20, id)
+		} else {
+			putUint32(tkhd.Data, 12, id)
+		}
+
+		if in != a {
+			continue
+		}
+
+		if tkhd.Version() == 1 {
+			rescaleAt(tkhd.Data, 28, true, a.movieTimescale, v.movieTimescale)
+		} else {
+			rescaleAt(tkhd.Data, 20, false, a.movieTimescale, v.movieTimescale)
+		}
+
+		if elst := in.trak.Find("edts", "elst"); elst != nil {
+			size := 12
+			if elst.Version() == 1 {
+				size = 20
+			}
+
+			for offset := 8; offset+size <= len(elst.Data); offset += size {
+				rescaleAt(elst.Data, offset, elst.Version() == 1, a.movieTimescale, v.movieTimescale)
+			}
+		}
+	}
+
+	mvex := bmff.New("mvex", nil)
+
+	if mehd := v.moov.Find("mvex", "mehd"); mehd != nil {
+		mvex.Children = append(mvex.Children, mehd)
+	}
+
+	for _, track := range tracks {
+		trex := track.in.moov.Find("mvex", "trex")
+		putUint32(trex.Data, 4, track.id)
+
+		mvex.Children = append(mvex.Children, trex)
+	}
+
+	return bmff.New("moov", nil, mvhd, v.trak, a.trak, mvex)
+}
+
+// timescaleOf returns the timescale of a 'mvhd' or 'mdhd' box.
+func timescaleOf(b *bmff.Box) (uint32, bool) {
+	offset := 12
+	if b.Version() == 1 {
+		offset = 20
+	}
+
+	if len(b.Data) < offset+4 {
+		return 0, false
+	}
+
+	timescale := binary.BigEndian.Uint32(b.Data[offset : offset+4])
+
+	return timescale, timescale > 0
+}
+
+// rescaleAt rescales the 32-bit or 64-bit duration at offset within buf from one timescale to another. Zero
+// durations and durations with all bits set, which denote an unknown duration, are left as is.
+func rescaleAt(buf []byte, offset int, wide bool, from, to uint32) {
+	if wide {
+		if len(buf) < offset+8 {
+			return
+		}
+		if d := binary.BigEndian.Uint64(buf[offset:]); d != 0 && d != math.MaxUint64 {
+			putUint64(buf, offset, uint64(float64(d)*float64(to)/float64(from)))
+		}
+		return
+	}
+
+	if len(buf) < offset+4 {
+		return
+	}
+	if d := binary.BigEndian.Uint32(buf[offset:]); d != 0 && d != math.MaxUint32 {
+		if scaled := uint64(d) * uint64(to) / uint64(from); scaled <= math.MaxUint32 {
+			putUint32(buf, offset, uint32(scaled))
+		}
+	}
+}
+
+func putUint32(buf []byte, offset int, v uint32) {
+	if offset >= 0 && len(buf) >= offset+4 {
+		binary.BigEndian.PutUint32(buf[offset:], v)
+	}
+}
+
+func putUint64(buf []byte, offset int, v uint64) {
+	if offset >= 0 && len(buf) >= offset+8 {
+		binary.BigEndian.PutUint64(buf[offset:], v)
+	}
+}
@@ -0,0 +1,168 @@
+package mux
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/lithdew/youtube/bmff"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// fullBox encodes the payload of a full box comprised of a version, flags, and 32-bit or 64-bit fields.
+func fullBox(version uint8, flags uint32, fields ...interface{}) []byte {
+	buf := []byte{version, byte(flags >> 16), byte(flags >> 8), byte(flags)}
+	for _, field := range fields {
+		switch v := field.(type) {
+		case uint32:
+			buf = append(buf, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+		case uint64:
+			buf = append(buf, byte(v>>56), byte(v>>48), byte(v>>40), byte(v>>32), byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+		case []byte:
+			buf = append(buf, v...)
+		}
+	}
+	return buf
+}
+
+type testFragment struct {
+	time uint64
+	data string
+}
+
+// testMP4 encodes a fragmented MP4 with a single track, with one fragment per given fragment. If base is true,
+// fragments carry an absolute base data offset.
+func testMP4(movieTimescale, mediaTimescale uint32, duration uint32, base bool, fragments ...testFragment) []byte {
+	mvhd := fullBox(0, 0, uint32(0), uint32(0), movieTimescale, duration, make([]byte, 76), uint32(2))
+	tkhd := fullBox(0, 3, uint32(0), uint32(0), uint32(1), uint32(0), duration, make([]byte, 60))
+	elst := fullBox(0, 0, uint32(1), duration, uint32(0), uint32(0x00010000))
+	mdhd := fullBox(0, 0, uint32(0), uint32(0), mediaTimescale, uint32(0), uint32(0))
+	trex := fullBox(0, 0, uint32(1), uint32(1), uint32(0), uint32(0), uint32(0))
+
+	moov := bmff.New("moov", nil,
+		bmff.New("mvhd", mvhd),
+		bmff.New("trak", nil,
+			bmff.New("tkhd", tkhd),
+			bmff.New("edts", nil, bmff.New("elst", elst)),
+			bmff.New("mdia", nil,
+				bmff.New("mdhd", mdhd),
+				bmff.New("minf", nil, bmff.New("stbl", nil,
+					bmff.New("stsd", fullBox(0, 0, uint32(0))),
+					bmff.New("stts", fullBox(0, 0, uint32(0))),
+					bmff.New("stsc", fullBox(0, 0, uint32(0))),
+					bmff.New("stsz", fullBox(0, 0, uint32(0), uint32(0))),
+					bmff.New("stco", fullBox(0, 0, uint32(0))),
+				)),
+			),
+		),
+		bmff.New("mvex", nil, bmff.New("trex", trex)),
+	)
+
+	buf := bmff.New("ftyp", []byte("dash\x00\x00\x00\x00iso6mp41")).AppendTo(nil)
+	buf = moov.AppendTo(buf)
+	buf = bmff.New("sidx", fullBox(1, 0, uint32(1), uint32(1000))).AppendTo(buf)
+
+	for i, f := range fragments {
+		tfhd := fullBox(0, 0x20000, uint32(1))
+		if base {
+			tfhd = fullBox(0, 0x1, uint32(1), uint64(len(buf)))
+		}
+
+		moof := bmff.New("moof", nil,
+			bmff.New("mfhd", fullBox(0, 0, uint32(i+1))),
+			bmff.New("traf", nil,
+				bmff.New("tfhd", tfhd),
+				bmff.New("tfdt", fullBox(1, 0, f.time)),
+			),
+		)
+
+		buf = moof.AppendTo(buf)
+		buf = bmff.New("mdat", []byte(f.data)).AppendTo(buf)
+	}
+
+	return buf
+}
+
+func TestMP4(t *testing.T) {
+	video := testMP4(1000, 90000, 6000, false,
+		testFragment{time: 0, data: "v0"},
+		testFragment{time: 180000, data: "v1"},
+		testFragment{time: 360000, data: "v2"},
+	)
+
+	audio := testMP4(48000, 48000, 288000, true,
+		testFragment{time: 0, data: "a0"},
+		testFragment{time: 72000, data: "a1"},
+		testFragment{time: 144000, data: "a2"},
+		testFragment{time: 216000, data: "a3"},
+	)
+
+	var out bytes.Buffer
+	require.NoError(t, MP4(&out, bytes.NewReader(video), bytes.NewReader(audio)))
+
+	boxes, err := bmff.ParseAll(out.Bytes())
+	require.NoError(t, err)
+	require.Len(t, boxes, 2+2*7)
+
+	require.Equal(t, "ftyp", boxes[0].Type)
+	require.Equal(t, "moov", boxes[1].Type)
+
+	moov := boxes[1]
+
+	mvhd := moov.Child("mvhd")
+	require.EqualValues(t, 3, binary.BigEndian.Uint32(mvhd.Data[len(mvhd.Data)-4:]))
+
+	traks := moov.All("trak")
+	require.Len(t, traks, 2)
+
+	require.EqualValues(t, 1, binary.BigEndian.Uint32(traks[0].Child("tkhd").Data[12:]))
+	require.EqualValues(t, 2, binary.BigEndian.Uint32(traks[1].Child("tkhd").Data[12:]))
+
+	// The audio track's durations are rescaled to the video's movie timescale.
+
+	require.EqualValues(t, 6000, binary.BigEndian.Uint32(traks[1].Child("tkhd").Data[20:]))
+	require.EqualValues(t, 6000, binary.BigEndian.Uint32(traks[1].Find("edts", "elst").Data[8:]))
+
+	trex := moov.Find("mvex").All("trex")
+	require.Len(t, trex, 2)
+	require.EqualValues(t, 1, binary.BigEndian.Uint32(trex[0].Data[4:]))
+	require.EqualValues(t, 2, binary.BigEndian.Uint32(trex[1].Data[4:]))
+
+	// Fragments are interleaved by decode time, sequentially numbered, and keep absolute data offsets intact.
+
+	offset := boxes[0].Size() + boxes[1].Size()
+
+	expected := []struct {
+		data string
+		id   uint32
+	}{{"v0", 1}, {"a0", 2}, {"a1", 2}, {"v1", 1}, {"a2", 2}, {"v2", 1}, {"a3", 2}}
+
+	for i, e := range expected {
+		moof, mdat := boxes[2+2*i], boxes[3+2*i]
+
+		require.Equal(t, "moof", moof.Type)
+		require.Equal(t, "mdat", mdat.Type)
+		require.Equal(t, e.data, string(mdat.Data))
+
+		require.EqualValues(t, i+1, binary.BigEndian.Uint32(moof.Child("mfhd").Data[4:]))
+
+		tfhd := moof.Find("traf", "tfhd")
+		require.Equal(t, e.id, binary.BigEndian.Uint32(tfhd.Data[4:]))
+
+		if tfhd.Flags()&0x1 != 0 {
+			require.Equal(t, offset, binary.BigEndian.Uint64(tfhd.Data[8:]))
+		}
+
+		offset += moof.Size() + mdat.Size()
+	}
+}
+
+func TestMP4NotFragmented(t *testing.T) {
+	video := testMP4(1000, 90000, 6000, false, testFragment{time: 0, data: "v0"})
+
+	moov := bmff.New("moov", nil,
+		bmff.New("mvhd", fullBox(0, 0, uint32(0), uint32(0), uint32(1000), uint32(0), make([]byte, 80))),
+		bmff.New("trak", nil, bmff.New("mdia", nil, bmff.New("mdhd", fullBox(0, 0, uint32(0), uint32(0), uint32(44100))))),
+	)
+
+	require.Error(t, MP4(new(bytes.Buffer), bytes.NewReader(video), bytes.NewReader(moov.AppendTo(nil))))
+}
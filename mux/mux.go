@@ -0,0 +1,73 @@
+// Package mux combines separate video-only and audio-only formats of a stream into a single file without
+// transcoding.
+package mux
+
+import (
+	"context"
+	"fmt"
+	"github.com/lithdew/youtube"
+	"io"
+	"strings"
+)
+
+// MaxBufferSize is the max size of a single box or element, such as a fragment's media data or a cluster, that is
+// read into memory while muxing.
+var MaxBufferSize uint64 = 256 * 1024 * 1024
+
+const (
+	videoTrackID = 1
+	audioTrackID = 2
+)
+
+// download opens formats video and audio of player p for reading, and muxes them into w using mux. Both formats
+// must be of the container described by mime.
+func download(ctx context.Context, p youtube.Player, video, audio youtube.Format, w io.Writer, mime string, mux func(w io.Writer, video, audio io.Reader) error) error {
+	for _, f := range []youtube.Format{video, audio} {
+		if !strings.Contains(f.MIMEType, mime) {
+			return fmt.Errorf("format with itag %d has mime type %q, but expected %q", f.ITag, f.MIMEType, mime)
+		}
+	}
+
+	v, err := p.Open(ctx, video)
+	if err != nil {
+		return err
+	}
+
+	a, err := p.Open(ctx, audio)
+	if err != nil {
+		return err
+	}
+
+	return mux(w, v, a)
+}
+
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// countingReader counts the number of bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n uint64
+}
+
+func (c *countingReader) Read(b []byte) (int, error) {
+	n, err := c.r.Read(b)
+	c.n += uint64(n)
+	return n, err
+}
+
+// countingWriter counts the number of bytes written to w.
+type countingWriter struct {
+	w io.Writer
+	n uint64
+}
+
+func (c *countingWriter) Write(b []byte) (int, error) {
+	n, err := c.w.Write(b)
+	c.n += uint64(n)
+	return n, err
+}
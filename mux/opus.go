@@ -0,0 +1,338 @@
+package mux
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/lithdew/youtube/ebml"
+	"github.com/lithdew/youtube/ogg"
+	"io"
+	"io/ioutil"
+)
+
+// Vendor is the vendor string written into the comment headers of remuxed audio.
+var Vendor = "github.com/lithdew/youtube"
+
+// Opus reads the Opus track of the WebM from r, and rewraps it without transcoding into an Ogg Opus stream (RFC 7845)
+// that is written to w. Only a single cluster is ever held in memory.
+func Opus(w io.Writer, r io.Reader) error {
+	in := &countingReader{r: r}
+
+	id, size, _, err := ebml.ReadHeader(in)
+	if err != nil {
+		return err
+	}
+
+	if id != ebml.IDHeader {
+		return errors.New("input does not start with an ebml header")
+	}
+
+	if _, err := io.CopyN(ioutil.Discard, in, int64(size)); err != nil {
+		return unexpected(err)
+	}
+
+	if id, size, _, err = ebml.ReadHeader(in); err != nil {
+		return unexpected(err)
+	}
+
+	if id != ebml.IDSegment {
+		return fmt.Errorf("expected segment, but got element %#x", id)
+	}
+
+	end := in.n + size
+	if size == ebml.UnknownSize {
+		end = ebml.UnknownSize
+	}
+
+	var (
+		out     = ogg.NewWriter(w, 1)
+		track   uint64
+		buf     []byte
+		granule uint64
+
+		// The last packet read, which is only written once the next packet is read such that the stream may be
+		// trimmed by the discard padding of its last packet.
+		pending []byte
+		padding int64
+	)
+
+	for in.n < end {
+		id, size, _, err := ebml.ReadHeader(in)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		switch id {
+		case ebml.IDTracks, ebml.IDCluster:
+		default:
+			if size == ebml.UnknownSize {
+				return fmt.Errorf("element %#x has an unknown size", id)
+			}
+			if _, err := io.CopyN(ioutil.Discard, in, int64(size)); err != nil {
+				return unexpected(err)
+			}
+			continue
+		}
+
+		if size == ebml.UnknownSize || size > MaxBufferSize {
+			return fmt.Errorf("element %#x has an unknown size or is too large", id)
+		}
+
+		buf = append(buf[:0], make([]byte, size)...)
+		if _, err := io.ReadFull(in, buf); err != nil {
+			return unexpected(err)
+		}
+
+		children, err := ebml.ParseAll(buf)
+		if err != nil {
+			return fmt.Errorf("failed to parse element %#x: %w", id, err)
+		}
+
+		if id == ebml.IDTracks {
+			if track != 0 {
+				return errors.New("found more than one tracks element")
+			}
+
+			var head []byte
+
+			if track, head, err = opusTrack(children); err != nil {
+				return err
+			}
+
+			if err := out.WritePacket(head, 0); err != nil {
+				return err
+			}
+			if err := out.Flush(); err != nil {
+				return err
+			}
+
+			if err := out.WritePacket(opusTags(nil), 0); err != nil {
+				return err
+			}
+			if err := out.Flush(); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if track == 0 {
+			return errors.New("cluster precedes tracks")
+		}
+
+		for _, child := range children {
+			var block []byte
+
+			discard := int64(0)
+
+			switch child.ID {
+			case ebml.IDSimpleBlock:
+				block = child.Data
+			case ebml.IDBlockGroup:
+				group, err := child.Children()
+				if err != nil {
+					return fmt.Errorf("failed to parse block group: %w", err)
+				}
+				for _, e := range group {
+					switch e.ID {
+					case ebml.IDBlock:
+						block = e.Data
+					case ebml.IDDiscardPadding:
+						discard = signed(e)
+					}
+				}
+			}
+
+			if block == nil {
+				continue
+			}
+
+			number, n, err := ebml.ParseVint(block)
+			if err != nil {
+				return fmt.Errorf("block track number is malformed: %w", err)
+			}
+
+			if number != track {
+				continue
+			}
+
+			if len(block) < n+3 {
+				return errors.New("block is truncated")
+			}
+
+			if block[n+2]&0x06 != 0 {
+				return errors.New("laced blocks are not supported")
+			}
+
+			if pending != nil {
+				if err := out.WritePacket(pending, granule); err != nil {
+					return err
+				}
+			}
+
+			pending = append(pending[:0], block[n+3:]...)
+			padding = discard
+			granule += opusSamples(pending)
+		}
+	}
+
+	if track == 0 {
+		return errors.New("no tracks found")
+	}
+
+	if pending != nil {
+		trim := uint64(0)
+		if padding > 0 {
+			trim = uint64(padding) * 48000 / 1000000000
+		}
+		if trim > granule {
+			trim = granule
+		}
+
+		if err := out.WritePacket(pending, granule-trim); err != nil {
+			return err
+		}
+	}
+
+	return out.Close()
+}
+
+// opusTrack finds the Opus track amongst the children of a Tracks element, returning its track number alongside its
+// identification header.
+func opusTrack(tracks []ebml.Element) (uint64, []byte, error) {
+	for _, track := range tracks {
+		if track.ID != ebml.IDTrackEntry {
+			continue
+		}
+
+		fields, err := track.Children()
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to parse track entry: %w", err)
+		}
+
+		var (
+			number, delay uint64
+			codec         string
+			head          []byte
+			channels      = uint64(2)
+			rate          = 48000.0
+		)
+
+		for _, field := range fields {
+			switch field.ID {
+			case ebml.IDTrackNumber:
+				number = field.Uint()
+			case ebml.IDCodecID:
+				codec = string(field.Data)
+			case ebml.IDCodecPrivate:
+				head = field.Data
+			case ebml.IDCodecDelay:
+				delay = field.Uint()
+			case ebml.IDAudio:
+				audio, err := field.Children()
+				if err != nil {
+					return 0, nil, fmt.Errorf("failed to parse audio settings: %w", err)
+				}
+				for _, e := range audio {
+					switch e.ID {
+					case ebml.IDChannels:
+						channels = e.Uint()
+					case ebml.IDSamplingFrequency:
+						rate = e.Float()
+					}
+				}
+			}
+		}
+
+		if codec != "A_OPUS" || number == 0 {
+			continue
+		}
+
+		// Synthesize an identification header for channel mapping family 0 should the track not carry one.
+
+		if len(head) < 19 || string(head[:8]) != "OpusHead" {
+			if channels > 2 {
+				return 0, nil, fmt.Errorf("opus track with %d channels has no identification header", channels)
+			}
+
+			head = make([]byte, 19)
+			copy(head, "OpusHead")
+			head[8] = 1
+			head[9] = byte(channels)
+			binary.LittleEndian.PutUint16(head[10:12], uint16(delay*48000/1000000000))
+			binary.LittleEndian.PutUint32(head[12:16], uint32(rate))
+		}
+
+		return number, head, nil
+	}
+
+	return 0, nil, errors.New("no opus track found")
+}
+
+// opusTags encodes an Opus comment header holding the given comments, each of the form 'KEY=value'.
+func opusTags(comments []string) []byte {
+	buf := append([]byte("OpusTags"), make([]byte, 4)...)
+	binary.LittleEndian.PutUint32(buf[8:], uint32(len(Vendor)))
+	buf = append(buf, Vendor...)
+
+	buf = appendUint32LE(buf, uint32(len(comments)))
+	for _, comment := range comments {
+		buf = appendUint32LE(buf, uint32(len(comment)))
+		buf = append(buf, comment...)
+	}
+
+	return buf
+}
+
+// opusSamples returns the number of samples at 48kHz held by an Opus packet given its TOC byte (RFC 6716 3.1).
+func opusSamples(packet []byte) uint64 {
+	if len(packet) == 0 {
+		return 0
+	}
+
+	config := packet[0] >> 3
+
+	var size uint64 // Size of a frame in units of 1/400th of a second.
+
+	switch {
+	case config < 12:
+		size = []uint64{4, 8, 16, 24}[config%4]
+	case config < 16:
+		size = []uint64{4, 8}[config%2]
+	default:
+		size = []uint64{1, 2, 4, 8}[config%4]
+	}
+
+	frames := uint64(1)
+
+	switch packet[0] & 0x3 {
+	case 1, 2:
+		frames = 2
+	case 3:
+		if len(packet) < 2 {
+			return 0
+		}
+		frames = uint64(packet[1] & 0x3f)
+	}
+
+	return frames * size * 120
+}
+
+// signed decodes the data of an element as a signed integer.
+func signed(e ebml.Element) int64 {
+	if len(e.Data) == 0 {
+		return 0
+	}
+	v := int64(int8(e.Data[0]))
+	for _, b := range e.Data[1:] {
+		v = v<<8 | int64(b)
+	}
+	return v
+}
+
+func appendUint32LE(dst []byte, v uint32) []byte {
+	return append(dst, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
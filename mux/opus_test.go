@@ -0,0 +1,90 @@
+package mux
+
+import (
+	"bytes"
+	"github.com/lithdew/youtube/ebml"
+	"github.com/lithdew/youtube/ogg"
+	"github.com/stretchr/testify/require"
+	"io"
+	"testing"
+)
+
+func TestOpus(t *testing.T) {
+	head := []byte("OpusHead\x01\x02\x38\x01\x80\xbb\x00\x00\x00\x00\x00")
+
+	var entry []byte
+	entry = ebml.AppendUint(entry, ebml.IDTrackNumber, 1)
+	entry = ebml.AppendElement(entry, ebml.IDCodecID, []byte("A_OPUS"))
+	entry = ebml.AppendElement(entry, ebml.IDCodecPrivate, head)
+
+	// Each packet is a single 20ms CELT frame holding 960 samples.
+
+	packets := [][]byte{{0xf8, 1, 2, 3}, {0xf8, 4, 5}, {0xf8, 6}, {0xf8, 7, 8, 9, 10}}
+
+	block := func(packet []byte) []byte {
+		return append([]byte{0x81, 0, 0, 0x80}, packet...)
+	}
+
+	var first, second []byte
+	first = ebml.AppendUint(first, ebml.IDTimecode, 0)
+	first = ebml.AppendElement(first, ebml.IDSimpleBlock, block(packets[0]))
+	first = ebml.AppendElement(first, ebml.IDSimpleBlock, append([]byte{0x82, 0, 0, 0x80}, 0xff))
+	first = ebml.AppendElement(first, ebml.IDSimpleBlock, block(packets[1]))
+
+	// The last packet is trimmed by 10ms worth of discard padding.
+
+	var group []byte
+	group = ebml.AppendElement(group, ebml.IDBlock, block(packets[3]))
+	group = ebml.AppendUintWidth(group, ebml.IDDiscardPadding, 10000000, 4)
+
+	second = ebml.AppendUint(second, ebml.IDTimecode, 40)
+	second = ebml.AppendElement(second, ebml.IDSimpleBlock, block(packets[2]))
+	second = ebml.AppendElement(second, ebml.IDBlockGroup, group)
+
+	var segment []byte
+	segment = ebml.AppendElement(segment, ebml.IDInfo, ebml.AppendUint(nil, ebml.IDTimecodeScale, 1000000))
+	segment = ebml.AppendElement(segment, ebml.IDTracks, ebml.AppendElement(nil, ebml.IDTrackEntry, entry))
+	segment = ebml.AppendElement(segment, ebml.IDCluster, first)
+	segment = ebml.AppendElement(segment, ebml.IDCluster, second)
+
+	input := ebml.AppendElement(nil, ebml.IDHeader, nil)
+	input = ebml.AppendElement(input, ebml.IDSegment, segment)
+
+	var out bytes.Buffer
+	require.NoError(t, Opus(&out, bytes.NewReader(input)))
+
+	r := ogg.NewReader(&out)
+
+	packet, page, err := r.ReadPacket()
+	require.NoError(t, err)
+	require.Equal(t, head, packet)
+	require.EqualValues(t, ogg.FlagBOS, page.Flags)
+
+	packet, _, err = r.ReadPacket()
+	require.NoError(t, err)
+	require.Equal(t, opusTags(nil), packet)
+
+	var last ogg.Page
+
+	for _, expected := range packets {
+		packet, page, err := r.ReadPacket()
+		require.NoError(t, err)
+		require.Equal(t, expected, packet)
+
+		last = page
+	}
+
+	require.EqualValues(t, ogg.FlagEOS, last.Flags)
+	require.EqualValues(t, 4*960-480, last.Granule)
+
+	_, _, err = r.ReadPacket()
+	require.Equal(t, io.EOF, err)
+}
+
+func TestOpusSamples(t *testing.T) {
+	require.EqualValues(t, 960, opusSamples([]byte{31 << 3}))
+	require.EqualValues(t, 2*480, opusSamples([]byte{0x01}))
+	require.EqualValues(t, 2880, opusSamples([]byte{3 << 3}))
+	require.EqualValues(t, 3*120, opusSamples([]byte{16<<3 | 3, 3}))
+	require.EqualValues(t, 0, opusSamples(nil))
+}
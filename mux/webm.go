@@ -0,0 +1,470 @@
+package mux
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/lithdew/youtube"
+	"github.com/lithdew/youtube/ebml"
+	"io"
+	"io/ioutil"
+	"sort"
+)
+
+// DownloadWebM downloads the video-only WebM format video and the audio-only WebM format audio of player p cluster by
+// cluster, and muxes them into a single WebM that is written to w as the download progresses.
+func DownloadWebM(ctx context.Context, p youtube.Player, video, audio youtube.Format, w io.Writer) error {
+	return download(ctx, p, video, audio, w, "/webm", WebM)
+}
+
+// WebM reads a video-only WebM from video and an audio-only WebM from audio, and writes a single WebM containing both
+// tracks to w. Clusters from both inputs are interleaved by their timecode, and only a single cluster per input is
+// ever held in memory. The output carries Cues for both tracks so that it remains seekable.
+//
+// The Cues of both inputs must precede their Clusters, as is the case for WebM formats served by YouTube, such that
+// the layout of the output may be computed before any Cluster is written.
+func WebM(w io.Writer, video, audio io.Reader) error {
+	v, err := openWebM(video)
+	if err != nil {
+		return fmt.Errorf("failed to read video: %w", err)
+	}
+
+	a, err := openWebM(audio)
+	if err != nil {
+		return fmt.Errorf("failed to read audio: %w", err)
+	}
+
+	if v.scale != a.scale {
+		return fmt.Errorf("timecode scales of video (%d) and audio (%d) differ", v.scale, a.scale)
+	}
+
+	// Order the clusters of both inputs by their timecode, preferring video on ties.
+
+	order := make([]webmCluster, 0, len(v.clusters)+len(a.clusters))
+	order = append(order, v.clusters...)
+	order = append(order, a.clusters...)
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return order[i].time < order[j].time
+	})
+
+	info, err := webmInfo(v, a)
+	if err != nil {
+		return err
+	}
+
+	tracks, err := webmTracks(v, a)
+	if err != nil {
+		return err
+	}
+
+	// Lay out the segment. Positions in the SeekHead and Cues are encoded with a fixed width, so the sizes of both
+	// are known upfront.
+
+	seekHead := webmSeekHead(0, 0, 0)
+	cues := webmCues(order, 0)
+
+	first := uint64(len(seekHead) + len(info) + len(tracks) + len(cues))
+
+	size := first
+	for _, c := range order {
+		size += c.size
+	}
+
+	seekHead = webmSeekHead(uint64(len(seekHead)), uint64(len(seekHead)+len(info)), uint64(len(seekHead)+len(info)+len(tracks)))
+	cues = webmCues(order, first)
+
+	header := ebml.AppendElement(nil, ebml.IDHeader, v.header)
+	header = ebml.AppendID(header, ebml.IDSegment)
+	header = ebml.AppendVint(header, size, 8)
+
+	for _, buf := range [][]byte{header, seekHead, info, tracks, cues} {
+		if _, err := w.Write(buf); err != nil {
+			return err
+		}
+	}
+
+	var buf []byte
+
+	for _, c := range order {
+		if buf, err = c.input.readCluster(buf[:0], c); err != nil {
+			return fmt.Errorf("failed to read cluster at offset %d: %w", c.offset, err)
+		}
+
+		if _, err := w.Write(buf); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// webmInput is a WebM with a single track whose Cues precede its Clusters.
+type webmInput struct {
+	r      *countingReader
+	header []byte
+
+	info  []ebml.Element
+	track []ebml.Element
+
+	// Number of the input's track, and the number it is renumbered to in the output.
+	number, id uint64
+
+	scale    uint64
+	clusters []webmCluster
+}
+
+// webmCluster is a cluster, alongside all elements that follow it up until the next cluster referenced by a cue.
+type webmCluster struct {
+	input  *webmInput
+	time   uint64
+	offset uint64
+	size   uint64
+}
+
+// openWebM reads the header of the WebM at r up to and including its Cues.
+func openWebM(r io.Reader) (*webmInput, error) {
+	in := &webmInput{r: &countingReader{r: r}, scale: 1000000}
+
+	id, size, _, err := ebml.ReadHeader(in.r)
+	if err != nil {
+		return nil, err
+	}
+
+	if id != ebml.IDHeader {
+		return nil, errors.New("input does not start with an ebml header")
+	}
+
+	if in.header, err = in.payload(id, size); err != nil {
+		return nil, err
+	}
+
+	if id, size, _, err = ebml.ReadHeader(in.r); err != nil {
+		return nil, err
+	}
+
+	if id != ebml.IDSegment {
+		return nil, fmt.Errorf("expected segment, but got element %#x", id)
+	}
+
+	if size == ebml.UnknownSize {
+		return nil, errors.New("segment size is unknown")
+	}
+
+	origin, end := in.r.n, in.r.n+size
+
+	var cues []ebml.Element
+
+	for cues == nil {
+		id, size, _, err := ebml.ReadHeader(in.r)
+		if err == io.EOF {
+			return nil, errors.New("no cues found")
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch id {
+		case ebml.IDInfo, ebml.IDTracks, ebml.IDCues:
+			buf, err := in.payload(id, size)
+			if err != nil {
+				return nil, err
+			}
+
+			children, err := ebml.ParseAll(buf)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse element %#x: %w", id, err)
+			}
+
+			switch id {
+			case ebml.IDInfo:
+				in.info = children
+			case ebml.IDTracks:
+				for _, e := range children {
+					if e.ID != ebml.IDTrackEntry {
+						continue
+					}
+					if in.track != nil {
+						return nil, errors.New("expected exactly one track, but found more")
+					}
+					if in.track, err = e.Children(); err != nil {
+						return nil, fmt.Errorf("failed to parse track entry: %w", err)
+					}
+				}
+			case ebml.IDCues:
+				cues = children
+			}
+		case ebml.IDCluster:
+			return nil, errors.New("cues do not precede clusters")
+		default:
+			if _, err := io.CopyN(ioutil.Discard, in.r, int64(size)); err != nil {
+				return nil, unexpected(err)
+			}
+		}
+	}
+
+	if in.info == nil || in.track == nil {
+		return nil, errors.New("info or tracks are missing")
+	}
+
+	for _, e := range in.info {
+		if e.ID == ebml.IDTimecodeScale {
+			in.scale = e.Uint()
+		}
+	}
+
+	for _, e := range in.track {
+		if e.ID == ebml.IDTrackNumber {
+			in.number = e.Uint()
+		}
+	}
+
+	// Each cue point references the position of a cluster relative to the start of the segment's data.
+
+	for _, point := range cues {
+		if point.ID != ebml.IDCuePoint {
+			continue
+		}
+
+		fields, err := point.Children()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse cue point: %w", err)
+		}
+
+		var (
+			c  = webmCluster{input: in}
+			ok bool
+		)
+
+		for _, field := range fields {
+			switch field.ID {
+			case ebml.IDCueTime:
+				c.time = field.Uint()
+			case ebml.IDCueTrackPositions:
+				positions, err := field.Children()
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse cue track positions: %w", err)
+				}
+				for _, position := range positions {
+					if position.ID == ebml.IDCueClusterPosition && !ok {
+						c.offset, ok = origin+position.Uint(), true
+					}
+				}
+			}
+		}
+
+		if !ok || c.offset < in.r.n || c.offset >= end {
+			continue
+		}
+
+		if n := len(in.clusters); n > 0 && c.offset <= in.clusters[n-1].offset {
+			continue
+		}
+
+		in.clusters = append(in.clusters, c)
+	}
+
+	if len(in.clusters) == 0 {
+		return nil, errors.New("cues do not reference any clusters")
+	}
+
+	for i := range in.clusters {
+		next := end
+		if i+1 < len(in.clusters) {
+			next = in.clusters[i+1].offset
+		}
+		in.clusters[i].size = next - in.clusters[i].offset
+	}
+
+	return in, nil
+}
+
+func (in *webmInput) payload(id uint32, size uint64) ([]byte, error) {
+	if size > MaxBufferSize {
+		return nil, fmt.Errorf("element %#x of %d byte(s) exceeds limit of %d byte(s)", id, size, MaxBufferSize)
+	}
+
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(in.r, buf); err != nil {
+		return nil, unexpected(err)
+	}
+
+	return buf, nil
+}
+
+// readCluster appends the bytes of cluster c to dst, renumbering the track of all of its blocks.
+func (in *webmInput) readCluster(dst []byte, c webmCluster) ([]byte, error) {
+	if c.offset < in.r.n {
+		return dst, errors.New("cluster overlaps previous cluster")
+	}
+
+	if _, err := io.CopyN(ioutil.Discard, in.r, int64(c.offset-in.r.n)); err != nil {
+		return dst, unexpected(err)
+	}
+
+	if c.size > MaxBufferSize {
+		return dst, fmt.Errorf("cluster of %d byte(s) exceeds limit of %d byte(s)", c.size, MaxBufferSize)
+	}
+
+	n := len(dst)
+
+	dst = append(dst, make([]byte, c.size)...)
+	if _, err := io.ReadFull(in.r, dst[n:]); err != nil {
+		return dst, unexpected(err)
+	}
+
+	elements, err := ebml.ParseAll(dst[n:])
+	if err != nil {
+		return dst, err
+	}
+
+	for _, e := range elements {
+		if e.ID != ebml.IDCluster {
+			continue
+		}
+
+		children, err := e.Children()
+		if err != nil {
+			return dst, fmt.Errorf("failed to parse cluster: %w", err)
+		}
+
+		for _, child := range children {
+			switch child.ID {
+			case ebml.IDSimpleBlock:
+				err = renumberBlock(child.Data, in.number, in.id)
+			case ebml.IDBlockGroup:
+				var group []ebml.Element
+
+				if group, err = child.Children(); err != nil {
+					break
+				}
+
+				for _, e := range group {
+					if e.ID == ebml.IDBlock {
+						if err = renumberBlock(e.Data, in.number, in.id); err != nil {
+							break
+						}
+					}
+				}
+			}
+
+			if err != nil {
+				return dst, err
+			}
+		}
+	}
+
+	return dst, nil
+}
+
+// renumberBlock rewrites in place the track number of a block that belongs to track from to track to.
+func renumberBlock(block []byte, from, to uint64) error {
+	number, width, err := ebml.ParseVint(block)
+	if err != nil {
+		return fmt.Errorf("block track number is malformed: %w", err)
+	}
+
+	if number != from {
+		return fmt.Errorf("block belongs to unknown track %d", number)
+	}
+
+	ebml.AppendVint(block[:0], to, width)
+
+	return nil
+}
+
+// webmInfo returns the Info element of the output, which is that of the video with its duration extended to cover
+// the audio.
+func webmInfo(v, a *webmInput) ([]byte, error) {
+	var duration float64
+
+	for _, in := range []*webmInput{v, a} {
+		for _, e := range in.info {
+			if e.ID == ebml.IDDuration && e.Float() > duration {
+				duration = e.Float()
+			}
+		}
+	}
+
+	var info []byte
+
+	for _, e := range v.info {
+		if e.ID != ebml.IDDuration {
+			info = e.AppendTo(info)
+		}
+	}
+
+	if duration > 0 {
+		info = ebml.AppendFloat(info, ebml.IDDuration, duration)
+	}
+
+	return ebml.AppendElement(nil, ebml.IDInfo, info), nil
+}
+
+// webmTracks returns the Tracks element of the output, renumbering the video's track to 1 and the audio's track to 2.
+func webmTracks(v, a *webmInput) ([]byte, error) {
+	v.id, a.id = videoTrackID, audioTrackID
+
+	var tracks []byte
+
+	for _, in := range []*webmInput{v, a} {
+		if in.number == 0 {
+			return nil, errors.New("track number is missing")
+		}
+
+		var entry []byte
+
+		for _, e := range in.track {
+			if e.ID == ebml.IDTrackNumber {
+				entry = ebml.AppendUint(entry, ebml.IDTrackNumber, in.id)
+			} else {
+				entry = e.AppendTo(entry)
+			}
+		}
+
+		tracks = ebml.AppendElement(tracks, ebml.IDTrackEntry, entry)
+	}
+
+	return ebml.AppendElement(nil, ebml.IDTracks, tracks), nil
+}
+
+// webmSeekHead returns a SeekHead element pointing to the Info, Tracks, and Cues elements at the given positions
+// relative to the start of the segment's data.
+func webmSeekHead(info, tracks, cues uint64) []byte {
+	var seeks []byte
+
+	for _, seek := range []struct {
+		id       uint32
+		position uint64
+	}{{ebml.IDInfo, info}, {ebml.IDTracks, tracks}, {ebml.IDCues, cues}} {
+		var buf []byte
+		buf = ebml.AppendElement(buf, ebml.IDSeekID, ebml.AppendID(nil, seek.id))
+		buf = ebml.AppendUintWidth(buf, ebml.IDSeekPosition, seek.position, 8)
+
+		seeks = ebml.AppendElement(seeks, ebml.IDSeek, buf)
+	}
+
+	return ebml.AppendElement(nil, ebml.IDSeekHead, seeks)
+}
+
+// webmCues returns a Cues element with a cue point per cluster, given the position of the first cluster relative to
+// the start of the segment's data.
+func webmCues(clusters []webmCluster, position uint64) []byte {
+	var points []byte
+
+	for _, c := range clusters {
+		var fields []byte
+		fields = ebml.AppendUint(fields, ebml.IDCueTrack, c.input.id)
+		fields = ebml.AppendUintWidth(fields, ebml.IDCueClusterPosition, position, 8)
+
+		var point []byte
+		point = ebml.AppendUint(point, ebml.IDCueTime, c.time)
+		point = ebml.AppendElement(point, ebml.IDCueTrackPositions, fields)
+
+		points = ebml.AppendElement(points, ebml.IDCuePoint, point)
+
+		position += c.size
+	}
+
+	return ebml.AppendElement(nil, ebml.IDCues, points)
+}
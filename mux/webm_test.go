@@ -0,0 +1,183 @@
+package mux
+
+import (
+	"bytes"
+	"github.com/lithdew/youtube/ebml"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type testCluster struct {
+	time uint64
+	data string
+}
+
+// testWebM encodes a WebM with a single track numbered number, whose Cues precede its Clusters.
+func testWebM(number uint64, duration float64, clusters ...testCluster) []byte {
+	var info []byte
+	info = ebml.AppendUint(info, ebml.IDTimecodeScale, 1000000)
+	info = ebml.AppendFloat(info, ebml.IDDuration, duration)
+
+	var entry []byte
+	entry = ebml.AppendUint(entry, ebml.IDTrackNumber, number)
+	entry = ebml.AppendElement(entry, ebml.IDCodecID, []byte("V_VP9"))
+
+	header := ebml.AppendElement(nil, ebml.IDVoid, make([]byte, 16))
+	header = ebml.AppendElement(header, ebml.IDInfo, info)
+	header = ebml.AppendElement(header, ebml.IDTracks, ebml.AppendElement(nil, ebml.IDTrackEntry, entry))
+
+	var body [][]byte
+	for _, c := range clusters {
+		block := append(ebml.AppendVint(nil, number, 1), 0, 0, 0x80)
+		block = append(block, c.data...)
+
+		var cluster []byte
+		cluster = ebml.AppendUint(cluster, ebml.IDTimecode, c.time)
+		cluster = ebml.AppendElement(cluster, ebml.IDSimpleBlock, block)
+
+		body = append(body, ebml.AppendElement(nil, ebml.IDCluster, cluster))
+	}
+
+	cues := func(position uint64) []byte {
+		var points []byte
+		for i, c := range clusters {
+			var fields []byte
+			fields = ebml.AppendUint(fields, ebml.IDCueTrack, number)
+			fields = ebml.AppendUintWidth(fields, ebml.IDCueClusterPosition, position, 8)
+
+			var point []byte
+			point = ebml.AppendUint(point, ebml.IDCueTime, c.time)
+			point = ebml.AppendElement(point, ebml.IDCueTrackPositions, fields)
+
+			points = ebml.AppendElement(points, ebml.IDCuePoint, point)
+			position += uint64(len(body[i]))
+		}
+		return ebml.AppendElement(nil, ebml.IDCues, points)
+	}
+
+	segment := append(header, cues(uint64(len(header)+len(cues(0))))...)
+	for _, cluster := range body {
+		segment = append(segment, cluster...)
+	}
+
+	buf := ebml.AppendElement(nil, ebml.IDHeader, ebml.AppendElement(nil, 0x4282, []byte("webm")))
+	buf = ebml.AppendID(buf, ebml.IDSegment)
+	buf = ebml.AppendVint(buf, uint64(len(segment)), 8)
+
+	return append(buf, segment...)
+}
+
+func TestWebM(t *testing.T) {
+	video := testWebM(1, 6000, testCluster{0, "v0"}, testCluster{2000, "v1"}, testCluster{4000, "v2"})
+	audio := testWebM(1, 6500, testCluster{0, "a0"}, testCluster{3000, "a1"}, testCluster{4500, "a2"})
+
+	var out bytes.Buffer
+	require.NoError(t, WebM(&out, bytes.NewReader(video), bytes.NewReader(audio)))
+
+	elements, err := ebml.ParseAll(out.Bytes())
+	require.NoError(t, err)
+	require.Len(t, elements, 2)
+	require.EqualValues(t, ebml.IDHeader, elements[0].ID)
+	require.EqualValues(t, ebml.IDSegment, elements[1].ID)
+
+	segment := elements[1].Data
+
+	children, err := elements[1].Children()
+	require.NoError(t, err)
+
+	// Record the position of every top-level element within the segment.
+
+	positions := make(map[uint64]ebml.Element)
+
+	var (
+		position uint64
+		clusters []ebml.Element
+	)
+
+	for _, e := range children {
+		positions[position] = e
+		position += e.Size()
+
+		if e.ID == ebml.IDCluster {
+			clusters = append(clusters, e)
+		}
+	}
+	require.EqualValues(t, len(segment), position)
+
+	require.EqualValues(t, ebml.IDSeekHead, children[0].ID)
+
+	seeks, err := children[0].Children()
+	require.NoError(t, err)
+	require.Len(t, seeks, 3)
+
+	for i, id := range []uint32{ebml.IDInfo, ebml.IDTracks, ebml.IDCues} {
+		fields, err := seeks[i].Children()
+		require.NoError(t, err)
+		require.Equal(t, ebml.AppendID(nil, id), fields[0].Data)
+		require.EqualValues(t, id, positions[fields[1].Uint()].ID)
+	}
+
+	info, err := children[1].Children()
+	require.NoError(t, err)
+	require.EqualValues(t, 6500, info[len(info)-1].Float())
+
+	tracks, err := children[2].Children()
+	require.NoError(t, err)
+	require.Len(t, tracks, 2)
+
+	for i, track := range tracks {
+		fields, err := track.Children()
+		require.NoError(t, err)
+		require.EqualValues(t, i+1, fields[0].Uint())
+	}
+
+	// Clusters are interleaved by timecode, have their blocks renumbered, and are referenced by the output's cues.
+
+	expected := []struct {
+		data  string
+		track uint64
+		time  uint64
+	}{{"v0", 1, 0}, {"a0", 2, 0}, {"v1", 1, 2000}, {"a1", 2, 3000}, {"v2", 1, 4000}, {"a2", 2, 4500}}
+
+	require.Len(t, clusters, len(expected))
+
+	points, err := children[3].Children()
+	require.NoError(t, err)
+	require.Len(t, points, len(expected))
+
+	for i, e := range expected {
+		fields, err := clusters[i].Children()
+		require.NoError(t, err)
+		require.Equal(t, e.time, fields[0].Uint())
+
+		block := fields[1].Data
+		require.EqualValues(t, 0x80|e.track, block[0])
+		require.Equal(t, e.data, string(block[4:]))
+
+		cue, err := points[i].Children()
+		require.NoError(t, err)
+		require.Equal(t, e.time, cue[0].Uint())
+
+		position, err := cue[1].Children()
+		require.NoError(t, err)
+		require.Equal(t, e.track, position[0].Uint())
+		require.Equal(t, clusters[i].Data, positions[position[1].Uint()].Data)
+	}
+}
+
+func TestWebMCuesAfterClusters(t *testing.T) {
+	var entry []byte
+	entry = ebml.AppendUint(entry, ebml.IDTrackNumber, 1)
+
+	var segment []byte
+	segment = ebml.AppendElement(segment, ebml.IDInfo, ebml.AppendUint(nil, ebml.IDTimecodeScale, 1000000))
+	segment = ebml.AppendElement(segment, ebml.IDTracks, ebml.AppendElement(nil, ebml.IDTrackEntry, entry))
+	segment = ebml.AppendElement(segment, ebml.IDCluster, ebml.AppendUint(nil, ebml.IDTimecode, 0))
+
+	buf := ebml.AppendElement(nil, ebml.IDHeader, nil)
+	buf = ebml.AppendElement(buf, ebml.IDSegment, segment)
+
+	video := testWebM(1, 2000, testCluster{0, "v0"})
+
+	require.Error(t, WebM(new(bytes.Buffer), bytes.NewReader(video), bytes.NewReader(buf)))
+}
@@ -0,0 +1,297 @@
+// Package ogg reads and writes Ogg bitstreams (RFC 3533).
+package ogg
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// Flags of a page header.
+const (
+	FlagContinued = 0x01
+	FlagBOS       = 0x02
+	FlagEOS       = 0x04
+)
+
+// MaxPageSize is the size of the body of a page past which no further packets are added to the page.
+const MaxPageSize = 4096
+
+// NoGranule is the granule position of a page on which no packet ends.
+const NoGranule = ^uint64(0)
+
+var crcTable = func() (table [256]uint32) {
+	for i := range table {
+		r := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if r&0x80000000 != 0 {
+				r = r<<1 ^ 0x04c11db7
+			} else {
+				r <<= 1
+			}
+		}
+		table[i] = r
+	}
+	return table
+}()
+
+// Checksum computes the CRC-32 checksum used by Ogg pages over buf.
+func Checksum(buf []byte) uint32 {
+	var crc uint32
+	for _, b := range buf {
+		crc = crc<<8 ^ crcTable[byte(crc>>24)^b]
+	}
+	return crc
+}
+
+// Writer packs packets of a single logical bitstream into pages, and writes them out.
+type Writer struct {
+	w        io.Writer
+	serial   uint32
+	sequence uint32
+
+	flags   byte
+	granule uint64
+	lacing  []byte
+	body    []byte
+	page    []byte
+}
+
+// NewWriter instantiates a new writer of the logical bitstream with the given serial number to w.
+func NewWriter(w io.Writer, serial uint32) *Writer {
+	return &Writer{w: w, serial: serial, flags: FlagBOS, granule: NoGranule}
+}
+
+// WritePacket adds a packet to the current page, flushing the current page beforehand should the packet not fit. The
+// packet ends at the given granule position. Packets that do not fit into a single page span multiple pages.
+func (w *Writer) WritePacket(packet []byte, granule uint64) error {
+	if len(w.body) > 0 && (len(w.body)+len(packet) > MaxPageSize || len(w.lacing)+len(packet)/255+1 > 255) {
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	for {
+		n := len(packet)
+		if max := 255 * (255 - len(w.lacing)); n >= max {
+			n = max
+		}
+
+		w.body = append(w.body, packet[:n]...)
+		for i := 0; i < n/255; i++ {
+			w.lacing = append(w.lacing, 255)
+		}
+
+		packet = packet[n:]
+
+		// The packet ends once a lacing value below 255 is written. If the page is full beforehand, the packet
+		// continues onto the next page.
+
+		if len(w.lacing) < 255 {
+			w.lacing = append(w.lacing, byte(n%255))
+			w.granule = granule
+			return nil
+		}
+
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		w.flags |= FlagContinued
+	}
+}
+
+// Flush writes out the current page if it holds any data.
+func (w *Writer) Flush() error {
+	if len(w.lacing) == 0 {
+		return nil
+	}
+	return w.flush()
+}
+
+// WritePage writes out an encoded page after flushing the current page. The page is renumbered to follow the pages
+// written before it and to belong to the writer's logical bitstream, and its checksum is recomputed.
+func (w *Writer) WritePage(page []byte) error {
+	if len(page) < 27 || string(page[:4]) != "OggS" {
+		return errors.New("page does not start with a capture pattern")
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	page = append(w.page[:0], page...)
+	binary.LittleEndian.PutUint32(page[14:18], w.serial)
+	binary.LittleEndian.PutUint32(page[18:22], w.sequence)
+	binary.LittleEndian.PutUint32(page[22:26], 0)
+	binary.LittleEndian.PutUint32(page[22:26], Checksum(page))
+
+	w.page = page
+	w.sequence++
+	w.flags = 0
+
+	_, err := w.w.Write(page)
+	return err
+}
+
+// Close writes out the current page marking it as the last page of the logical bitstream.
+func (w *Writer) Close() error {
+	w.flags |= FlagEOS
+	return w.flush()
+}
+
+func (w *Writer) flush() error {
+	page := append(w.page[:0], "OggS"...)
+	page = append(page, 0, w.flags)
+	page = append(page, make([]byte, 20)...)
+	binary.LittleEndian.PutUint64(page[6:14], w.granule)
+	binary.LittleEndian.PutUint32(page[14:18], w.serial)
+	binary.LittleEndian.PutUint32(page[18:22], w.sequence)
+	page = append(page, byte(len(w.lacing)))
+	page = append(page, w.lacing...)
+	page = append(page, w.body...)
+
+	binary.LittleEndian.PutUint32(page[22:26], Checksum(page))
+
+	w.page = page
+	w.sequence++
+	w.flags = 0
+	w.granule = NoGranule
+	w.lacing = w.lacing[:0]
+	w.body = w.body[:0]
+
+	_, err := w.w.Write(page)
+	return err
+}
+
+// Page is the header of a page.
+type Page struct {
+	Flags    byte
+	Granule  uint64
+	Serial   uint32
+	Sequence uint32
+}
+
+// Reader reads packets out of the pages of a logical bitstream.
+type Reader struct {
+	r      io.Reader
+	page   Page
+	lacing []byte
+	body   []byte
+	buf    []byte
+	raw    []byte
+}
+
+// NewReader instantiates a new reader of the pages read from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: r}
+}
+
+// ReadPacket reads the next packet, returning it alongside the header of the page that the packet ends on. The
+// packet is only valid until the next call to ReadPacket.
+func (r *Reader) ReadPacket() ([]byte, Page, error) {
+	packet := r.buf[:0]
+	started := false
+
+	for {
+		if len(r.lacing) == 0 {
+			if err := r.readPage(); err != nil {
+				if err == io.EOF && started {
+					err = io.ErrUnexpectedEOF
+				}
+				return nil, Page{}, err
+			}
+
+			if started != (r.page.Flags&FlagContinued != 0) {
+				return nil, Page{}, errors.New("page continuation flag does not match")
+			}
+
+			continue
+		}
+
+		started = true
+
+		for len(r.lacing) > 0 {
+			n := int(r.lacing[0])
+			r.lacing = r.lacing[1:]
+
+			packet = append(packet, r.body[:n]...)
+			r.body = r.body[n:]
+
+			if n < 255 {
+				r.buf = packet
+				return packet, r.page, nil
+			}
+		}
+	}
+}
+
+// ReadPage reads the next page in its entirety, returning its encoded bytes. Any packets left unread on the current
+// page are discarded. The page is only valid until the next call to ReadPage or ReadPacket.
+func (r *Reader) ReadPage() ([]byte, error) {
+	if err := r.readPage(); err != nil {
+		return nil, err
+	}
+
+	r.lacing, r.body = nil, nil
+
+	return r.raw, nil
+}
+
+// Buffered reports whether there are packets left unread on the current page.
+func (r *Reader) Buffered() bool {
+	return len(r.lacing) > 0
+}
+
+func (r *Reader) readPage() error {
+	var header [27]byte
+
+	if _, err := io.ReadFull(r.r, header[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return errors.New("page header is truncated")
+		}
+		return err
+	}
+
+	if string(header[:4]) != "OggS" || header[4] != 0 {
+		return errors.New("page does not start with a capture pattern")
+	}
+
+	page := append([]byte{}, header[:]...)
+	page = append(page, make([]byte, header[26])...)
+
+	if _, err := io.ReadFull(r.r, page[27:]); err != nil {
+		return errors.New("page lacing values are truncated")
+	}
+
+	size := 0
+	for _, v := range page[27:] {
+		size += int(v)
+	}
+
+	page = append(page, make([]byte, size)...)
+	if _, err := io.ReadFull(r.r, page[len(page)-size:]); err != nil {
+		return errors.New("page body is truncated")
+	}
+
+	checksum := binary.LittleEndian.Uint32(page[22:26])
+	binary.LittleEndian.PutUint32(page[22:26], 0)
+
+	if Checksum(page) != checksum {
+		return errors.New("page checksum mismatch")
+	}
+
+	r.page = Page{
+		Flags:    header[5],
+		Granule:  binary.LittleEndian.Uint64(header[6:14]),
+		Serial:   binary.LittleEndian.Uint32(header[14:18]),
+		Sequence: binary.LittleEndian.Uint32(header[18:22]),
+	}
+	binary.LittleEndian.PutUint32(page[22:26], checksum)
+
+	r.raw = page
+	r.lacing = page[27 : 27+int(header[26])]
+	r.body = page[27+int(header[26]):]
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package ogg
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/require"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+func TestReadWrite(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+
+	var packets [][]byte
+	for _, size := range []int{10, 255, 300, 70000, 0, 510, 5000, 5000, 5000} {
+		packet := make([]byte, size)
+		rng.Read(packet)
+		packets = append(packets, packet)
+	}
+
+	var buf bytes.Buffer
+
+	w := NewWriter(&buf, 42)
+	for i, packet := range packets {
+		require.NoError(t, w.WritePacket(packet, uint64(i+1)*960))
+	}
+	require.NoError(t, w.Close())
+
+	r := NewReader(&buf)
+
+	var last Page
+
+	for i, expected := range packets {
+		packet, page, err := r.ReadPacket()
+		require.NoError(t, err)
+		require.Equal(t, expected, packet)
+		require.EqualValues(t, 42, page.Serial)
+
+		// The granule position of a page is that of the last packet that ends on it.
+
+		require.True(t, page.Granule >= uint64(i+1)*960)
+
+		if i == 0 {
+			require.EqualValues(t, FlagBOS, page.Flags&FlagBOS)
+		}
+
+		last = page
+	}
+
+	require.EqualValues(t, FlagEOS, last.Flags&FlagEOS)
+	require.EqualValues(t, len(packets)*960, last.Granule)
+
+	_, _, err := r.ReadPacket()
+	require.Equal(t, io.EOF, err)
+}
+
+func TestReadCorrupted(t *testing.T) {
+	var buf bytes.Buffer
+
+	w := NewWriter(&buf, 1)
+	require.NoError(t, w.WritePacket([]byte("hello"), 0))
+	require.NoError(t, w.Close())
+
+	data := buf.Bytes()
+	data[len(data)-1] ^= 0xff
+
+	_, _, err := NewReader(bytes.NewReader(data)).ReadPacket()
+	require.Error(t, err)
+}
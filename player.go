@@ -1,6 +1,7 @@
 package youtube
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"regexp"
@@ -16,6 +17,73 @@ type Player struct {
 	Transport
 	Assets
 	Streams
+
+	// LoadedAt is the time at which the player's streaming info was loaded.
+	LoadedAt time.Time
+
+	// loader is a snapshot of the client the player was loaded with, which is used to reload the player once the
+	// URLs it resolves expire. Nil if the player was not loaded through a client.
+	loader *Client
+}
+
+// reloader returns the client the player is to be reloaded with through transport t. It carries the headers and
+// cookies of the client the player was loaded with.
+func (p Player) reloader(t Transport) Client {
+	if p.loader == nil {
+		return WrapClient(t)
+	}
+
+	c := *p.loader
+	c.Transport = t
+
+	return c
+}
+
+// playerJSON is the serialized form of a Player. The transport of a player is never serialized.
+type playerJSON struct {
+	Assets   Assets          `json:"assets"`
+	Response json.RawMessage `json:"player_response"`
+	LoadedAt time.Time       `json:"loaded_at"`
+}
+
+// MarshalJSON encodes the player's assets, raw player response, and load timestamp as JSON such that the player may
+// be cached and later reconstructed using UnmarshalJSON.
+func (p Player) MarshalJSON() ([]byte, error) {
+	response, err := p.Streams.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(playerJSON{Assets: p.Assets, Response: response, LoadedAt: p.LoadedAt})
+}
+
+// UnmarshalJSON decodes a player previously encoded with MarshalJSON. The player's transport is left untouched, and
+// must be set before the player is used to resolve any URLs.
+func (p *Player) UnmarshalJSON(buf []byte) error {
+	var v playerJSON
+
+	if err := json.Unmarshal(buf, &v); err != nil {
+		return err
+	}
+
+	streams, err := NewStreamsFromJSON(v.Response)
+	if err != nil {
+		return err
+	}
+
+	p.Assets = v.Assets
+	p.Streams = streams
+	p.LoadedAt = v.LoadedAt
+
+	return nil
+}
+
+// ExpiresAt returns the time at which the URLs to the player's formats expire. It returns the zero time if unknown.
+func (p Player) ExpiresAt() time.Time {
+	if p.LoadedAt.IsZero() || p.ExpiresIn() == 0 {
+		return time.Time{}
+	}
+	return p.LoadedAt.Add(p.ExpiresIn())
 }
 
 func (p Player) ResolveURL(v Format) (string, error) {
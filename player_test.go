@@ -0,0 +1,40 @@
+package youtube
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func loadTestStreams(t testing.TB) Streams {
+	buf, err := ioutil.ReadFile("testdata/player_response.json")
+	require.NoError(t, err)
+
+	streams, err := NewStreamsFromJSON(buf)
+	require.NoError(t, err)
+
+	return streams
+}
+
+func TestPlayerJSON(t *testing.T) {
+	player := Player{
+		Assets:   Assets{CSS: "/yts/cssbin/player.css", JS: "/yts/jsbin/player/base.js"},
+		Streams:  loadTestStreams(t),
+		LoadedAt: time.Date(2020, 5, 12, 0, 0, 0, 0, time.UTC),
+	}
+
+	buf, err := json.Marshal(player)
+	require.NoError(t, err)
+
+	var decoded Player
+	require.NoError(t, json.Unmarshal(buf, &decoded))
+
+	require.Equal(t, player.Assets, decoded.Assets)
+	require.True(t, player.LoadedAt.Equal(decoded.LoadedAt))
+	require.EqualValues(t, "pAsDzfbLM8Y", decoded.ID())
+	require.Equal(t, player.Title(), decoded.Title())
+	require.Equal(t, player.SourceFormats(), decoded.SourceFormats())
+	require.Equal(t, player.MuxedFormats(), decoded.MuxedFormats())
+}
@@ -0,0 +1,177 @@
+package youtube
+
+import (
+	"errors"
+	"fmt"
+	"github.com/valyala/fasthttp"
+	"golang.org/x/sync/errgroup"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Doer is implemented by transports that send requests through fasthttp, such as *nicehttp.Client. A Doer may be
+// adapted into a RoundTripper using AdaptTransport.
+type Doer interface {
+	DoDeadline(req *fasthttp.Request, res *fasthttp.Response, deadline time.Time) error
+}
+
+// FormatProbe describes a format as reported by the server that hosts it.
+type FormatProbe struct {
+	// Itag of the format probed.
+	ITag uint
+
+	// Size of the format in bytes. Zero if the server did not report it.
+	ContentLength uint64
+
+	// Whether the server accepts requests for byte ranges of the format.
+	AcceptRanges bool
+
+	// Value of the 'Content-Type' header.
+	ContentType string
+
+	// Status code of the response the probe was derived from.
+	StatusCode int
+}
+
+// ProbeFormat learns the size, content type, and whether byte ranges may be requested of format f from the server
+// that hosts it. It sends a HEAD request first, and should the HEAD request fail or not report the format's size,
+// requests the first byte of the format instead. The player's transport must implement RoundTripper or Doer.
+func (p Player) ProbeFormat(f Format) (FormatProbe, error) {
+	return p.ProbeFormatDeadline(f, zeroTime)
+}
+
+func (p Player) ProbeFormatTimeout(f Format, timeout time.Duration) (FormatProbe, error) {
+	return p.ProbeFormatDeadline(f, time.Now().Add(timeout))
+}
+
+func (p Player) ProbeFormatDeadline(f Format, deadline time.Time) (FormatProbe, error) {
+	switch p.Transport.(type) {
+	case RoundTripper, Doer:
+	default:
+		return FormatProbe{}, errors.New("transport is unable to send arbitrary http requests")
+	}
+
+	url, err := p.ResolveURLDeadline(f, deadline)
+	if err != nil {
+		return FormatProbe{}, fmt.Errorf("failed to resolve url of format with itag %d: %w", f.ITag, err)
+	}
+
+	probe, err := probeDeadline(AdaptTransport(p.Transport), url, deadline)
+	if err != nil {
+		return probe, fmt.Errorf("failed to probe format with itag %d: %w", f.ITag, err)
+	}
+
+	probe.ITag = f.ITag
+
+	return probe, nil
+}
+
+// ProbeFormats probes formats concurrently, with at most parallelism formats being probed at once. A parallelism of
+// zero or less probes all formats at once. The probes returned line up with formats. Should any format fail to be
+// probed, the first error encountered is returned, and the probes of formats that failed are left zero.
+func (p Player) ProbeFormats(formats Formats, parallelism int) ([]FormatProbe, error) {
+	return p.ProbeFormatsDeadline(formats, parallelism, zeroTime)
+}
+
+func (p Player) ProbeFormatsTimeout(formats Formats, parallelism int, timeout time.Duration) ([]FormatProbe, error) {
+	return p.ProbeFormatsDeadline(formats, parallelism, time.Now().Add(timeout))
+}
+
+func (p Player) ProbeFormatsDeadline(formats Formats, parallelism int, deadline time.Time) ([]FormatProbe, error) {
+	if parallelism <= 0 || parallelism > len(formats) {
+		parallelism = len(formats)
+	}
+
+	probes := make([]FormatProbe, len(formats))
+	sem := make(chan struct{}, parallelism)
+
+	var g errgroup.Group
+
+	for i := range formats {
+		i := i
+
+		sem <- struct{}{}
+
+		g.Go(func() error {
+			defer func() { <-sem }()
+
+			probe, err := p.ProbeFormatDeadline(formats[i], deadline)
+			if err != nil {
+				return err
+			}
+
+			probes[i] = probe
+
+			return nil
+		})
+	}
+
+	return probes, g.Wait()
+}
+
+// probeDeadline probes url with a HEAD request, and falls back to requesting its first byte should the HEAD request
+// not report its size.
+func probeDeadline(rt RoundTripper, url string, deadline time.Time) (FormatProbe, error) {
+	var probe FormatProbe
+
+	res := Response{}
+
+	err := rt.RoundTripDeadline(&Request{Method: http.MethodHead, URL: url}, &res, deadline)
+	if err == nil && isSuccessStatus(res.StatusCode) {
+		if n, _ := strconv.ParseUint(res.Header.Get("Content-Length"), 10, 64); n > 0 {
+			probe.StatusCode = res.StatusCode
+			probe.ContentType = res.Header.Get("Content-Type")
+			probe.ContentLength = n
+			probe.AcceptRanges = res.Header.Get("Accept-Ranges") == "bytes"
+
+			return probe, nil
+		}
+	}
+
+	// The server either does not support HEAD requests, or did not report the size. The total size is reported in
+	// the 'Content-Range' header of a response to a request for a single byte instead.
+
+	res = Response{}
+
+	if err := rt.RoundTripDeadline(&Request{URL: url, Header: http.Header{"Range": {"bytes=0-0"}}}, &res, deadline); err != nil {
+		return probe, err
+	}
+
+	probe.StatusCode = res.StatusCode
+	if err := checkStatus(url, &res); err != nil {
+		return probe, err
+	}
+
+	probe.ContentType = res.Header.Get("Content-Type")
+
+	switch probe.StatusCode {
+	case http.StatusPartialContent:
+		probe.AcceptRanges = true
+		probe.ContentLength = parseContentRangeSize(res.Header.Get("Content-Range"))
+	default:
+		// The server ignored the requested range, and responded with the whole format instead.
+
+		probe.ContentLength, _ = strconv.ParseUint(res.Header.Get("Content-Length"), 10, 64)
+		probe.AcceptRanges = res.Header.Get("Accept-Ranges") == "bytes"
+	}
+
+	return probe, nil
+}
+
+// parseContentRangeSize returns the total size in a 'Content-Range' header value such as 'bytes 0-0/1234'. It returns
+// zero if the total size is unknown.
+func parseContentRangeSize(s string) uint64 {
+	i := strings.LastIndexByte(s, '/')
+	if i < 0 {
+		return 0
+	}
+	n, _ := strconv.ParseUint(s[i+1:], 10, 64)
+	return n
+}
+
+// isSuccessStatus reports whether status is a 2xx status code.
+func isSuccessStatus(status int) bool {
+	return status >= 200 && status < 300
+}
@@ -0,0 +1,64 @@
+package youtube
+
+import (
+	"bytes"
+	"errors"
+	"github.com/lithdew/nicehttp"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestProbeFormats(t *testing.T) {
+	data := bytes.Repeat([]byte("youtube"), 1000)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			w.WriteHeader(http.StatusForbidden)
+			return
+		case "/nohead":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", "video/mp4")
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+	}))
+	defer srv.Close()
+
+	client := nicehttp.NewClient()
+	player := Player{Transport: &client}
+
+	format := func(itag uint, path string) Format {
+		url := srv.URL + path
+		return Format{ITag: itag, URL: &url}
+	}
+
+	probes, err := player.ProbeFormats(Formats{format(18, "/head"), format(22, "/nohead")}, 1)
+	require.NoError(t, err)
+	require.Equal(t, []FormatProbe{
+		{ITag: 18, ContentLength: uint64(len(data)), AcceptRanges: true, ContentType: "video/mp4", StatusCode: 200},
+		{ITag: 22, ContentLength: uint64(len(data)), AcceptRanges: true, ContentType: "video/mp4", StatusCode: 206},
+	}, probes)
+
+	_, err = player.ProbeFormat(format(137, "/missing"))
+	require.Error(t, err)
+
+	var status *StatusError
+	require.True(t, errors.As(err, &status))
+	require.Equal(t, http.StatusForbidden, status.StatusCode)
+
+	// Formats may be probed through net/http, but not through transports that only download bytes.
+
+	probe, err := Player{Transport: HTTPTransport{}}.ProbeFormat(format(22, "/nohead"))
+	require.NoError(t, err)
+	require.Equal(t, probes[1], probe)
+
+	_, err = Player{Transport: &scriptedTransport{}}.ProbeFormat(format(18, "/head"))
+	require.Error(t, err)
+}
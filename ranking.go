@@ -0,0 +1,208 @@
+package youtube
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// CodecEfficiency scores how efficiently a codec compresses media relative to other codecs. Codecs are keyed by
+// Codec.Name, e.g. 'avc1' or 'opus'. Unknown codecs score zero.
+var CodecEfficiency = map[string]float64{
+	"mp4v":   0,
+	"avc1":   1,
+	"avc3":   1,
+	"vp8":    1,
+	"vp9":    2,
+	"hev1":   2,
+	"hvc1":   2,
+	"av01":   3,
+	"mp4a":   1,
+	"vorbis": 1,
+	"opus":   2,
+}
+
+// Ranking scores formats by a weighted sum of their qualities. Resolution, frame rate, and bitrate are measured on a
+// logarithmic scale, so that doubling any of them raises a format's score by its weight. Formats that lack a
+// quality, such as the frame rate of an audio-only format, score zero for it.
+//
+// Formats are ranked by score in descending order. Formats with equal scores are ranked by itag in ascending order,
+// and then by bitrate in descending order. Formats that are equal in all three are ranked equally.
+type Ranking struct {
+	// Weight of the number of pixels in a frame.
+	Resolution float64
+
+	// Weight of the number of frames per second.
+	FPS float64
+
+	// Weight given to formats with a high dynamic range.
+	HDR float64
+
+	// Weight of the sum of the efficiencies of a format's codecs as listed in CodecEfficiency.
+	Codec float64
+
+	// Weight of the number of bits per second.
+	Bitrate float64
+
+	// Weight of a format's audio quality as listed in AudioQuality.
+	AudioQuality float64
+
+	// Weight of the audio sample rate.
+	AudioSampleRate float64
+}
+
+// VideoRanking ranks formats by resolution first, frame rate second, and dynamic range third. Codec efficiency and
+// bitrate only decide between formats that are otherwise alike.
+var VideoRanking = Ranking{
+	Resolution: 100,
+	FPS:        10,
+	HDR:        5,
+	Codec:      1,
+	Bitrate:    1,
+}
+
+// AudioRanking ranks formats by audio quality first. Codec efficiency, bitrate, and sample rate only decide between
+// formats that are otherwise alike.
+var AudioRanking = Ranking{
+	AudioQuality:    100,
+	Codec:           1,
+	Bitrate:         1,
+	AudioSampleRate: 1,
+}
+
+// Score returns the score of format f.
+func (r Ranking) Score(f Format) float64 {
+	var score float64
+
+	if pixels := float64(f.Width) * float64(f.Height); pixels > 0 {
+		score += r.Resolution * math.Log2(pixels)
+	}
+
+	if fps := formatFPS(f); fps > 0 {
+		score += r.FPS * math.Log2(float64(fps))
+	}
+
+	if formatHDR(f) {
+		score += r.HDR
+	}
+
+	for _, c := range f.parsedMedia().Codecs {
+		score += r.Codec * CodecEfficiency[c.Name]
+	}
+
+	if f.Bitrate > 0 {
+		score += r.Bitrate * math.Log2(float64(f.Bitrate))
+	}
+
+	if f.AudioQuality != nil {
+		if quality, ok := AudioQuality[*f.AudioQuality]; ok {
+			score += r.AudioQuality * float64(quality+1)
+		}
+	}
+
+	if rate := f.SampleRate(); rate > 0 {
+		score += r.AudioSampleRate * math.Log2(float64(rate))
+	}
+
+	// Keep the ranking a total order should weights be infinite or NaN.
+
+	if math.IsNaN(score) {
+		return math.Inf(-1)
+	}
+
+	return score
+}
+
+// Less reports whether format a ranks strictly higher than format b.
+func (r Ranking) Less(a, b Format) bool {
+	if x, y := r.Score(a), r.Score(b); x != y {
+		return x > y
+	}
+	if a.ITag != b.ITag {
+		return a.ITag < b.ITag
+	}
+	return a.Bitrate > b.Bitrate
+}
+
+// Sort sorts formats in place from the highest to the lowest ranked, and returns them. Formats that are ranked
+// equally keep their original order.
+func (r Ranking) Sort(formats Formats) Formats {
+	scores := make([]float64, len(formats))
+	for i := range formats {
+		scores[i] = r.Score(formats[i])
+	}
+
+	sort.Stable(rankedFormats{formats: formats, scores: scores})
+
+	return formats
+}
+
+// Best returns the highest ranked format out of formats without modifying them. It reports false if formats is empty.
+func (r Ranking) Best(formats Formats) (Format, bool) {
+	if len(formats) == 0 {
+		return Format{}, false
+	}
+
+	best := formats[0]
+	for _, f := range formats[1:] {
+		if r.Less(f, best) {
+			best = f
+		}
+	}
+
+	return best, true
+}
+
+// Worst returns the lowest ranked format out of formats without modifying them. It reports false if formats is
+// empty.
+func (r Ranking) Worst(formats Formats) (Format, bool) {
+	if len(formats) == 0 {
+		return Format{}, false
+	}
+
+	worst := formats[0]
+	for _, f := range formats[1:] {
+		if r.Less(worst, f) {
+			worst = f
+		}
+	}
+
+	return worst, true
+}
+
+// rankedFormats sorts formats by their precomputed scores following the order documented by Ranking.
+type rankedFormats struct {
+	formats Formats
+	scores  []float64
+}
+
+func (r rankedFormats) Len() int {
+	return len(r.formats)
+}
+
+func (r rankedFormats) Less(i, j int) bool {
+	if r.scores[i] != r.scores[j] {
+		return r.scores[i] > r.scores[j]
+	}
+	if a, b := r.formats[i], r.formats[j]; a.ITag != b.ITag {
+		return a.ITag < b.ITag
+	}
+	return r.formats[i].Bitrate > r.formats[j].Bitrate
+}
+
+func (r rankedFormats) Swap(i, j int) {
+	r.formats[i], r.formats[j] = r.formats[j], r.formats[i]
+	r.scores[i], r.scores[j] = r.scores[j], r.scores[i]
+}
+
+// formatHDR reports whether format f has a high dynamic range, which is either signalled by its transfer
+// characteristics or by its quality label.
+func formatHDR(f Format) bool {
+	if f.ColorInfo != nil {
+		switch f.ColorInfo.TransferCharacteristics {
+		case "COLOR_TRANSFER_CHARACTERISTICS_SMPTEST2084", "COLOR_TRANSFER_CHARACTERISTICS_ARIB_STD_B67":
+			return true
+		}
+	}
+	return strings.HasSuffix(f.QualityLabel, "HDR")
+}
@@ -0,0 +1,104 @@
+package youtube
+
+import (
+	"github.com/stretchr/testify/require"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+)
+
+// randomFormat generates formats out of small pools of values so that formats often tie in some or all qualities.
+type randomFormat struct {
+	Format
+}
+
+func (randomFormat) Generate(r *rand.Rand, _ int) reflect.Value {
+	pick := func(values ...string) string { return values[r.Intn(len(values))] }
+
+	f := Format{
+		ITag:     uint(r.Intn(4)),
+		Bitrate:  uint(r.Intn(3)) * 64000,
+		Width:    uint(r.Intn(3)) * 640,
+		Height:   uint(r.Intn(3)) * 360,
+		MIMEType: pick(`video/mp4; codecs="avc1.640028"`, `video/webm; codecs="vp9"`, `audio/webm; codecs="opus"`, `video/mp4; codecs="avc1.42001E, mp4a.40.2"`),
+	}
+
+	if r.Intn(2) == 0 {
+		fps := uint(r.Intn(3)) * 30
+		f.FPS = &fps
+	}
+
+	if r.Intn(2) == 0 {
+		quality := pick("AUDIO_QUALITY_LOW", "AUDIO_QUALITY_MEDIUM", "AUDIO_QUALITY_UNKNOWN")
+		f.AudioQuality = &quality
+	}
+
+	if r.Intn(2) == 0 {
+		f.ColorInfo = &ColorInfo{TransferCharacteristics: "COLOR_TRANSFER_CHARACTERISTICS_SMPTEST2084"}
+	}
+
+	return reflect.ValueOf(randomFormat{f})
+}
+
+func TestRankingIsStrictWeakOrder(t *testing.T) {
+	rankings := []Ranking{
+		VideoRanking,
+		AudioRanking,
+		{},
+		{Resolution: -1, Bitrate: 3, HDR: 0.5},
+		{Resolution: math.Inf(1), FPS: math.Inf(-1)},
+		{Codec: math.NaN()},
+	}
+
+	config := &quick.Config{MaxCount: 5000}
+
+	for _, r := range rankings {
+		irreflexive := func(a randomFormat) bool {
+			return !r.Less(a.Format, a.Format)
+		}
+
+		asymmetric := func(a, b randomFormat) bool {
+			return !(r.Less(a.Format, b.Format) && r.Less(b.Format, a.Format))
+		}
+
+		transitive := func(a, b, c randomFormat) bool {
+			return !(r.Less(a.Format, b.Format) && r.Less(b.Format, c.Format)) || r.Less(a.Format, c.Format)
+		}
+
+		equivalent := func(a, b Format) bool { return !r.Less(a, b) && !r.Less(b, a) }
+
+		transitiveEquivalence := func(a, b, c randomFormat) bool {
+			return !(equivalent(a.Format, b.Format) && equivalent(b.Format, c.Format)) || equivalent(a.Format, c.Format)
+		}
+
+		require.NoError(t, quick.Check(irreflexive, config))
+		require.NoError(t, quick.Check(asymmetric, config))
+		require.NoError(t, quick.Check(transitive, config))
+		require.NoError(t, quick.Check(transitiveEquivalence, config))
+	}
+}
+
+func TestRankingSort(t *testing.T) {
+	formats := loadTestStreams(t).Formats()
+
+	require.Equal(t, []uint{337, 399, 248, 137, 302, 247, 136, 22, 135, 18, 251, 250, 140, 249}, itagsOf(SortByVideoQuality(formats)))
+	require.Equal(t, []uint{251, 140, 250, 249}, itagsOf(SortByAudioQuality(loadTestStreams(t).SourceFormats()[8:])))
+
+	shuffled := append(Formats{}, formats...)
+	rand.New(rand.NewSource(0)).Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+
+	require.Equal(t, itagsOf(SortByVideoQuality(formats)), itagsOf(SortByVideoQuality(shuffled)))
+
+	best, ok := formats.BestVideo()
+	require.True(t, ok)
+	require.EqualValues(t, 337, best.ITag)
+
+	worst, ok := VideoRanking.Worst(formats)
+	require.True(t, ok)
+	require.EqualValues(t, 249, worst.ITag)
+
+	_, ok = Formats{}.BestAudio()
+	require.False(t, ok)
+}
@@ -0,0 +1,155 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// RateLimitedTransport is a Transport that limits the rate at which requests are sent, both overall and to each
+// host, and the number of requests that may be in flight at once. A RateLimitedTransport must not be copied after
+// it is first used.
+type RateLimitedTransport struct {
+	// The transport whose requests are limited.
+	Transport Transport
+
+	// Max number of requests sent per second across all hosts. Zero disables the limit.
+	RequestsPerSecond float64
+
+	// Max number of requests sent per second to any single host. Zero disables the limit.
+	HostRequestsPerSecond float64
+
+	// Max number of requests that may be sent at once after a period of inactivity. Defaults to one.
+	Burst int
+
+	// Max number of requests in flight at once. Zero disables the limit.
+	MaxConcurrent int
+
+	once  sync.Once
+	burst float64
+	total *bucket
+	sem   chan struct{}
+
+	mu    sync.Mutex
+	hosts map[string]*bucket
+}
+
+func (t *RateLimitedTransport) DownloadBytes(dst []byte, url string) ([]byte, error) {
+	return t.DownloadBytesDeadline(dst, url, zeroTime)
+}
+
+func (t *RateLimitedTransport) DownloadBytesTimeout(dst []byte, url string, timeout time.Duration) ([]byte, error) {
+	return t.DownloadBytesDeadline(dst, url, time.Now().Add(timeout))
+}
+
+// DownloadBytesDeadline downloads the contents of url once the limits of the transport allow for it. Should the
+// limits not allow for the request to be sent before deadline, the request is abandoned.
+func (t *RateLimitedTransport) DownloadBytesDeadline(dst []byte, url string, deadline time.Time) ([]byte, error) {
+	release, err := t.acquire(url, deadline)
+	if err != nil {
+		return dst, err
+	}
+	defer release()
+
+	return t.Transport.DownloadBytesDeadline(dst, url, deadline)
+}
+
+// RoundTripDeadline sends req through the underlying transport adapted using AdaptTransport once the limits of the
+// transport allow for it.
+func (t *RateLimitedTransport) RoundTripDeadline(req *Request, res *Response, deadline time.Time) error {
+	release, err := t.acquire(req.URL, deadline)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	return AdaptTransport(t.Transport).RoundTripDeadline(req, res, deadline)
+}
+
+// acquire waits until a request to url may be sent, or until deadline. The release function returned must be called
+// once the request is done.
+func (t *RateLimitedTransport) acquire(url string, deadline time.Time) (release func(), err error) {
+	t.once.Do(t.init)
+
+	ctx := context.Background()
+
+	if !deadline.IsZero() {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+
+	release = func() {}
+
+	if t.sem != nil {
+		select {
+		case t.sem <- struct{}{}:
+			release = func() { <-t.sem }
+		case <-ctx.Done():
+			return nil, errors.New("timed out waiting for a free request slot")
+		}
+	}
+
+	if t.total != nil {
+		if err := t.total.take(ctx, 1); err != nil {
+			release()
+			return nil, errors.New("timed out waiting for the request rate limit")
+		}
+	}
+
+	if host := t.host(url); host != nil {
+		if err := host.take(ctx, 1); err != nil {
+			if t.total != nil {
+				t.total.put(1)
+			}
+			release()
+			return nil, errors.New("timed out waiting for the per-host request rate limit")
+		}
+	}
+
+	return release, nil
+}
+
+func (t *RateLimitedTransport) init() {
+	t.burst = float64(t.Burst)
+	if t.burst < 1 {
+		t.burst = 1
+	}
+
+	if t.RequestsPerSecond > 0 {
+		t.total = newBucket(t.RequestsPerSecond, t.burst)
+	}
+
+	if t.MaxConcurrent > 0 {
+		t.sem = make(chan struct{}, t.MaxConcurrent)
+	}
+
+	t.hosts = make(map[string]*bucket)
+}
+
+// host returns the token bucket limiting requests to the host of uri. It returns nil if requests per host are not
+// limited.
+func (t *RateLimitedTransport) host(uri string) *bucket {
+	if t.HostRequestsPerSecond <= 0 {
+		return nil
+	}
+
+	var host string
+	if u, err := url.Parse(uri); err == nil {
+		host = u.Host
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b, exists := t.hosts[host]
+	if !exists {
+		b = newBucket(t.HostRequestsPerSecond, t.burst)
+		t.hosts[host] = b
+	}
+
+	return b
+}
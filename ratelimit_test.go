@@ -0,0 +1,119 @@
+package youtube
+
+import (
+	"github.com/stretchr/testify/require"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// slowTransport records the peak number of requests in flight at once, and the times at which requests were made.
+type slowTransport struct {
+	delay time.Duration
+
+	inflight int32
+	peak     int32
+
+	mu       sync.Mutex
+	requests []time.Time
+}
+
+func (t *slowTransport) DownloadBytesDeadline(dst []byte, url string, deadline time.Time) ([]byte, error) {
+	n := atomic.AddInt32(&t.inflight, 1)
+	defer atomic.AddInt32(&t.inflight, -1)
+
+	for {
+		peak := atomic.LoadInt32(&t.peak)
+		if n <= peak || atomic.CompareAndSwapInt32(&t.peak, peak, n) {
+			break
+		}
+	}
+
+	t.mu.Lock()
+	t.requests = append(t.requests, time.Now())
+	t.mu.Unlock()
+
+	time.Sleep(t.delay)
+
+	return append(dst, "ok"...), nil
+}
+
+func TestRateLimitedTransportConcurrency(t *testing.T) {
+	inner := &slowTransport{delay: 20 * time.Millisecond}
+	transport := &RateLimitedTransport{Transport: inner, MaxConcurrent: 2}
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			buf, err := transport.DownloadBytes(nil, "https://www.youtube.com/watch?v=pAsDzfbLM8Y")
+			require.NoError(t, err)
+			require.Equal(t, "ok", string(buf))
+		}()
+	}
+
+	wg.Wait()
+
+	require.EqualValues(t, 2, inner.peak)
+	require.Len(t, inner.requests, 8)
+}
+
+func TestRateLimitedTransportRate(t *testing.T) {
+	inner := &slowTransport{}
+	transport := &RateLimitedTransport{Transport: inner, HostRequestsPerSecond: 20, Burst: 2}
+
+	start := time.Now()
+
+	for i := 0; i < 4; i++ {
+		_, err := transport.DownloadBytes(nil, "https://www.youtube.com/watch?v=pAsDzfbLM8Y")
+		require.NoError(t, err)
+	}
+
+	// Requests to other hosts are limited separately.
+
+	_, err := transport.DownloadBytes(nil, "https://i.ytimg.com/vi/pAsDzfbLM8Y/hqdefault.jpg")
+	require.NoError(t, err)
+
+	// The first two requests are sent in a burst, and the other two are spaced out by 50ms each.
+
+	require.True(t, time.Since(start) >= 90*time.Millisecond)
+	require.True(t, inner.requests[4].Sub(inner.requests[3]) < 40*time.Millisecond)
+
+	// Requests that are not allowed to be sent before their deadline are abandoned.
+
+	transport = &RateLimitedTransport{Transport: inner, RequestsPerSecond: 1}
+
+	_, err = transport.DownloadBytesTimeout(nil, "https://www.youtube.com", time.Second)
+	require.NoError(t, err)
+
+	_, err = transport.DownloadBytesTimeout(nil, "https://www.youtube.com", 10*time.Millisecond)
+	require.Error(t, err)
+}
+
+func TestRateLimitedTransportTimeoutRefund(t *testing.T) {
+	inner := &slowTransport{}
+	transport := &RateLimitedTransport{Transport: inner, RequestsPerSecond: 5}
+
+	start := time.Now()
+
+	_, err := transport.DownloadBytes(nil, "https://www.youtube.com")
+	require.NoError(t, err)
+
+	_, err = transport.DownloadBytesTimeout(nil, "https://www.youtube.com", 10*time.Millisecond)
+	require.Error(t, err)
+
+	// The request that timed out gave back its token, so the next request only waits for the bucket to refill after
+	// the first request (200ms) rather than after both (400ms).
+
+	_, err = transport.DownloadBytes(nil, "https://www.youtube.com")
+	require.NoError(t, err)
+
+	elapsed := time.Since(start)
+	require.True(t, elapsed >= 150*time.Millisecond && elapsed < 300*time.Millisecond, elapsed)
+	require.Len(t, inner.requests, 2)
+}
@@ -0,0 +1,341 @@
+package youtube
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Ref is a typed reference to a resource on YouTube parsed out of a URL by ParseURL. It is one of VideoRef,
+// PlaylistRef, ChannelRef, or SearchRef.
+type Ref interface {
+	// URL returns the canonical URL of the referenced resource.
+	URL() string
+
+	isRef()
+}
+
+// VideoRef references a single video, optionally linked from within a playlist. Start and End, when non-zero, denote
+// the window of the video the URL points to.
+type VideoRef struct {
+	ID    StreamID
+	List  string
+	Start time.Duration
+	End   time.Duration
+}
+
+// PlaylistRef references a playlist.
+type PlaylistRef struct {
+	ID string
+}
+
+type ChannelKind uint8
+
+const (
+	ChannelByID ChannelKind = iota
+	ChannelByHandle
+	ChannelByUser
+	ChannelByCustomURL
+)
+
+func (k ChannelKind) String() string {
+	switch k {
+	case ChannelByID:
+		return "id"
+	case ChannelByHandle:
+		return "handle"
+	case ChannelByUser:
+		return "user"
+	case ChannelByCustomURL:
+		return "custom url"
+	default:
+		return fmt.Sprintf("unknown channel kind %d", k)
+	}
+}
+
+// ChannelRef references a channel either by its ID, its @handle, its legacy username, or its custom URL.
+type ChannelRef struct {
+	Kind ChannelKind
+	Name string
+}
+
+// SearchRef references the results of a search query.
+type SearchRef struct {
+	Query string
+}
+
+func (VideoRef) isRef()    {}
+func (PlaylistRef) isRef() {}
+func (ChannelRef) isRef()  {}
+func (SearchRef) isRef()   {}
+
+func (r VideoRef) URL() string {
+	if r.End > 0 {
+		return r.embedURL()
+	}
+
+	uri := "https://www.youtube.com/watch?v=" + string(r.ID)
+	if r.List != "" {
+		uri += "&list=" + url.QueryEscape(r.List)
+	}
+	if r.Start > 0 {
+		uri += "&t=" + strconv.FormatInt(int64(r.Start/time.Second), 10) + "s"
+	}
+	return uri
+}
+
+// embedURL returns the URL to the embed player for the referenced video, which unlike the watch page supports
+// specifying the time the video should end at.
+func (r VideoRef) embedURL() string {
+	uri := "https://www.youtube.com/embed/" + string(r.ID) + "?start=" + strconv.FormatInt(int64(r.Start/time.Second), 10)
+	uri += "&end=" + strconv.FormatInt(int64(r.End/time.Second), 10)
+	if r.List != "" {
+		uri += "&list=" + url.QueryEscape(r.List)
+	}
+	return uri
+}
+
+func (r PlaylistRef) URL() string {
+	return "https://www.youtube.com/playlist?list=" + url.QueryEscape(r.ID)
+}
+
+func (r ChannelRef) URL() string {
+	switch r.Kind {
+	case ChannelByHandle:
+		return "https://www.youtube.com/@" + url.PathEscape(r.Name)
+	case ChannelByUser:
+		return "https://www.youtube.com/user/" + url.PathEscape(r.Name)
+	case ChannelByCustomURL:
+		return "https://www.youtube.com/c/" + url.PathEscape(r.Name)
+	default:
+		return "https://www.youtube.com/channel/" + url.PathEscape(r.Name)
+	}
+}
+
+func (r SearchRef) URL() string {
+	return "https://www.youtube.com/results?search_query=" + url.QueryEscape(r.Query)
+}
+
+// ParseURL parses a YouTube URL, or a bare stream ID, into a typed reference to the video, playlist, channel or
+// search results it points to.
+func ParseURL(src string) (Ref, error) {
+	if id := StreamID(strings.TrimSpace(src)); id.Valid() == nil {
+		return VideoRef{ID: id}, nil
+	}
+
+	u, host, err := parseYouTubeURL(src)
+	if err != nil {
+		return nil, err
+	}
+
+	query := u.Query()
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+
+	if host != "youtu.be" && segments[0] == "playlist" {
+		if list := query.Get("list"); list != "" {
+			return PlaylistRef{ID: list}, nil
+		}
+		return nil, fmt.Errorf("could not find playlist id in url %q", src)
+	}
+
+	if id, ok := streamIDFromURL(u, host); ok {
+		ref := VideoRef{ID: id, List: query.Get("list")}
+
+		if ref.Start, ref.End, err = parseTimeWindow(u); err != nil {
+			return nil, fmt.Errorf("failed to parse time window in url %q: %w", src, err)
+		}
+
+		return ref, nil
+	}
+
+	if host == "youtu.be" {
+		return nil, fmt.Errorf("could not find stream id in url %q", src)
+	}
+
+	switch {
+	case strings.HasPrefix(segments[0], "@") && len(segments[0]) > 1:
+		return ChannelRef{Kind: ChannelByHandle, Name: segments[0][1:]}, nil
+	case len(segments) >= 2 && segments[1] != "":
+		switch segments[0] {
+		case "channel":
+			return ChannelRef{Kind: ChannelByID, Name: segments[1]}, nil
+		case "user":
+			return ChannelRef{Kind: ChannelByUser, Name: segments[1]}, nil
+		case "c":
+			return ChannelRef{Kind: ChannelByCustomURL, Name: segments[1]}, nil
+		}
+	case segments[0] == "results" || segments[0] == "search":
+		q := query.Get("search_query")
+		if q == "" {
+			q = query.Get("q")
+		}
+		if q != "" {
+			return SearchRef{Query: q}, nil
+		}
+		return nil, fmt.Errorf("could not find search query in url %q", src)
+	}
+
+	if list := query.Get("list"); list != "" {
+		return PlaylistRef{ID: list}, nil
+	}
+
+	return nil, fmt.Errorf("url %q does not reference a video, playlist, channel, or search query", src)
+}
+
+// parseTimeWindow parses the start and end offsets of the video a URL points to. Offsets may be specified through the
+// 't' query parameter or fragment of watch URLs, the 'start' and 'end' query parameters of embed URLs, or the 'clipt'
+// query parameter of clip URLs.
+func parseTimeWindow(u *url.URL) (start, end time.Duration, err error) {
+	query := u.Query()
+
+	if clip := query.Get("clipt"); clip != "" {
+		return parseClipTimeWindow(clip)
+	}
+
+	switch {
+	case query.Get("t") != "":
+		start, err = parseTimestamp(query.Get("t"))
+	case query.Get("start") != "":
+		start, err = parseTimestamp(query.Get("start"))
+	case strings.HasPrefix(u.Fragment, "t="):
+		start, err = parseTimestamp(u.Fragment[2:])
+	}
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if query.Get("end") != "" {
+		if end, err = parseTimestamp(query.Get("end")); err != nil {
+			return 0, 0, err
+		}
+
+		if end <= start {
+			return 0, 0, fmt.Errorf("end %s is not after start %s", end, start)
+		}
+	}
+
+	return start, end, nil
+}
+
+// parseClipTimeWindow decodes the 'clipt' query parameter of clip URLs, which is a base64-encoded protobuf message
+// whose second and third fields hold the start and end of the clip in milliseconds.
+func parseClipTimeWindow(s string) (start, end time.Duration, err error) {
+	buf, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to decode clip time window: %w", err)
+	}
+
+	malformed := errors.New("clip time window is malformed")
+
+	for len(buf) > 0 {
+		key, n := binary.Uvarint(buf)
+		if n <= 0 {
+			return 0, 0, malformed
+		}
+		buf = buf[n:]
+
+		var skip uint64
+
+		switch key & 7 {
+		case 0:
+			val, n := binary.Uvarint(buf)
+			if n <= 0 {
+				return 0, 0, malformed
+			}
+
+			switch key >> 3 {
+			case 2:
+				start = time.Duration(val) * time.Millisecond
+			case 3:
+				end = time.Duration(val) * time.Millisecond
+			}
+
+			skip = uint64(n)
+		case 1:
+			skip = 8
+		case 2:
+			size, n := binary.Uvarint(buf)
+			if n <= 0 {
+				return 0, 0, malformed
+			}
+			skip = uint64(n) + size
+		case 5:
+			skip = 4
+		default:
+			return 0, 0, fmt.Errorf("clip time window has unknown wire type %d", key&7)
+		}
+
+		if skip > uint64(len(buf)) {
+			return 0, 0, malformed
+		}
+		buf = buf[skip:]
+	}
+
+	if end <= start {
+		return 0, 0, fmt.Errorf("clip end %s is not after start %s", end, start)
+	}
+
+	return start, end, nil
+}
+
+// parseTimestamp parses a timestamp found in a YouTube URL, which is either a plain number of seconds (90) or a
+// combination of hours, minutes and seconds (1h2m3s, 1m30s, 90s).
+func parseTimestamp(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, fmt.Errorf("timestamp is empty")
+	}
+
+	if n, err := strconv.ParseUint(s, 10, 32); err == nil {
+		return time.Duration(n) * time.Second, nil
+	}
+
+	var (
+		total time.Duration
+		n     uint64
+		digit bool
+	)
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		if c >= '0' && c <= '9' {
+			n = n*10 + uint64(c-'0')
+			digit = true
+
+			if n > 1<<32 {
+				return 0, fmt.Errorf("timestamp %q is too large", s)
+			}
+
+			continue
+		}
+
+		if !digit {
+			return 0, fmt.Errorf("timestamp %q is malformed", s)
+		}
+
+		switch c {
+		case 'h':
+			total += time.Duration(n) * time.Hour
+		case 'm':
+			total += time.Duration(n) * time.Minute
+		case 's':
+			total += time.Duration(n) * time.Second
+		default:
+			return 0, fmt.Errorf("timestamp %q has unknown unit %q", s, c)
+		}
+
+		n, digit = 0, false
+	}
+
+	if digit {
+		total += time.Duration(n) * time.Second
+	}
+
+	return total, nil
+}
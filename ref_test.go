@@ -0,0 +1,137 @@
+package youtube
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		src string
+		ref Ref
+	}{
+		{"pAsDzfbLM8Y", VideoRef{ID: "pAsDzfbLM8Y"}},
+		{"https://www.youtube.com/watch?v=pAsDzfbLM8Y", VideoRef{ID: "pAsDzfbLM8Y"}},
+		{"https://youtube.com/watch?v=pAsDzfbLM8Y&feature=youtu.be", VideoRef{ID: "pAsDzfbLM8Y"}},
+		{"http://m.youtube.com/watch?v=pAsDzfbLM8Y", VideoRef{ID: "pAsDzfbLM8Y"}},
+		{"https://music.youtube.com/watch?v=pAsDzfbLM8Y", VideoRef{ID: "pAsDzfbLM8Y"}},
+		{"youtube.com/watch?v=pAsDzfbLM8Y", VideoRef{ID: "pAsDzfbLM8Y"}},
+		{"https://youtu.be/pAsDzfbLM8Y", VideoRef{ID: "pAsDzfbLM8Y"}},
+		{"https://www.youtube.com/shorts/pAsDzfbLM8Y", VideoRef{ID: "pAsDzfbLM8Y"}},
+		{"https://www.youtube.com/live/pAsDzfbLM8Y", VideoRef{ID: "pAsDzfbLM8Y"}},
+		{"https://www.youtube.com/embed/pAsDzfbLM8Y", VideoRef{ID: "pAsDzfbLM8Y"}},
+		{"https://www.youtube-nocookie.com/embed/pAsDzfbLM8Y", VideoRef{ID: "pAsDzfbLM8Y"}},
+		{"https://www.youtube.com/v/pAsDzfbLM8Y", VideoRef{ID: "pAsDzfbLM8Y"}},
+
+		{"https://youtu.be/pAsDzfbLM8Y?t=90", VideoRef{ID: "pAsDzfbLM8Y", Start: 90 * time.Second}},
+		{"https://youtu.be/pAsDzfbLM8Y?t=90s", VideoRef{ID: "pAsDzfbLM8Y", Start: 90 * time.Second}},
+		{"https://www.youtube.com/watch?v=pAsDzfbLM8Y&t=1m30s", VideoRef{ID: "pAsDzfbLM8Y", Start: 90 * time.Second}},
+		{"https://www.youtube.com/watch?v=pAsDzfbLM8Y&t=1h2m3s", VideoRef{ID: "pAsDzfbLM8Y", Start: time.Hour + 2*time.Minute + 3*time.Second}},
+		{"https://www.youtube.com/watch?v=pAsDzfbLM8Y&t=2m", VideoRef{ID: "pAsDzfbLM8Y", Start: 2 * time.Minute}},
+		{"https://www.youtube.com/watch?v=pAsDzfbLM8Y#t=45", VideoRef{ID: "pAsDzfbLM8Y", Start: 45 * time.Second}},
+
+		{"https://www.youtube.com/watch?v=pAsDzfbLM8Y&list=PL25785A39039615CF", VideoRef{ID: "pAsDzfbLM8Y", List: "PL25785A39039615CF"}},
+		{"https://www.youtube.com/watch?v=pAsDzfbLM8Y&list=PL25785A39039615CF&index=3&t=10", VideoRef{ID: "pAsDzfbLM8Y", List: "PL25785A39039615CF", Start: 10 * time.Second}},
+		{"https://youtu.be/pAsDzfbLM8Y?list=PL25785A39039615CF", VideoRef{ID: "pAsDzfbLM8Y", List: "PL25785A39039615CF"}},
+
+		{"https://www.youtube.com/playlist?list=PL25785A39039615CF", PlaylistRef{ID: "PL25785A39039615CF"}},
+		{"https://music.youtube.com/playlist?list=OLAK5uy_kJ8gPahHcGMo0NwR9dHS6F6Q8w3OHjRJA", PlaylistRef{ID: "OLAK5uy_kJ8gPahHcGMo0NwR9dHS6F6Q8w3OHjRJA"}},
+		{"https://www.youtube.com/watch?list=PL25785A39039615CF", PlaylistRef{ID: "PL25785A39039615CF"}},
+		{"https://www.youtube.com/embed/videoseries?list=PL25785A39039615CF", PlaylistRef{ID: "PL25785A39039615CF"}},
+
+		{"https://www.youtube.com/channel/UCHc3wFJ5hMdJMBJS4QhJPFA", ChannelRef{Kind: ChannelByID, Name: "UCHc3wFJ5hMdJMBJS4QhJPFA"}},
+		{"https://www.youtube.com/channel/UCHc3wFJ5hMdJMBJS4QhJPFA/videos", ChannelRef{Kind: ChannelByID, Name: "UCHc3wFJ5hMdJMBJS4QhJPFA"}},
+		{"https://www.youtube.com/@theglitchmob", ChannelRef{Kind: ChannelByHandle, Name: "theglitchmob"}},
+		{"https://www.youtube.com/@theglitchmob/featured", ChannelRef{Kind: ChannelByHandle, Name: "theglitchmob"}},
+		{"https://www.youtube.com/user/theglitchmob", ChannelRef{Kind: ChannelByUser, Name: "theglitchmob"}},
+		{"https://www.youtube.com/c/TheGlitchMob", ChannelRef{Kind: ChannelByCustomURL, Name: "TheGlitchMob"}},
+
+		{"https://www.youtube.com/results?search_query=animus+vox", SearchRef{Query: "animus vox"}},
+		{"https://www.youtube.com/results?search_query=animus%20vox&sp=EgIQAQ%3D%3D", SearchRef{Query: "animus vox"}},
+		{"https://music.youtube.com/search?q=animus+vox", SearchRef{Query: "animus vox"}},
+	}
+
+	for _, test := range tests {
+		ref, err := ParseURL(test.src)
+		require.NoError(t, err, test.src)
+		require.Equal(t, test.ref, ref, test.src)
+	}
+}
+
+func TestParseURLInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"a-very-long-garbage-string",
+		"https://www.example.com/watch?v=pAsDzfbLM8Y",
+		"https://www.youtube.com/",
+		"https://www.youtube.com/watch?v=short",
+		"https://www.youtube.com/playlist",
+		"https://www.youtube.com/results",
+		"https://www.youtube.com/@",
+		"https://www.youtube.com/channel/",
+		"https://youtu.be/",
+		"https://youtu.be/pAsDzfbLM8Y?t=abc",
+		"https://www.youtube.com/watch?v=pAsDzfbLM8Y&t=1x",
+	}
+
+	for _, src := range tests {
+		_, err := ParseURL(src)
+		require.Error(t, err, src)
+	}
+}
+
+func TestRefURLRoundTrip(t *testing.T) {
+	refs := []Ref{
+		VideoRef{ID: "pAsDzfbLM8Y"},
+		VideoRef{ID: "pAsDzfbLM8Y", Start: 90 * time.Second},
+		VideoRef{ID: "pAsDzfbLM8Y", List: "PL25785A39039615CF", Start: time.Hour},
+		PlaylistRef{ID: "PL25785A39039615CF"},
+		ChannelRef{Kind: ChannelByID, Name: "UCHc3wFJ5hMdJMBJS4QhJPFA"},
+		ChannelRef{Kind: ChannelByHandle, Name: "theglitchmob"},
+		ChannelRef{Kind: ChannelByUser, Name: "theglitchmob"},
+		ChannelRef{Kind: ChannelByCustomURL, Name: "TheGlitchMob"},
+		SearchRef{Query: "animus vox"},
+		SearchRef{Query: "the glitch mob & friends?"},
+	}
+
+	for _, ref := range refs {
+		parsed, err := ParseURL(ref.URL())
+		require.NoError(t, err, ref.URL())
+		require.Equal(t, ref, parsed, ref.URL())
+	}
+}
+
+func TestParseURLTimeWindow(t *testing.T) {
+	tests := []struct {
+		src        string
+		start, end time.Duration
+	}{
+		{"https://www.youtube.com/embed/pAsDzfbLM8Y?start=30&end=60", 30 * time.Second, time.Minute},
+		{"https://www.youtube-nocookie.com/embed/pAsDzfbLM8Y?end=15", 0, 15 * time.Second},
+		{"https://www.youtube.com/watch?v=pAsDzfbLM8Y&t=1m30s", 90 * time.Second, 0},
+		{"https://youtu.be/pAsDzfbLM8Y?start=5", 5 * time.Second, 0},
+		{"https://www.youtube.com/watch?v=pAsDzfbLM8Y&clip=Ugkx&clipt=EMCYFhjAxxY", 363584 * time.Millisecond, 369600 * time.Millisecond},
+	}
+
+	for _, test := range tests {
+		ref, err := ParseURL(test.src)
+		require.NoError(t, err, test.src)
+		require.Equal(t, test.start, ref.(VideoRef).Start, test.src)
+		require.Equal(t, test.end, ref.(VideoRef).End, test.src)
+	}
+
+	for _, src := range []string{
+		"https://www.youtube.com/embed/pAsDzfbLM8Y?start=60&end=30",
+		"https://www.youtube.com/watch?v=pAsDzfbLM8Y&clipt=!!!",
+	} {
+		_, err := ParseURL(src)
+		require.Error(t, err, src)
+	}
+
+	ref := VideoRef{ID: "pAsDzfbLM8Y", Start: 30 * time.Second, End: time.Minute}
+
+	parsed, err := ParseURL(ref.URL())
+	require.NoError(t, err)
+	require.Equal(t, ref, parsed)
+}
@@ -0,0 +1,99 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// streamURL is a signed URL to a format of a stream which may be refreshed by reloading the stream's player once the
+// URL expires.
+type streamURL struct {
+	client Client
+	id     StreamID
+	itag   uint
+	size   uint64
+
+	mu      sync.Mutex
+	url     string
+	expires time.Time
+	version int
+}
+
+func newStreamURL(client Client, id StreamID, itag uint, size uint64, url string, expires time.Time) *streamURL {
+	if expiry, ok := expiryOfURL(url); ok {
+		expires = expiry
+	}
+
+	return &streamURL{client: client, id: id, itag: itag, size: size, url: url, expires: expires}
+}
+
+// get returns the current URL alongside its version, which is bumped every time the URL is refreshed.
+func (s *streamURL) get() (string, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.url, s.version
+}
+
+// expired reports whether the URL has expired given err, the error returned while downloading from it.
+func (s *streamURL) expired(err error) bool {
+	var status *StatusError
+	if errors.As(err, &status) && (status.StatusCode == 403 || status.StatusCode == 410) {
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return !s.expires.IsZero() && time.Now().After(s.expires)
+}
+
+// refresh reloads the player of the stream and resolves a fresh URL to the format with the same itag, unless the
+// URL has already been refreshed since the given version was retrieved.
+func (s *streamURL) refresh(ctx context.Context, version int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.version != version {
+		return nil
+	}
+
+	if s.id == "" {
+		return errors.New("stream id of url is unknown")
+	}
+
+	deadline, _ := ctx.Deadline()
+
+	client := s.client
+
+	player, err := client.LoadDeadline(s.id, deadline)
+	if err != nil {
+		return fmt.Errorf("failed to reload player: %w", err)
+	}
+
+	f, ok := findFormatByITag(player, s.itag)
+	if !ok {
+		return fmt.Errorf("format with itag %d is no longer available for stream id %q", s.itag, s.id)
+	}
+
+	if size := f.Size(); size != 0 && s.size != 0 && size != s.size {
+		return fmt.Errorf("content length of format with itag %d changed from %d to %d", s.itag, s.size, size)
+	}
+
+	url, err := player.ResolveURLDeadline(f, deadline)
+	if err != nil {
+		return fmt.Errorf("failed to resolve url of format with itag %d: %w", s.itag, err)
+	}
+
+	s.url = url
+	s.expires = player.ExpiresAt()
+	if expiry, ok := expiryOfURL(url); ok {
+		s.expires = expiry
+	}
+	s.version++
+
+	return nil
+}
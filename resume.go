@@ -0,0 +1,243 @@
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// PartialFileSuffix is appended to the name of a file being downloaded to name the sidecar file recording which byte
+// ranges of the file have been downloaded so far.
+const PartialFileSuffix = ".ytpart"
+
+// partialFile is the state of a resumable download persisted in a sidecar file.
+type partialFile struct {
+	ID            StreamID    `json:"id"`
+	ITag          uint        `json:"itag"`
+	URL           string      `json:"url"`
+	ContentLength uint64      `json:"content_length"`
+	Completed     [][2]uint64 `json:"completed"`
+}
+
+// DownloadFile downloads format f to filename using a downloader with default settings on top of the player's
+// transport. See (*Downloader).DownloadFile.
+func (p Player) DownloadFile(ctx context.Context, f Format, filename string) error {
+	d := NewDownloader(p.Transport)
+	return d.DownloadFile(ctx, p, f, filename)
+}
+
+// DownloadFile downloads format f to filename. Byte ranges that have been downloaded are recorded in a sidecar file
+// named filename+PartialFileSuffix such that, should the download be interrupted, calling DownloadFile or ResumeFile
+// again only downloads the byte ranges that are missing. The sidecar file is removed once the download completes.
+//
+// Formats with an unknown content length are downloaded from scratch in a single request instead.
+func (d *Downloader) DownloadFile(ctx context.Context, p Player, f Format, filename string) error {
+	deadline, _ := ctx.Deadline()
+
+	uri, err := p.ResolveURLDeadline(f, deadline)
+	if err != nil {
+		return fmt.Errorf("failed to resolve url of format with itag %d: %w", f.ITag, err)
+	}
+
+	size := f.Size()
+
+	if size == 0 {
+		w, err := os.Create(filename)
+		if err != nil {
+			return fmt.Errorf("failed to create file: %w", err)
+		}
+		defer w.Close()
+
+		return d.download(ctx, newStreamURL(p.reloader(d.Transport), p.ID(), f.ITag, 0, uri, p.ExpiresAt()), 0, w)
+	}
+
+	state, err := readPartialFile(filename + PartialFileSuffix)
+	if err != nil || state.ID != p.ID() || state.ITag != f.ITag || state.ContentLength != size {
+		state = partialFile{ID: p.ID(), ITag: f.ITag, ContentLength: size}
+	}
+
+	src := newStreamURL(p.reloader(d.Transport), p.ID(), f.ITag, size, uri, p.ExpiresAt())
+
+	return d.resume(ctx, filename, state, src)
+}
+
+// ResumeFile resumes an interrupted download to filename started by DownloadFile using only the state recorded in
+// the download's sidecar file. If the signed URL the download was started with has expired, the player of the
+// download's stream is reloaded using the downloader's transport to obtain a fresh URL.
+func (d *Downloader) ResumeFile(ctx context.Context, filename string) error {
+	state, err := readPartialFile(filename + PartialFileSuffix)
+	if err != nil {
+		return fmt.Errorf("failed to read state of partial download: %w", err)
+	}
+
+	src := newStreamURL(WrapClient(d.Transport), state.ID, state.ITag, state.ContentLength, state.URL, zeroTime)
+
+	if expiry, ok := expiryOfURL(state.URL); !ok || time.Until(expiry) < time.Minute {
+		if err := src.refresh(ctx, 0); err != nil {
+			return fmt.Errorf("failed to refresh url of partial download: %w", err)
+		}
+	}
+
+	return d.resume(ctx, filename, state, src)
+}
+
+// resume downloads from src all byte ranges of a file not yet marked as completed in state, recording each
+// downloaded byte range in the file's sidecar file.
+func (d *Downloader) resume(ctx context.Context, filename string, state partialFile, src *streamURL) error {
+	w, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer w.Close()
+
+	if err := w.Truncate(int64(state.ContentLength)); err != nil {
+		return fmt.Errorf("failed to truncate file to %d byte(s): %w", state.ContentLength, err)
+	}
+
+	sidecar := filename + PartialFileSuffix
+
+	state.URL, _ = src.get()
+
+	if err := writePartialFile(sidecar, state); err != nil {
+		return fmt.Errorf("failed to write state of partial download: %w", err)
+	}
+
+	done := func(s ByteRange) error {
+		state.URL, _ = src.get()
+		state.Completed = mergeSpan(state.Completed, s)
+		return writePartialFile(sidecar, state)
+	}
+
+	missing := missingSpans(state.Completed, state.ContentLength)
+
+	if err := d.downloadSpans(ctx, src, state.ContentLength, missing, w, done); err != nil {
+		return err
+	}
+
+	// Only remove the sidecar file once every byte of the file is recorded to have been downloaded.
+
+	if missing := missingSpans(state.Completed, state.ContentLength); len(missing) > 0 {
+		return fmt.Errorf("%d byte range(s) of the file were not downloaded, the first being bytes %s", len(missing), missing[0])
+	}
+
+	if err := w.Sync(); err != nil {
+		return fmt.Errorf("failed to sync file: %w", err)
+	}
+
+	return os.Remove(sidecar)
+}
+
+func readPartialFile(filename string) (partialFile, error) {
+	var state partialFile
+
+	buf, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return state, err
+	}
+
+	if err := json.Unmarshal(buf, &state); err != nil {
+		return state, err
+	}
+
+	if err := state.ID.Valid(); err != nil {
+		return state, err
+	}
+
+	if state.ContentLength == 0 {
+		return state, errors.New("content length is unknown")
+	}
+
+	return state, nil
+}
+
+// writePartialFile atomically replaces the sidecar file at filename with state.
+func writePartialFile(filename string, state partialFile) error {
+	buf, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(filename+".tmp", buf, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(filename+".tmp", filename)
+}
+
+// mergeSpan adds s to a sorted list of disjoint inclusive byte ranges, coalescing adjacent and overlapping ranges.
+func mergeSpan(spans [][2]uint64, s ByteRange) [][2]uint64 {
+	spans = append(spans, [2]uint64{s.Start, s.End})
+
+	sort.Slice(spans, func(i, j int) bool { return spans[i][0] < spans[j][0] })
+
+	merged := spans[:1]
+	for _, r := range spans[1:] {
+		last := &merged[len(merged)-1]
+		if r[0] <= last[1]+1 {
+			if r[1] > last[1] {
+				last[1] = r[1]
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+
+	return merged
+}
+
+// missingSpans returns the byte ranges in [0, size) not covered by a sorted list of disjoint inclusive byte ranges.
+func missingSpans(completed [][2]uint64, size uint64) []ByteRange {
+	var (
+		missing []ByteRange
+		next    uint64
+	)
+
+	for _, r := range completed {
+		if r[0] > next {
+			missing = append(missing, ByteRange{Start: next, End: r[0] - 1})
+		}
+		if r[1]+1 > next {
+			next = r[1] + 1
+		}
+	}
+
+	if next < size {
+		missing = append(missing, ByteRange{Start: next, End: size - 1})
+	}
+
+	return missing
+}
+
+// expiryOfURL returns the time at which a signed URL to a stream, as denoted by its 'expire' query parameter, expires.
+func expiryOfURL(uri string) (time.Time, bool) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	expire, err := strconv.ParseInt(u.Query().Get("expire"), 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return time.Unix(expire, 0), true
+}
+
+// findFormatByITag looks for a format with the given itag amongst the formats of a player.
+func findFormatByITag(p Player, itag uint) (Format, bool) {
+	for _, formats := range [...]Formats{p.SourceFormats(), p.MuxedFormats()} {
+		for _, f := range formats {
+			if f.ITag == itag {
+				return f, true
+			}
+		}
+	}
+	return Format{}, false
+}
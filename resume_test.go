@@ -0,0 +1,145 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyTransport counts the bytes it downloads, and fails all requests after its first limit requests.
+type flakyTransport struct {
+	Transport
+
+	mu    sync.Mutex
+	limit int
+	calls int
+	bytes int
+}
+
+func (t *flakyTransport) DownloadBytesDeadline(dst []byte, url string, deadline time.Time) ([]byte, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.limit >= 0 && t.calls >= t.limit {
+		return dst, errors.New("connection reset by peer")
+	}
+	t.calls++
+
+	n := len(dst)
+	dst, err := t.Transport.DownloadBytesDeadline(dst, url, deadline)
+	t.bytes += len(dst) - n
+
+	return dst, err
+}
+
+func TestDownloadFileResume(t *testing.T) {
+	dir, err := ioutil.TempDir("", "youtube")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "audio.webm")
+
+	data := make([]byte, 256*1024+17)
+	rand.New(rand.NewSource(0)).Read(data)
+
+	uri := "https://example.com/videoplayback?expire=" + strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+
+	transport := &flakyTransport{Transport: rangeTransport{data: data}, limit: 3}
+
+	player := Player{Transport: transport, Streams: Streams{id: "pAsDzfbLM8Y"}}
+	format := Format{ITag: 251, URL: &uri, ContentLength: strconv.Itoa(len(data))}
+
+	d := NewDownloader(transport)
+	d.NumWorkers = 1
+	d.ChunkSize = 32 * 1024
+	d.MaxRetries = 0
+
+	require.Error(t, d.DownloadFile(context.Background(), player, format, filename))
+
+	state, err := readPartialFile(filename + PartialFileSuffix)
+	require.NoError(t, err)
+	require.Equal(t, [][2]uint64{{0, 3*32*1024 - 1}}, state.Completed)
+
+	// Resume the download using nothing but the sidecar file.
+
+	transport.limit, transport.bytes = -1, 0
+
+	require.NoError(t, d.ResumeFile(context.Background(), filename))
+	require.Equal(t, len(data)-3*32*1024, transport.bytes)
+
+	buf, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, data, buf)
+
+	_, err = os.Stat(filename + PartialFileSuffix)
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestResumeFileExpiredURL(t *testing.T) {
+	dir, err := ioutil.TempDir("", "youtube")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "audio.webm")
+
+	response, err := ioutil.ReadFile("testdata/player_response.json")
+	require.NoError(t, err)
+
+	streams, err := NewStreamsFromJSON(response)
+	require.NoError(t, err)
+
+	format, ok := findFormatByITag(Player{Streams: streams}, 249)
+	require.True(t, ok)
+
+	size, err := strconv.Atoi(format.ContentLength)
+	require.NoError(t, err)
+
+	data := make([]byte, size)
+	rand.New(rand.NewSource(0)).Read(data)
+
+	half := uint64(size / 2)
+
+	require.NoError(t, ioutil.WriteFile(filename, append(data[:half:half], make([]byte, uint64(size)-half)...), 0644))
+	require.NoError(t, writePartialFile(filename+PartialFileSuffix, partialFile{
+		ID:            "pAsDzfbLM8Y",
+		ITag:          249,
+		URL:           "https://example.com/videoplayback?expire=1",
+		ContentLength: uint64(size),
+		Completed:     [][2]uint64{{0, half - 1}},
+	}))
+
+	transport := &flakyTransport{
+		Transport: watchTransport{
+			Transport: rangeTransport{data: data},
+			pages:     map[StreamID][]byte{"pAsDzfbLM8Y": newTestWatchPage(t, response)},
+		},
+		limit: -1,
+	}
+
+	d := NewDownloader(transport)
+	d.ChunkSize = 256 * 1024
+
+	require.NoError(t, d.ResumeFile(context.Background(), filename))
+
+	buf, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, data, buf)
+}
+
+func TestMissingSpans(t *testing.T) {
+	completed := mergeSpan(nil, ByteRange{Start: 10, End: 19})
+	completed = mergeSpan(completed, ByteRange{Start: 40, End: 49})
+	completed = mergeSpan(completed, ByteRange{Start: 20, End: 29})
+
+	require.Equal(t, [][2]uint64{{10, 29}, {40, 49}}, completed)
+	require.Equal(t, []ByteRange{{0, 9}, {30, 39}, {50, 99}}, missingSpans(completed, 100))
+	require.Empty(t, missingSpans([][2]uint64{{0, 99}}, 100))
+}
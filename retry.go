@@ -0,0 +1,233 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"github.com/valyala/fasthttp"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// RetryingTransport is a Transport that retries requests which fail with retryable errors using exponential backoff
+// with jitter. It may be wrapped into a Client using WrapClient.
+type RetryingTransport struct {
+	// The transport whose requests are retried.
+	Transport Transport
+
+	// Max number of attempts made at a request, including the first. Values less than one allow for a single attempt.
+	MaxAttempts int
+
+	// Delay before the first retry, which is doubled on every retry after.
+	BaseDelay time.Duration
+
+	// Max delay between two attempts. Delays requested by a server through a 'Retry-After' header are not capped.
+	MaxDelay time.Duration
+
+	// Retryable, if non-nil, decides whether or not a request that failed with err is retried. Defaults to
+	// IsRetryable.
+	Retryable func(err error) bool
+}
+
+// NewRetryingTransport instantiates a new retrying transport on top of t with sane configuration defaults.
+func NewRetryingTransport(t Transport) *RetryingTransport {
+	return &RetryingTransport{
+		Transport: t,
+
+		// Attempt a request 4 times at most.
+		MaxAttempts: 4,
+
+		// Wait 250ms, 500ms, then 1s between attempts.
+		BaseDelay: 250 * time.Millisecond,
+
+		// Never wait longer than 10s between attempts.
+		MaxDelay: 10 * time.Second,
+	}
+}
+
+func (t *RetryingTransport) DownloadBytes(dst []byte, url string) ([]byte, error) {
+	return t.DownloadBytesDeadline(dst, url, zeroTime)
+}
+
+func (t *RetryingTransport) DownloadBytesTimeout(dst []byte, url string, timeout time.Duration) ([]byte, error) {
+	return t.DownloadBytesDeadline(dst, url, time.Now().Add(timeout))
+}
+
+// DownloadBytesDeadline downloads the contents of url, retrying should downloading fail with a retryable error. A
+// request is not retried should the next attempt not be able to start before deadline.
+//
+// Should the underlying transport implement RoundTripper or Doer, such as *nicehttp.Client, the status code of the
+// response is checked, and a *StatusError is returned for responses with a non-2xx status code. Otherwise, it is up
+// to the underlying transport to report such responses as a *StatusError.
+func (t *RetryingTransport) DownloadBytesDeadline(dst []byte, url string, deadline time.Time) ([]byte, error) {
+	n := len(dst)
+
+	download := t.Transport.DownloadBytesDeadline
+
+	switch t.Transport.(type) {
+	case RoundTripper, Doer:
+		rt := AdaptTransport(t.Transport)
+		download = func(dst []byte, url string, deadline time.Time) ([]byte, error) {
+			return roundTripBytesDeadline(rt, dst, url, nil, deadline)
+		}
+	}
+
+	err := t.retry(deadline, func() error {
+		var err error
+		dst, err = download(dst[:n], url, deadline)
+		return err
+	})
+
+	return dst, err
+}
+
+// RoundTripDeadline sends req through the underlying transport adapted using AdaptTransport, retrying should sending
+// req fail with a retryable error, or should its response have a retryable status code. The response to the last
+// attempt made is reported.
+func (t *RetryingTransport) RoundTripDeadline(req *Request, res *Response, deadline time.Time) error {
+	inner := AdaptTransport(t.Transport)
+
+	n := len(res.Body)
+
+	var failedWithStatus bool
+
+	err := t.retry(deadline, func() error {
+		res.Body = res.Body[:n]
+
+		if err := inner.RoundTripDeadline(req, res, deadline); err != nil {
+			failedWithStatus = false
+			return err
+		}
+
+		err := checkStatus(req.URL, res)
+		failedWithStatus = err != nil
+
+		return err
+	})
+
+	if failedWithStatus {
+		return nil
+	}
+
+	return err
+}
+
+// retry calls attempt until it succeeds, fails with an error that is not retryable, or until attempts run out. It
+// gives up early should the next attempt not be able to start before deadline.
+func (t *RetryingTransport) retry(deadline time.Time, attempt func() error) error {
+	retryable := t.Retryable
+	if retryable == nil {
+		retryable = IsRetryable
+	}
+
+	for i := 1; ; i++ {
+		err := attempt()
+		if err == nil {
+			return nil
+		}
+
+		if i >= t.MaxAttempts || !retryable(err) {
+			return err
+		}
+
+		delay := t.delay(i, err)
+		if !deadline.IsZero() && time.Now().Add(delay).After(deadline) {
+			return err
+		}
+
+		if sleepUntil(deadline, delay) != nil {
+			return err
+		}
+	}
+}
+
+// sleepUntil pauses the current goroutine for at least duration d, or until deadline.
+func sleepUntil(deadline time.Time, d time.Duration) error {
+	ctx := context.Background()
+
+	if !deadline.IsZero() {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+
+	return sleep(ctx, d)
+}
+
+// delay returns how long to wait before the next attempt after the given attempt failed with err. A delay requested
+// by the server is honored. Otherwise, the delay is picked at random between half and the whole of the exponential
+// backoff delay.
+func (t *RetryingTransport) delay(attempt int, err error) time.Duration {
+	var status *StatusError
+	if errors.As(err, &status) && status.RetryAfter > 0 {
+		return status.RetryAfter
+	}
+
+	d := t.BaseDelay
+	for i := 1; i < attempt && (t.MaxDelay <= 0 || d < t.MaxDelay); i++ {
+		d *= 2
+	}
+
+	if t.MaxDelay > 0 && d > t.MaxDelay {
+		d = t.MaxDelay
+	}
+
+	if d <= 1 {
+		return d
+	}
+
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// IsRetryable reports whether a request that failed with err may succeed should it be retried. Requests that failed
+// with a 408, 429, or 5xx status code other than 501, that timed out, or whose connection was reset or closed
+// prematurely are retryable.
+func IsRetryable(err error) bool {
+	var status *StatusError
+	if errors.As(err, &status) {
+		switch code := status.StatusCode; {
+		case code == http.StatusRequestTimeout, code == http.StatusTooManyRequests:
+			return true
+		case code == http.StatusNotImplemented:
+			return false
+		default:
+			return code >= 500 && code < 600
+		}
+	}
+
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNABORTED) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, fasthttp.ErrConnectionClosed) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// parseRetryAfter parses the value of a 'Retry-After' header, which is either given in seconds or as an HTTP date,
+// into how long to wait from now. It returns zero if the value is malformed or lies in the past.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.ParseUint(value, 10, 32); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+
+	return 0
+}
@@ -0,0 +1,155 @@
+package youtube
+
+import (
+	"errors"
+	"fmt"
+	"github.com/lithdew/nicehttp"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// scriptedTransport fails requests with the errors it holds in order, and succeeds once it runs out of errors.
+type scriptedTransport struct {
+	mu       sync.Mutex
+	errs     []error
+	attempts int
+}
+
+func (t *scriptedTransport) DownloadBytesDeadline(dst []byte, url string, deadline time.Time) ([]byte, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.attempts++
+
+	if len(t.errs) > 0 {
+		err := t.errs[0]
+		t.errs = t.errs[1:]
+		return append(dst, "partial"...), err
+	}
+
+	return append(dst, "ok"...), nil
+}
+
+func TestRetryingTransport(t *testing.T) {
+	reset := fmt.Errorf("read tcp: %w", syscall.ECONNRESET)
+	unavailable := &StatusError{URL: "https://example.com", StatusCode: http.StatusServiceUnavailable}
+
+	inner := &scriptedTransport{errs: []error{reset, unavailable, io.ErrUnexpectedEOF}}
+
+	transport := NewRetryingTransport(inner)
+	transport.BaseDelay = time.Millisecond
+
+	buf, err := transport.DownloadBytesDeadline([]byte("prefix:"), "https://example.com", zeroTime)
+	require.NoError(t, err)
+	require.Equal(t, "prefix:ok", string(buf))
+	require.Equal(t, 4, inner.attempts)
+
+	// Fatal errors are not retried.
+
+	inner = &scriptedTransport{errs: []error{&StatusError{StatusCode: http.StatusNotFound}}}
+	transport.Transport = inner
+
+	_, err = transport.DownloadBytesDeadline(nil, "https://example.com", zeroTime)
+	require.Error(t, err)
+	require.Equal(t, 1, inner.attempts)
+
+	// Requests are attempted at most MaxAttempts times.
+
+	inner = &scriptedTransport{errs: []error{reset, reset, reset, reset, reset}}
+	transport.Transport = inner
+
+	_, err = transport.DownloadBytesDeadline(nil, "https://example.com", zeroTime)
+	require.True(t, errors.Is(err, syscall.ECONNRESET))
+	require.Equal(t, 4, inner.attempts)
+
+	// Requests are not retried should the delay requested by the server run past the deadline.
+
+	inner = &scriptedTransport{errs: []error{&StatusError{StatusCode: http.StatusTooManyRequests, RetryAfter: time.Minute}}}
+	transport.Transport = inner
+
+	_, err = transport.DownloadBytesTimeout(nil, "https://example.com", time.Second)
+	require.Error(t, err)
+	require.Equal(t, 1, inner.attempts)
+}
+
+func TestRetryingTransportStatus(t *testing.T) {
+	var requests int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && atomic.AddInt32(&requests, 1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte("error page"))
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	// Responses with a retryable status code are retried through transports that ignore status codes.
+
+	client := nicehttp.NewClient()
+
+	transport := NewRetryingTransport(&client)
+	transport.BaseDelay = time.Millisecond
+
+	buf, err := transport.DownloadBytesDeadline([]byte("prefix:"), srv.URL, zeroTime)
+	require.NoError(t, err)
+	require.Equal(t, "prefix:ok", string(buf))
+	require.EqualValues(t, 3, atomic.LoadInt32(&requests))
+
+	// Error pages are never reported as a successful download.
+
+	atomic.StoreInt32(&requests, 0)
+	transport.MaxAttempts = 2
+
+	_, err = transport.DownloadBytesDeadline(nil, srv.URL, zeroTime)
+
+	var status *StatusError
+	require.True(t, errors.As(err, &status))
+	require.Equal(t, http.StatusServiceUnavailable, status.StatusCode)
+}
+
+func TestRetryingTransportDelay(t *testing.T) {
+	transport := RetryingTransport{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	for attempt, max := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		max *= time.Millisecond
+
+		for i := 0; i < 100; i++ {
+			delay := transport.delay(attempt+1, syscall.ECONNRESET)
+			require.True(t, delay >= max/2 && delay <= max, "attempt %d: delay %s", attempt+1, delay)
+		}
+	}
+
+	require.Equal(t, 3*time.Second, transport.delay(1, &StatusError{StatusCode: 503, RetryAfter: 3 * time.Second}))
+}
+
+func TestIsRetryable(t *testing.T) {
+	for _, code := range []int{408, 429, 500, 502, 503, 504} {
+		require.True(t, IsRetryable(fmt.Errorf("wrapped: %w", &StatusError{StatusCode: code})), code)
+	}
+	for _, code := range []int{400, 401, 403, 404, 410, 501} {
+		require.False(t, IsRetryable(&StatusError{StatusCode: code}), code)
+	}
+
+	require.True(t, IsRetryable(fmt.Errorf("write: %w", syscall.EPIPE)))
+	require.True(t, IsRetryable(io.EOF))
+	require.False(t, IsRetryable(errors.New("could not find watch video player config in html page")))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	require.Equal(t, 120*time.Second, parseRetryAfter("120", now))
+	require.Equal(t, 30*time.Second, parseRetryAfter("Fri, 01 May 2020 12:00:30 GMT", now))
+	require.Zero(t, parseRetryAfter("Fri, 01 May 2020 11:59:00 GMT", now))
+	require.Zero(t, parseRetryAfter("soon", now))
+	require.Zero(t, parseRetryAfter("", now))
+}
@@ -0,0 +1,163 @@
+package youtube
+
+import (
+	"errors"
+	"github.com/valyala/fasthttp"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Request is an HTTP request sent through a RoundTripper.
+type Request struct {
+	// HTTP method of the request. Defaults to GET.
+	Method string
+
+	URL    string
+	Header http.Header
+	Body   []byte
+}
+
+// Response is the response to an HTTP request sent through a RoundTripper.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+
+	// Body of the response. A RoundTripper appends the body of the response to Body, which allows for a buffer to be
+	// reused across requests.
+	Body []byte
+}
+
+// RoundTripper is a transport that sends arbitrary HTTP requests, and reports the status and headers of their
+// responses. A Transport may be adapted into a RoundTripper using AdaptTransport.
+type RoundTripper interface {
+	// RoundTripDeadline sends req, and populates res with its response. A response with a non-2xx status code is not
+	// treated as an error.
+	RoundTripDeadline(req *Request, res *Response, deadline time.Time) error
+}
+
+// AdaptTransport adapts t into a RoundTripper. Should t implement RoundTripper, t is returned as-is. Should t
+// implement Doer, such as *nicehttp.Client, requests are sent with their method, headers and body intact.
+//
+// Otherwise, only GET requests without a body may be sent, and the headers of requests are dropped. Responses are
+// then reported to have a 200 status code, or the status code of a *StatusError returned by t.
+func AdaptTransport(t Transport) RoundTripper {
+	switch t := t.(type) {
+	case RoundTripper:
+		return t
+	case Doer:
+		return doerRoundTripper{doer: t}
+	default:
+		return transportRoundTripper{transport: t}
+	}
+}
+
+// doerRoundTripper sends requests through a Doer.
+type doerRoundTripper struct {
+	doer Doer
+}
+
+func (t doerRoundTripper) RoundTripDeadline(req *Request, res *Response, deadline time.Time) error {
+	r := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(r)
+
+	w := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseResponse(w)
+
+	r.SetRequestURI(req.URL)
+
+	if req.Method != "" {
+		r.Header.SetMethod(req.Method)
+	}
+
+	for key, values := range req.Header {
+		for _, value := range values {
+			r.Header.Add(key, value)
+		}
+	}
+
+	if len(req.Body) > 0 {
+		r.SetBody(req.Body)
+	}
+
+	if err := t.doer.DoDeadline(r, w, deadline); err != nil {
+		return err
+	}
+
+	res.StatusCode = w.StatusCode()
+	res.Header = make(http.Header)
+
+	w.Header.VisitAll(func(key, value []byte) {
+		res.Header.Add(string(key), string(value))
+	})
+
+	res.Body = append(res.Body, w.Body()...)
+
+	return nil
+}
+
+// transportRoundTripper sends GET requests through a Transport.
+type transportRoundTripper struct {
+	transport Transport
+}
+
+func (t transportRoundTripper) RoundTripDeadline(req *Request, res *Response, deadline time.Time) error {
+	if (req.Method != "" && req.Method != http.MethodGet) || len(req.Body) > 0 {
+		return errors.New("transport is only able to send get requests without a body")
+	}
+
+	body, err := t.transport.DownloadBytesDeadline(res.Body, req.URL, deadline)
+	if err != nil {
+		var status *StatusError
+		if !errors.As(err, &status) {
+			return err
+		}
+
+		res.StatusCode = status.StatusCode
+		res.Header = make(http.Header)
+
+		if status.RetryAfter > 0 {
+			seconds := (status.RetryAfter + time.Second - 1) / time.Second
+			res.Header.Set("Retry-After", strconv.FormatInt(int64(seconds), 10))
+		}
+
+		return nil
+	}
+
+	res.StatusCode = http.StatusOK
+	res.Header = make(http.Header)
+	res.Body = body
+
+	return nil
+}
+
+// checkStatus returns a *StatusError should res have a non-2xx status code.
+func checkStatus(url string, res *Response) error {
+	if isSuccessStatus(res.StatusCode) {
+		return nil
+	}
+
+	return &StatusError{
+		URL:        url,
+		StatusCode: res.StatusCode,
+		RetryAfter: parseRetryAfter(res.Header.Get("Retry-After"), time.Now()),
+	}
+}
+
+// roundTripBytesDeadline sends a GET request for url through t, and appends the body of its response to dst. It
+// returns a *StatusError should the response have a non-2xx status code.
+func roundTripBytesDeadline(t RoundTripper, dst []byte, url string, header http.Header, deadline time.Time) ([]byte, error) {
+	n := len(dst)
+
+	res := Response{Body: dst}
+
+	if err := t.RoundTripDeadline(&Request{URL: url, Header: header}, &res, deadline); err != nil {
+		return res.Body[:n], err
+	}
+
+	if err := checkStatus(url, &res); err != nil {
+		return res.Body[:n], err
+	}
+
+	return res.Body, nil
+}
@@ -0,0 +1,156 @@
+package youtube
+
+import (
+	"errors"
+	"github.com/lithdew/nicehttp"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func newEchoServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/expired":
+			w.WriteHeader(http.StatusForbidden)
+			return
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "SID", Value: "session", Path: "/"})
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+
+		var cookies []string
+		for _, cookie := range r.Cookies() {
+			cookies = append(cookies, cookie.Name+"="+cookie.Value)
+		}
+
+		w.Header().Set("X-Method", r.Method)
+		w.Header().Set("X-Language", r.Header.Get("Accept-Language"))
+		w.Header().Set("X-Cookies", http.Header{"Cookie": cookies}.Get("Cookie"))
+
+		_, _ = w.Write(body)
+	}))
+}
+
+func TestAdaptTransport(t *testing.T) {
+	srv := newEchoServer()
+	defer srv.Close()
+
+	client := nicehttp.NewClient()
+
+	rt := AdaptTransport(&client)
+
+	req := Request{
+		Method: http.MethodPost,
+		URL:    srv.URL + "/youtubei/v1/player",
+		Header: http.Header{"Accept-Language": {"en-US"}},
+		Body:   []byte(`{"videoId":"pAsDzfbLM8Y"}`),
+	}
+
+	res := Response{Body: []byte("prefix:")}
+
+	require.NoError(t, rt.RoundTripDeadline(&req, &res, zeroTime))
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Equal(t, "POST", res.Header.Get("X-Method"))
+	require.Equal(t, "en-US", res.Header.Get("X-Language"))
+	require.Equal(t, `prefix:{"videoId":"pAsDzfbLM8Y"}`, string(res.Body))
+
+	res = Response{}
+
+	require.NoError(t, rt.RoundTripDeadline(&Request{URL: srv.URL + "/expired"}, &res, zeroTime))
+	require.Equal(t, http.StatusForbidden, res.StatusCode)
+
+	// Transports that only download bytes are limited to GET requests.
+
+	rt = AdaptTransport(&scriptedTransport{errs: []error{&StatusError{StatusCode: 429, RetryAfter: 1500 * time.Millisecond}}})
+
+	require.Error(t, rt.RoundTripDeadline(&req, &Response{}, zeroTime))
+
+	res = Response{}
+
+	require.NoError(t, rt.RoundTripDeadline(&Request{URL: srv.URL}, &res, zeroTime))
+	require.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+	require.Equal(t, "2", res.Header.Get("Retry-After"))
+
+	res = Response{}
+
+	require.NoError(t, rt.RoundTripDeadline(&Request{URL: srv.URL}, &res, zeroTime))
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Equal(t, "ok", string(res.Body))
+
+	// Wrapping transports pass requests through as-is.
+
+	rt = AdaptTransport(&RetryingTransport{Transport: &client})
+
+	res = Response{}
+
+	require.NoError(t, rt.RoundTripDeadline(&req, &res, zeroTime))
+	require.Equal(t, "POST", res.Header.Get("X-Method"))
+}
+
+func TestClientHeadersAndCookies(t *testing.T) {
+	srv := newEchoServer()
+	defer srv.Close()
+
+	transport := nicehttp.NewClient()
+
+	client := WrapClient(&transport)
+	client.Header = http.Header{"Accept-Language": {"en"}}
+	client.AcceptConsent()
+
+	res := Response{}
+
+	require.NoError(t, client.RoundTripDeadline(&Request{URL: srv.URL + "/login"}, &res, zeroTime))
+	require.Equal(t, "en", res.Header.Get("X-Language"))
+
+	// The session cookie set by the server is sent with requests after. The consent cookie is only sent to YouTube.
+
+	res = Response{}
+
+	require.NoError(t, client.RoundTripDeadline(&Request{URL: srv.URL + "/"}, &res, zeroTime))
+	require.Equal(t, "SID=session", res.Header.Get("X-Cookies"))
+
+	_, err := client.DownloadBytesDeadline(nil, srv.URL+"/expired", zeroTime)
+
+	var status *StatusError
+	require.True(t, errors.As(err, &status))
+	require.Equal(t, http.StatusForbidden, status.StatusCode)
+}
+
+func TestClientSnapshot(t *testing.T) {
+	client := WrapClient(&scriptedTransport{})
+	client.Header = http.Header{"Accept-Language": {"en-US"}}
+	client.AcceptConsent()
+
+	snapshot := client.snapshot()
+
+	client.Header.Set("Accept-Language", "de-DE")
+	client.Jar.SetCookies(&url.URL{Scheme: "https", Host: "www.youtube.com"}, []*http.Cookie{{Name: "CONSENT", Value: "NO"}})
+
+	require.Equal(t, "en-US", snapshot.Header.Get("Accept-Language"))
+
+	cookies := snapshot.Jar.Cookies(&url.URL{Scheme: "https", Host: "www.youtube.com", Path: "/watch"})
+	require.Len(t, cookies, 1)
+	require.Equal(t, "YES+", cookies[0].Value)
+
+	bare := WrapClient(&scriptedTransport{})
+	require.Nil(t, bare.snapshot().Jar)
+}
+
+func TestDownloadRangeReportsStatus(t *testing.T) {
+	srv := newEchoServer()
+	defer srv.Close()
+
+	client := nicehttp.NewClient()
+
+	_, err := downloadRangeDeadline(&client, nil, srv.URL+"/expired", ByteRange{Start: 0, End: 9}, time.Now().Add(time.Second))
+
+	var status *StatusError
+	require.True(t, errors.As(err, &status))
+	require.Equal(t, http.StatusForbidden, status.StatusCode)
+}
@@ -0,0 +1,383 @@
+package youtube
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/lithdew/youtube/bmff"
+	"github.com/lithdew/youtube/ebml"
+	"time"
+)
+
+// Segment is an independently-decodable, time-addressable chunk of media within a fragmented MP4 or WebM format.
+type Segment struct {
+	// Presentation time at which the segment starts, and for how long it lasts.
+	Start    time.Duration
+	Duration time.Duration
+
+	// Offset of the segment within the format, and its size in bytes.
+	Offset uint64
+	Size   uint64
+}
+
+// Range returns the byte range of the segment within its format.
+func (s Segment) Range() ByteRange {
+	return ByteRange{Start: s.Offset, End: s.Offset + s.Size - 1}
+}
+
+// LoadInitSegment downloads the init segment of fragmented format f, which is to be prepended to any of the format's
+// media segments to have them be decoded.
+func (p Player) LoadInitSegment(f Format) ([]byte, error) {
+	return p.LoadInitSegmentDeadline(f, zeroTime)
+}
+
+func (p Player) LoadInitSegmentTimeout(f Format, timeout time.Duration) ([]byte, error) {
+	return p.LoadInitSegmentDeadline(f, time.Now().Add(timeout))
+}
+
+func (p Player) LoadInitSegmentDeadline(f Format, deadline time.Time) ([]byte, error) {
+	if f.InitRange == nil {
+		return nil, fmt.Errorf("format with itag %d is not fragmented", f.ITag)
+	}
+
+	url, err := p.ResolveURLDeadline(f, deadline)
+	if err != nil {
+		return nil, err
+	}
+
+	init, err := downloadRangeDeadline(p.Transport, nil, url, *f.InitRange, deadline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download init segment: %w", err)
+	}
+
+	return init, nil
+}
+
+// LoadSegments downloads the init and index segments of fragmented format f, and parses the index segment into the
+// list of media segments that make up the format. It returns the init segment alongside the media segments.
+func (p Player) LoadSegments(f Format) ([]byte, []Segment, error) {
+	return p.LoadSegmentsDeadline(f, zeroTime)
+}
+
+func (p Player) LoadSegmentsTimeout(f Format, timeout time.Duration) ([]byte, []Segment, error) {
+	return p.LoadSegmentsDeadline(f, time.Now().Add(timeout))
+}
+
+func (p Player) LoadSegmentsDeadline(f Format, deadline time.Time) ([]byte, []Segment, error) {
+	if _, err := indexRange(f); err != nil {
+		return nil, nil, err
+	}
+
+	url, err := p.ResolveURLDeadline(f, deadline)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return p.loadSegmentsDeadline(f, url, deadline)
+}
+
+// loadSegmentsDeadline loads the init segment and segment index of format f from url, the already resolved URL to f.
+func (p Player) loadSegmentsDeadline(f Format, url string, deadline time.Time) ([]byte, []Segment, error) {
+	index, err := indexRange(f)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The init and index segments sit next to each other at the start of the format; grab them both at once.
+
+	header, err := downloadRangeDeadline(p.Transport, nil, url, ByteRange{Start: 0, End: index.End}, deadline)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to download init and index segments: %w", err)
+	}
+
+	segments, err := parseSegmentIndex(f, header, index.Start)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return header[:index.Start], segments, nil
+}
+
+// SegmentsWithin returns the contiguous run of segments overlapping the time window [start, end). A zero end denotes
+// the end of the format.
+func SegmentsWithin(segments []Segment, start, end time.Duration) []Segment {
+	i := 0
+	for i < len(segments) && segments[i].Start+segments[i].Duration <= start {
+		i++
+	}
+
+	j := i
+	for j < len(segments) && (end == 0 || segments[j].Start < end) {
+		j++
+	}
+
+	return segments[i:j]
+}
+
+// indexRange returns the byte range of the index segment of fragmented format f. The index segment is expected to
+// immediately follow the init segment, which starts at the beginning of the format.
+func indexRange(f Format) (ByteRange, error) {
+	if f.InitRange == nil || f.IndexRange == nil {
+		return ByteRange{}, fmt.Errorf("format with itag %d is not fragmented", f.ITag)
+	}
+
+	if f.InitRange.Start != 0 || f.IndexRange.Start != f.InitRange.End+1 {
+		return ByteRange{}, errors.New("init and index ranges are not contiguous")
+	}
+
+	return *f.IndexRange, nil
+}
+
+// parseSegmentIndex parses the list of media segments of fragmented format f given header, which holds the format's
+// init segment followed by its index segment starting at offset indexStart.
+func parseSegmentIndex(f Format, header []byte, indexStart uint64) ([]Segment, error) {
+	init, index := header[:indexStart], header[indexStart:]
+
+	var (
+		segments []Segment
+		err      error
+	)
+
+	switch f.parsedMedia().Container {
+	case "mp4":
+		segments, err = ParseSIDX(index, indexStart)
+	case "webm":
+		size := f.Size()
+		if size == 0 {
+			return nil, fmt.Errorf("content length of webm format with itag %d is unknown", f.ITag)
+		}
+
+		segments, err = ParseCues(init, index, size)
+	default:
+		return nil, fmt.Errorf("format with mime type %q is neither mp4 nor webm", f.MIMEType)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse segment index: %w", err)
+	}
+
+	return segments, nil
+}
+
+// ParseSIDX parses a Segment Index box (ISO/IEC 14496-12 8.16.3) located at offset within a fragmented MP4 file into
+// a list of segments.
+func ParseSIDX(buf []byte, offset uint64) ([]Segment, error) {
+	box, n, err := bmff.Parse(buf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse sidx box: %w", err)
+	}
+	if box.Type != "sidx" {
+		return nil, fmt.Errorf("expected box 'sidx', but got %q", box.Type)
+	}
+
+	body := box.Data
+	if len(body) < 12 {
+		return nil, errors.New("sidx box is truncated")
+	}
+
+	version := body[0]
+	timescale := uint64(binary.BigEndian.Uint32(body[8:12]))
+	body = body[12:]
+
+	if timescale == 0 {
+		return nil, errors.New("sidx box has a timescale of zero")
+	}
+
+	var earliest, first uint64
+
+	switch version {
+	case 0:
+		if len(body) < 8 {
+			return nil, errors.New("sidx box is truncated")
+		}
+		earliest = uint64(binary.BigEndian.Uint32(body[0:4]))
+		first = uint64(binary.BigEndian.Uint32(body[4:8]))
+		body = body[8:]
+	default:
+		if len(body) < 16 {
+			return nil, errors.New("sidx box is truncated")
+		}
+		earliest = binary.BigEndian.Uint64(body[0:8])
+		first = binary.BigEndian.Uint64(body[8:16])
+		body = body[16:]
+	}
+
+	if len(body) < 4 {
+		return nil, errors.New("sidx box is truncated")
+	}
+
+	count := int(binary.BigEndian.Uint16(body[2:4]))
+	body = body[4:]
+
+	if len(body) < count*12 {
+		return nil, errors.New("sidx box is truncated")
+	}
+
+	segments := make([]Segment, 0, count)
+
+	pos := offset + uint64(n) + first
+	t := earliest
+
+	for i := 0; i < count; i++ {
+		entry := body[i*12 : i*12+12]
+
+		ref := binary.BigEndian.Uint32(entry[0:4])
+		if ref>>31 != 0 {
+			return nil, errors.New("hierarchical sidx boxes are not supported")
+		}
+
+		length := uint64(ref & 0x7fffffff)
+		duration := uint64(binary.BigEndian.Uint32(entry[4:8]))
+
+		segments = append(segments, Segment{
+			Start:    scaleToDuration(t, timescale),
+			Duration: scaleToDuration(t+duration, timescale) - scaleToDuration(t, timescale),
+			Offset:   pos,
+			Size:     length,
+		})
+
+		pos += length
+		t += duration
+	}
+
+	return segments, nil
+}
+
+// ParseCues parses the Cues element of a WebM file into a list of segments, with each segment spanning a cluster.
+// init holds the initialization segment of the file, starting at its EBML header and containing at least the
+// segment's Info element. size is the total size of the file in bytes.
+func ParseCues(init, index []byte, size uint64) ([]Segment, error) {
+	origin, scale, total, err := parseWebMInfo(init)
+	if err != nil {
+		return nil, err
+	}
+
+	cues, _, err := ebml.Parse(index)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cues: %w", err)
+	}
+	if cues.ID != ebml.IDCues {
+		return nil, fmt.Errorf("expected cues element, but got element %#x", cues.ID)
+	}
+
+	points, err := cues.Children()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cue points: %w", err)
+	}
+
+	var segments []Segment
+
+	for _, point := range points {
+		if point.ID != ebml.IDCuePoint {
+			continue
+		}
+
+		fields, err := point.Children()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cue point: %w", err)
+		}
+
+		var (
+			t   uint64
+			pos uint64
+			ok  bool
+		)
+
+		for _, field := range fields {
+			switch field.ID {
+			case ebml.IDCueTime:
+				t = field.Uint()
+			case ebml.IDCueTrackPositions:
+				positions, err := field.Children()
+				if err != nil {
+					return nil, fmt.Errorf("failed to read cue track positions: %w", err)
+				}
+
+				for _, position := range positions {
+					if position.ID == ebml.IDCueClusterPosition && !ok {
+						pos, ok = position.Uint(), true
+					}
+				}
+			}
+		}
+
+		if !ok || (len(segments) > 0 && origin+pos <= segments[len(segments)-1].Offset) {
+			continue
+		}
+
+		segments = append(segments, Segment{Start: time.Duration(t * scale), Offset: origin + pos})
+	}
+
+	for i := range segments {
+		next, end := size, total
+		if i+1 < len(segments) {
+			next, end = segments[i+1].Offset, segments[i+1].Start
+		}
+
+		if next < segments[i].Offset {
+			return nil, fmt.Errorf("cluster at offset %d lies past the end of the file", segments[i].Offset)
+		}
+
+		segments[i].Size = next - segments[i].Offset
+		if end > segments[i].Start {
+			segments[i].Duration = end - segments[i].Start
+		}
+	}
+
+	return segments, nil
+}
+
+// parseWebMInfo returns the absolute offset of the data of the Segment element of a WebM file, its timecode scale in
+// nanoseconds, and its duration given the file's initialization segment.
+func parseWebMInfo(init []byte) (origin uint64, scale uint64, duration time.Duration, err error) {
+	header, n, err := ebml.Parse(init)
+	if err != nil || header.ID != ebml.IDHeader {
+		return 0, 0, 0, errors.New("init segment does not start with an ebml header")
+	}
+
+	id, _, m, err := ebml.ParseHeader(init[n:])
+	if err != nil || id != ebml.IDSegment {
+		return 0, 0, 0, errors.New("init segment does not contain a segment element")
+	}
+
+	origin = uint64(n + m)
+	init = init[n+m:]
+
+	scale = 1000000
+
+	var rawDuration float64
+
+	// The init segment ends partway through the segment element, so only parse up until the first element that is
+	// truncated.
+
+	for len(init) > 0 {
+		e, n, err := ebml.Parse(init)
+		if err != nil {
+			break
+		}
+		init = init[n:]
+
+		if e.ID != ebml.IDInfo {
+			continue
+		}
+
+		fields, _ := e.Children()
+		for _, field := range fields {
+			switch field.ID {
+			case ebml.IDTimecodeScale:
+				scale = field.Uint()
+			case ebml.IDDuration:
+				rawDuration = field.Float()
+			}
+		}
+
+		break
+	}
+
+	return origin, scale, time.Duration(rawDuration * float64(scale)), nil
+}
+
+// scaleToDuration converts t units of a timescale of the given number of units per second into a duration.
+func scaleToDuration(t, timescale uint64) time.Duration {
+	return time.Duration(t/timescale)*time.Second + time.Duration(t%timescale*uint64(time.Second)/timescale)
+}
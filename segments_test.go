@@ -0,0 +1,59 @@
+package youtube
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/require"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestLoadSegments(t *testing.T) {
+	init := append([]byte("\x00\x00\x00\x10ftypdash\x00\x00\x00\x00"), bytes.Repeat([]byte{'m'}, 24)...)
+	index := appendSIDX(nil, 1000, 0, []uint32{100, 200}, []uint32{5000, 5000})
+
+	data := append(append([]byte{}, init...), index...)
+	data = append(data, bytes.Repeat([]byte{'a'}, 100)...)
+	data = append(data, bytes.Repeat([]byte{'b'}, 200)...)
+
+	uri := "https://example.com/videoplayback?itag=140"
+
+	f := Format{
+		ITag:          140,
+		MIMEType:      `audio/mp4; codecs="mp4a.40.2"`,
+		URL:           &uri,
+		ContentLength: strconv.Itoa(len(data)),
+		InitRange:     &ByteRange{Start: 0, End: uint64(len(init) - 1)},
+		IndexRange:    &ByteRange{Start: uint64(len(init)), End: uint64(len(init) + len(index) - 1)},
+	}
+
+	player := Player{Transport: rangeTransport{data: data}}
+
+	buf, err := player.LoadInitSegment(f)
+	require.NoError(t, err)
+	require.Equal(t, init, buf)
+
+	buf, segments, err := player.LoadSegments(f)
+	require.NoError(t, err)
+	require.Equal(t, init, buf)
+
+	offset := uint64(len(init) + len(index))
+
+	require.Equal(t, []Segment{
+		{Start: 0, Duration: 5 * time.Second, Offset: offset, Size: 100},
+		{Start: 5 * time.Second, Duration: 5 * time.Second, Offset: offset + 100, Size: 200},
+	}, segments)
+
+	require.Equal(t, ByteRange{Start: offset + 100, End: offset + 299}, segments[1].Range())
+	require.Equal(t, segments[1:], SegmentsWithin(segments, 7*time.Second, 0))
+
+	f.IndexRange.Start++
+
+	_, _, err = player.LoadSegments(f)
+	require.Error(t, err)
+
+	f.InitRange, f.IndexRange = nil, nil
+
+	_, err = player.LoadInitSegment(f)
+	require.Error(t, err)
+}
@@ -0,0 +1,430 @@
+package youtube
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Selector picks either a single format, or a pair of video and audio formats to be muxed together, out of a list of
+// formats. Selectors are parsed from expressions such as:
+//
+//	bestvideo[height<=1080][vcodec^=avc1]+bestaudio[ext=m4a]/best
+//
+// An expression is a list of alternatives separated by '/', of which the first alternative that matches is selected.
+// An alternative is either a single format specifier, or a video format specifier and an audio format specifier
+// joined by '+'.
+//
+// A format specifier is one of 'best' (or 'b') and 'worst' (or 'w') for formats with both video and audio,
+// 'bestvideo' ('bv') and 'worstvideo' ('wv') for video-only formats, 'bestaudio' ('ba') and 'worstaudio' ('wa')
+// for audio-only formats, or an itag number. It may be followed by any number of filters of the form [key op value].
+//
+// Numeric keys are itag, width, height, fps, bitrate (bits per second), tbr (kilobits per second), asr (audio sample
+// rate), channels (audio channels), and filesize (bytes). They support the operators =, !=, <, <=, >, and >=, and
+// their values may be suffixed with k, M, or G, or Ki, Mi, or Gi. String keys are ext, vcodec, acodec, and quality.
+// They support the operators = and !=, and ^=, $=, and *= for prefix, suffix, and substring matches. Appending '?'
+// to an operator, as in [height<=?1080], makes the filter also match formats for which the key is unknown.
+type Selector struct {
+	expr         string
+	alternatives [][]formatSpec
+}
+
+// ParseSelector parses a format selector expression. See the documentation of Selector for its syntax.
+func ParseSelector(expr string) (Selector, error) {
+	p := selectorParser{expr: expr}
+
+	alternatives, err := p.parse()
+	if err != nil {
+		return Selector{}, fmt.Errorf("bad format selector %q: %w", expr, err)
+	}
+
+	return Selector{expr: expr, alternatives: alternatives}, nil
+}
+
+// MustParseSelector is like ParseSelector, but panics if expr is not a valid format selector expression.
+func MustParseSelector(expr string) Selector {
+	s, err := ParseSelector(expr)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// String returns the expression the selector was parsed from.
+func (s Selector) String() string {
+	return s.expr
+}
+
+// Select evaluates the selector against formats. It returns either a single format, or a video format followed by
+// an audio format that are to be muxed together. It reports false if none of the selector's alternatives match.
+func (s Selector) Select(formats Formats) (Formats, bool) {
+	for _, alternative := range s.alternatives {
+		if selected, ok := selectAlternative(alternative, formats); ok {
+			return selected, true
+		}
+	}
+	return nil, false
+}
+
+func selectAlternative(alternative []formatSpec, formats Formats) (Formats, bool) {
+	selected := make(Formats, 0, len(alternative))
+
+	for _, spec := range alternative {
+		f, ok := spec.pick(formats)
+		if !ok {
+			return nil, false
+		}
+		selected = append(selected, f)
+	}
+
+	if len(selected) == 2 && (!hasVideo(selected[0]) || !hasAudio(selected[1])) {
+		return nil, false
+	}
+
+	return selected, true
+}
+
+type specKind int
+
+const (
+	specMuxed specKind = iota
+	specVideo
+	specAudio
+	specITag
+)
+
+var formatSpecNames = map[string]struct {
+	kind  specKind
+	worst bool
+}{
+	"best":       {kind: specMuxed},
+	"b":          {kind: specMuxed},
+	"worst":      {kind: specMuxed, worst: true},
+	"w":          {kind: specMuxed, worst: true},
+	"bestvideo":  {kind: specVideo},
+	"bv":         {kind: specVideo},
+	"worstvideo": {kind: specVideo, worst: true},
+	"wv":         {kind: specVideo, worst: true},
+	"bestaudio":  {kind: specAudio},
+	"ba":         {kind: specAudio},
+	"worstaudio": {kind: specAudio, worst: true},
+	"wa":         {kind: specAudio, worst: true},
+}
+
+// formatSpec is a single format specifier, such as bestvideo[height<=1080].
+type formatSpec struct {
+	kind    specKind
+	worst   bool
+	itag    uint
+	filters []formatFilter
+}
+
+func (s formatSpec) matches(f Format) bool {
+	switch s.kind {
+	case specMuxed:
+		if !hasVideo(f) || !hasAudio(f) {
+			return false
+		}
+	case specVideo:
+		if !hasVideo(f) || hasAudio(f) {
+			return false
+		}
+	case specAudio:
+		if hasVideo(f) || !hasAudio(f) {
+			return false
+		}
+	case specITag:
+		if f.ITag != s.itag {
+			return false
+		}
+	}
+
+	for _, filter := range s.filters {
+		if !filter.matches(f) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// pick returns the best, or worst, format out of formats that matches the specifier. Audio-only formats are ranked by
+// AudioRanking, and all other formats by VideoRanking.
+func (s formatSpec) pick(formats Formats) (Format, bool) {
+	matched := formats.Where(s.matches)
+
+	ranking := VideoRanking
+	if s.kind == specAudio {
+		ranking = AudioRanking
+	}
+
+	if s.worst {
+		return ranking.Worst(matched)
+	}
+
+	return ranking.Best(matched)
+}
+
+var (
+	numericFilterKeys = map[string]func(f Format) uint64{
+		"itag":     func(f Format) uint64 { return uint64(f.ITag) },
+		"width":    func(f Format) uint64 { return uint64(f.Width) },
+		"height":   func(f Format) uint64 { return uint64(f.Height) },
+		"fps":      formatFPS,
+		"bitrate":  func(f Format) uint64 { return uint64(f.Bitrate) },
+		"tbr":      func(f Format) uint64 { return uint64(f.Bitrate) / 1000 },
+		"asr":      func(f Format) uint64 { return uint64(f.SampleRate()) },
+		"channels": formatChannels,
+		"filesize": Format.Size,
+	}
+
+	stringFilterKeys = map[string]func(f Format) string{
+		"ext":     Format.FileExtension,
+		"vcodec":  func(f Format) string { v, _ := formatCodecs(f); return v },
+		"acodec":  func(f Format) string { _, a := formatCodecs(f); return a },
+		"quality": func(f Format) string { return f.Quality },
+	}
+)
+
+// filterOps lists filter operators, longest first so that they may be matched greedily.
+var filterOps = []string{"<=", ">=", "!=", "^=", "$=", "*=", "=", "<", ">"}
+
+// formatFilter is a single filter of a format specifier, such as [height<=1080].
+type formatFilter struct {
+	key      string
+	op       string
+	optional bool
+
+	str string
+	num uint64
+}
+
+func (f formatFilter) matches(format Format) bool {
+	if fn, ok := numericFilterKeys[f.key]; ok {
+		v := fn(format)
+		if v == 0 && f.key != "itag" {
+			return f.optional
+		}
+
+		switch f.op {
+		case "=":
+			return v == f.num
+		case "!=":
+			return v != f.num
+		case "<":
+			return v < f.num
+		case "<=":
+			return v <= f.num
+		case ">":
+			return v > f.num
+		default:
+			return v >= f.num
+		}
+	}
+
+	v := stringFilterKeys[f.key](format)
+	if v == "" {
+		return f.optional
+	}
+
+	switch f.op {
+	case "=":
+		return v == f.str
+	case "!=":
+		return v != f.str
+	case "^=":
+		return strings.HasPrefix(v, f.str)
+	case "$=":
+		return strings.HasSuffix(v, f.str)
+	default:
+		return strings.Contains(v, f.str)
+	}
+}
+
+type selectorParser struct {
+	expr string
+	pos  int
+}
+
+func (p *selectorParser) parse() ([][]formatSpec, error) {
+	var alternatives [][]formatSpec
+
+	for {
+		var alternative []formatSpec
+
+		for {
+			spec, err := p.parseSpec()
+			if err != nil {
+				return nil, err
+			}
+
+			alternative = append(alternative, spec)
+
+			if !p.consume("+") {
+				break
+			}
+
+			if len(alternative) == 2 {
+				return nil, fmt.Errorf("at most two formats may be merged at offset %d", p.pos-1)
+			}
+		}
+
+		alternatives = append(alternatives, alternative)
+
+		if p.pos == len(p.expr) {
+			return alternatives, nil
+		}
+
+		if !p.consume("/") {
+			return nil, fmt.Errorf("unexpected %q at offset %d", p.expr[p.pos], p.pos)
+		}
+	}
+}
+
+func (p *selectorParser) parseSpec() (formatSpec, error) {
+	var spec formatSpec
+
+	start := p.pos
+	for p.pos < len(p.expr) && isSelectorNameChar(p.expr[p.pos]) {
+		p.pos++
+	}
+
+	name := p.expr[start:p.pos]
+
+	if s, ok := formatSpecNames[name]; ok {
+		spec.kind, spec.worst = s.kind, s.worst
+	} else if itag, err := strconv.ParseUint(name, 10, 32); err == nil {
+		spec.kind, spec.itag = specITag, uint(itag)
+	} else if name == "" {
+		return spec, fmt.Errorf("expected format specifier at offset %d", start)
+	} else {
+		return spec, fmt.Errorf("unknown format specifier %q at offset %d", name, start)
+	}
+
+	for p.consume("[") {
+		filter, err := p.parseFilter()
+		if err != nil {
+			return spec, err
+		}
+		spec.filters = append(spec.filters, filter)
+	}
+
+	return spec, nil
+}
+
+func (p *selectorParser) parseFilter() (formatFilter, error) {
+	var filter formatFilter
+
+	start := p.pos
+	for p.pos < len(p.expr) && isSelectorNameChar(p.expr[p.pos]) {
+		p.pos++
+	}
+
+	filter.key = p.expr[start:p.pos]
+
+	_, numeric := numericFilterKeys[filter.key]
+	if _, ok := stringFilterKeys[filter.key]; !ok && !numeric {
+		return filter, fmt.Errorf("unknown filter key %q at offset %d", filter.key, start)
+	}
+
+	for _, op := range filterOps {
+		if p.consume(op) {
+			filter.op = op
+			break
+		}
+	}
+
+	switch filter.op {
+	case "":
+		return filter, fmt.Errorf("expected filter operator at offset %d", p.pos)
+	case "^=", "$=", "*=":
+		if numeric {
+			return filter, fmt.Errorf("operator %q is not supported by numeric key %q", filter.op, filter.key)
+		}
+	case "<", "<=", ">", ">=":
+		if !numeric {
+			return filter, fmt.Errorf("operator %q is not supported by string key %q", filter.op, filter.key)
+		}
+	}
+
+	filter.optional = p.consume("?")
+
+	end := strings.IndexByte(p.expr[p.pos:], ']')
+	if end < 0 {
+		return filter, fmt.Errorf("unterminated filter at offset %d", start-1)
+	}
+
+	value := p.expr[p.pos : p.pos+end]
+	p.pos += end + 1
+
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		value = value[1 : len(value)-1]
+	}
+
+	if !numeric {
+		filter.str = value
+		return filter, nil
+	}
+
+	num, err := parseQuantity(value)
+	if err != nil {
+		return filter, fmt.Errorf("bad value for key %q: %w", filter.key, err)
+	}
+
+	filter.num = num
+
+	return filter, nil
+}
+
+func (p *selectorParser) consume(token string) bool {
+	if strings.HasPrefix(p.expr[p.pos:], token) {
+		p.pos += len(token)
+		return true
+	}
+	return false
+}
+
+func isSelectorNameChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_'
+}
+
+var quantitySuffixes = []struct {
+	suffix     string
+	multiplier float64
+}{
+	{"Ki", 1 << 10}, {"Mi", 1 << 20}, {"Gi", 1 << 30},
+	{"k", 1e3}, {"K", 1e3}, {"M", 1e6}, {"G", 1e9},
+}
+
+// parseQuantity parses a non-negative number optionally suffixed with a decimal or binary multiplier, such as 1.5M.
+func parseQuantity(s string) (uint64, error) {
+	multiplier := 1.0
+
+	for _, q := range quantitySuffixes {
+		if strings.HasSuffix(s, q.suffix) {
+			s, multiplier = strings.TrimSuffix(s, q.suffix), q.multiplier
+			break
+		}
+	}
+
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("%q is not a non-negative number", s)
+	}
+
+	return uint64(v * multiplier), nil
+}
+
+func formatFPS(f Format) uint64 {
+	if f.FPS == nil {
+		return 0
+	}
+	return uint64(*f.FPS)
+}
+
+func formatChannels(f Format) uint64 {
+	if f.AudioChannels == nil {
+		return 0
+	}
+	return uint64(*f.AudioChannels)
+}
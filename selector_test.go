@@ -0,0 +1,70 @@
+package youtube
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSelectorSelect(t *testing.T) {
+	formats := loadTestStreams(t).Formats()
+
+	cases := []struct {
+		expr  string
+		itags []uint
+	}{
+		{expr: "best", itags: []uint{22}},
+		{expr: "worst", itags: []uint{18}},
+		{expr: "bestvideo", itags: []uint{337}},
+		{expr: "worstvideo", itags: []uint{135}},
+		{expr: "bestaudio", itags: []uint{251}},
+		{expr: "worstaudio", itags: []uint{249}},
+		{expr: "bestaudio[ext=m4a]", itags: []uint{140}},
+		{expr: "ba[acodec=opus][tbr<100]", itags: []uint{250}},
+		{expr: "bv[height<=1080][vcodec^=avc1]+ba[ext=m4a]/best", itags: []uint{137, 140}},
+		{expr: "bv[height<=1080][fps>30]+ba/best", itags: []uint{302, 251}},
+		{expr: "bv[vcodec^=avc1][height>1080]+ba/bv[vcodec=vp9][height>=1080]+ba", itags: []uint{248, 251}},
+		{expr: "bv[vcodec*=av01]", itags: []uint{399}},
+		{expr: "bv[ext!=webm][height<720]", itags: []uint{135}},
+		{expr: "bv[filesize<1Ki]/bv[height=4320]/b[quality=medium]", itags: []uint{18}},
+		{expr: "ba[asr>=?48k]", itags: []uint{251}},
+		{expr: "140", itags: []uint{140}},
+		{expr: "137+251", itags: []uint{137, 251}},
+	}
+
+	for _, c := range cases {
+		selected, ok := MustParseSelector(c.expr).Select(formats)
+		require.True(t, ok, c.expr)
+		require.Equal(t, c.itags, itagsOf(selected), c.expr)
+	}
+
+	for _, expr := range []string{"bv[height>4320]", "ba+bv", "999", "bv[vcodec=h264]/ba[channels>6]"} {
+		_, ok := MustParseSelector(expr).Select(formats)
+		require.False(t, ok, expr)
+	}
+
+	require.Len(t, formats, 14)
+	require.EqualValues(t, 18, formats[0].ITag)
+}
+
+func TestParseSelectorInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"best/",
+		"bestest",
+		"bv+ba+ba",
+		"bv[height<=1080",
+		"bv[resolution=1080]",
+		"bv[height^=1]",
+		"bv[ext>mp4]",
+		"bv[height<=big]",
+		"bv[height]",
+		"bv)",
+	} {
+		_, err := ParseSelector(expr)
+		require.Error(t, err, expr)
+	}
+
+	s, err := ParseSelector("bestvideo[height<=1080]+bestaudio/best")
+	require.NoError(t, err)
+	require.Equal(t, "bestvideo[height<=1080]+bestaudio/best", s.String())
+}
@@ -0,0 +1,126 @@
+package youtube
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// Open opens format f for sequential reading using a downloader with default settings on top of the player's
+// transport.
+func (p Player) Open(ctx context.Context, f Format) (io.Reader, error) {
+	d := NewDownloader(p.Transport)
+	return d.Open(ctx, p, f)
+}
+
+// Open opens format f for sequential reading. Bytes are downloaded lazily as they are read: fragmented MP4 and WebM
+// formats are downloaded one media segment at a time as laid out by their init and index ranges, while all other
+// formats are downloaded in chunks of d.ChunkSize bytes. Reads fail once ctx is done.
+//
+// Only a single segment or chunk is ever held in memory, which allows for a format to be processed while it is being
+// downloaded.
+func (d *Downloader) Open(ctx context.Context, p Player, f Format) (io.Reader, error) {
+	deadline, _ := ctx.Deadline()
+
+	url, err := p.ResolveURLDeadline(f, deadline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve url of format with itag %d: %w", f.ITag, err)
+	}
+
+	size := f.Size()
+	if size == 0 {
+		return nil, fmt.Errorf("content length of format with itag %d is unknown", f.ITag)
+	}
+
+	r := &formatReader{ctx: ctx, d: d, src: newStreamURL(p.reloader(d.Transport), p.ID(), f.ITag, size, url, p.ExpiresAt())}
+
+	if f.InitRange == nil || f.IndexRange == nil {
+		chunkSize := uint64(d.ChunkSize)
+		if chunkSize == 0 {
+			chunkSize = size
+		}
+
+		for start := uint64(0); start < size; start += chunkSize {
+			end := start + chunkSize - 1
+			if end >= size {
+				end = size - 1
+			}
+			r.spans = append(r.spans, ByteRange{Start: start, End: end})
+		}
+
+		return r, nil
+	}
+
+	index, err := indexRange(f)
+	if err != nil {
+		return nil, err
+	}
+
+	// The init and index segments are downloaded upfront in order to know where each media segment lies. They are
+	// the first bytes to be read.
+
+	r.buf, err = d.downloadChunk(ctx, nil, r.src, ByteRange{Start: 0, End: index.End})
+	if err != nil {
+		return nil, fmt.Errorf("failed to download init and index segments: %w", err)
+	}
+
+	segments, err := parseSegmentIndex(f, r.buf, index.Start)
+	if err != nil {
+		return nil, err
+	}
+
+	offset := index.End + 1
+
+	for _, s := range segments {
+		if s.Offset != offset || s.Size == 0 {
+			return nil, fmt.Errorf("segment at offset %d does not immediately follow offset %d", s.Offset, offset)
+		}
+		r.spans = append(r.spans, s.Range())
+		offset += s.Size
+	}
+
+	if offset < size {
+		r.spans = append(r.spans, ByteRange{Start: offset, End: size - 1})
+	}
+
+	return r, nil
+}
+
+// formatReader reads the contents of a format one byte range at a time.
+type formatReader struct {
+	ctx   context.Context
+	d     *Downloader
+	src   *streamURL
+	spans []ByteRange
+	buf   []byte
+	pos   int
+	err   error
+}
+
+func (r *formatReader) Read(b []byte) (int, error) {
+	for r.pos == len(r.buf) {
+		if r.err != nil {
+			return 0, r.err
+		}
+
+		if len(r.spans) == 0 {
+			return 0, io.EOF
+		}
+
+		s := r.spans[0]
+		r.spans = r.spans[1:]
+
+		r.buf, r.err = r.d.downloadChunk(r.ctx, r.buf[:0], r.src, s)
+		r.pos = 0
+
+		if r.err != nil {
+			r.err = fmt.Errorf("failed to download bytes %s: %w", s, r.err)
+			r.buf = r.buf[:0]
+		}
+	}
+
+	n := copy(b, r.buf[r.pos:])
+	r.pos += n
+
+	return n, nil
+}
@@ -0,0 +1,87 @@
+package youtube
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// recordingTransport records the 'range' query parameter of every request made through it.
+type recordingTransport struct {
+	Transport
+	ranges *[]string
+}
+
+func (t recordingTransport) DownloadBytesDeadline(dst []byte, uri string, deadline time.Time) ([]byte, error) {
+	if u, err := url.Parse(uri); err == nil {
+		*t.ranges = append(*t.ranges, u.Query().Get("range"))
+	}
+	return t.Transport.DownloadBytesDeadline(dst, uri, deadline)
+}
+
+func TestOpen(t *testing.T) {
+	init := append([]byte("\x00\x00\x00\x10ftypdash\x00\x00\x00\x00"), bytes.Repeat([]byte{'m'}, 24)...)
+
+	sizes := []uint32{100, 200, 300}
+	index := appendSIDX(nil, 1000, 0, sizes, []uint32{10000, 10000, 10000})
+
+	data := append(append([]byte{}, init...), index...)
+	for i, size := range sizes {
+		data = append(data, bytes.Repeat([]byte{byte('a' + i)}, int(size))...)
+	}
+
+	uri := "https://example.com/videoplayback?itag=140"
+
+	f := Format{
+		ITag:          140,
+		MIMEType:      `audio/mp4; codecs="mp4a.40.2"`,
+		URL:           &uri,
+		ContentLength: strconv.Itoa(len(data)),
+		InitRange:     &ByteRange{Start: 0, End: uint64(len(init) - 1)},
+		IndexRange:    &ByteRange{Start: uint64(len(init)), End: uint64(len(init) + len(index) - 1)},
+	}
+
+	var ranges []string
+
+	player := Player{Transport: recordingTransport{Transport: rangeTransport{data: data}, ranges: &ranges}}
+
+	r, err := player.Open(context.Background(), f)
+	require.NoError(t, err)
+
+	buf, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, data, buf)
+
+	// The init and index segments are downloaded at once, followed by one request per media segment.
+
+	header := len(init) + len(index)
+
+	require.Equal(t, []string{
+		"0-" + strconv.Itoa(header-1),
+		strconv.Itoa(header) + "-" + strconv.Itoa(header+99),
+		strconv.Itoa(header+100) + "-" + strconv.Itoa(header+299),
+		strconv.Itoa(header+300) + "-" + strconv.Itoa(header+599),
+	}, ranges)
+
+	// Formats that are not fragmented are downloaded in chunks.
+
+	ranges = ranges[:0]
+
+	f.InitRange, f.IndexRange = nil, nil
+
+	d := NewDownloader(player.Transport)
+	d.ChunkSize = 256
+
+	r, err = d.Open(context.Background(), player, f)
+	require.NoError(t, err)
+
+	buf, err = ioutil.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, data, buf)
+	require.Len(t, ranges, (len(data)+255)/256)
+}
@@ -1,7 +1,11 @@
 package youtube
 
 import (
+	"errors"
+	"fmt"
 	"github.com/valyala/fastjson"
+	"strconv"
+	"time"
 )
 
 type Streams struct {
@@ -9,6 +13,51 @@ type Streams struct {
 	v  *fastjson.Value
 }
 
+// NewStreamsFromJSON parses a raw player response, such as the one previously returned by Streams.MarshalJSON, into
+// streaming info.
+func NewStreamsFromJSON(buf []byte) (Streams, error) {
+	if len(buf) == 0 {
+		return Streams{}, errors.New("player response is empty")
+	}
+
+	val, err := fastjson.ParseBytes(buf)
+	if err != nil {
+		return Streams{}, fmt.Errorf("failed to parse json response: %w", err)
+	}
+
+	if val.Type() == fastjson.TypeNull {
+		return Streams{}, nil
+	}
+
+	return newStreams(StreamID(val.GetStringBytes("videoDetails", "videoId")), val), nil
+}
+
+// newStreams wraps the parsed player response v. fastjson lazily unescapes object keys on lookup, so all keys are
+// unescaped upfront to allow for streaming info to be queried from multiple goroutines.
+func newStreams(id StreamID, v *fastjson.Value) Streams {
+	unescapeKeys(v)
+	return Streams{id: id, v: v}
+}
+
+func unescapeKeys(v *fastjson.Value) {
+	switch v.Type() {
+	case fastjson.TypeObject:
+		v.GetObject().Visit(func(_ []byte, v *fastjson.Value) { unescapeKeys(v) })
+	case fastjson.TypeArray:
+		for _, item := range v.GetArray() {
+			unescapeKeys(item)
+		}
+	}
+}
+
+// MarshalJSON returns the raw player response the streaming info was parsed from.
+func (s Streams) MarshalJSON() ([]byte, error) {
+	if s.v == nil {
+		return []byte("null"), nil
+	}
+	return s.v.MarshalTo(nil), nil
+}
+
 // ID returns the unique ID pertaining to this stream.
 func (s Streams) ID() StreamID {
 	return s.id
@@ -44,6 +93,11 @@ func (s Streams) MuxedFormats() Formats {
 	return formats
 }
 
+// Formats returns both premuxed and source streaming formats, which may be narrowed down using a Selector.
+func (s Streams) Formats() Formats {
+	return append(s.MuxedFormats(), s.SourceFormats()...)
+}
+
 func (s Streams) Title() string {
 	return string(s.v.GetStringBytes("videoDetails", "title"))
 }
@@ -98,3 +152,68 @@ func (s Streams) PlayableInEmbed() bool {
 func (s Streams) ExpiresInSeconds() string {
 	return string(s.v.GetStringBytes("streamingData", "expiresInSeconds"))
 }
+
+// ExpiresIn returns how long the URLs to the stream's formats remain valid for after the stream was loaded. It
+// returns zero if unknown.
+func (s Streams) ExpiresIn() time.Duration {
+	seconds, err := strconv.ParseUint(s.ExpiresInSeconds(), 10, 32)
+	if err != nil {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// Thumbnail is a thumbnail image of a stream.
+type Thumbnail struct {
+	URL    string `json:"url"`
+	Width  uint   `json:"width"`
+	Height uint   `json:"height"`
+}
+
+func ParseThumbnailJSON(v *fastjson.Value) Thumbnail {
+	return Thumbnail{
+		URL:    string(v.GetStringBytes("url")),
+		Width:  v.GetUint("width"),
+		Height: v.GetUint("height"),
+	}
+}
+
+// Thumbnails returns all thumbnails of the stream.
+func (s Streams) Thumbnails() []Thumbnail {
+	vals := s.v.GetArray("videoDetails", "thumbnail", "thumbnails")
+	if len(vals) == 0 {
+		vals = s.v.GetArray("microformat", "playerMicroformatRenderer", "thumbnail", "thumbnails")
+	}
+
+	results := make([]Thumbnail, 0, len(vals))
+	for _, v := range vals {
+		results = append(results, ParseThumbnailJSON(v))
+	}
+
+	return results
+}
+
+// BestThumbnail returns the thumbnail of the stream with the highest resolution.
+func (s Streams) BestThumbnail() (Thumbnail, bool) {
+	var (
+		best Thumbnail
+		ok   bool
+	)
+
+	for _, t := range s.Thumbnails() {
+		if !ok || t.Width*t.Height > best.Width*best.Height {
+			best, ok = t, true
+		}
+	}
+
+	return best, ok
+}
+
+// PublishDate returns the date the stream was published on. It returns the zero time if unknown.
+func (s Streams) PublishDate() time.Time {
+	date, err := time.Parse("2006-01-02", string(s.v.GetStringBytes("microformat", "playerMicroformatRenderer", "publishDate")))
+	if err != nil {
+		return time.Time{}
+	}
+	return date
+}
@@ -0,0 +1,23 @@
+package youtube
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestStreamsThumbnails(t *testing.T) {
+	streams := loadTestStreams(t)
+
+	require.Len(t, streams.Thumbnails(), 4)
+
+	best, ok := streams.BestThumbnail()
+	require.True(t, ok)
+	require.Equal(t, Thumbnail{URL: "https://i.ytimg.com/vi/pAsDzfbLM8Y/maxresdefault.jpg", Width: 1920, Height: 1080}, best)
+
+	require.Equal(t, time.Date(2010, 6, 17, 0, 0, 0, 0, time.UTC), streams.PublishDate())
+
+	_, ok = Streams{}.BestThumbnail()
+	require.False(t, ok)
+	require.True(t, Streams{}.PublishDate().IsZero())
+}
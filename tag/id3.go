@@ -0,0 +1,76 @@
+package tag
+
+import "errors"
+
+// ID3 encodes tags t as an ID3v2.4 tag.
+func ID3(t Tags) []byte {
+	var frames []byte
+
+	for _, frame := range []struct {
+		id, value string
+	}{
+		{"TIT2", t.Title},
+		{"TPE1", t.Artist},
+		{"TALB", t.Album},
+		{"TDRC", t.date()},
+	} {
+		if frame.value != "" {
+			frames = appendID3Frame(frames, frame.id, append([]byte{0x03}, frame.value...))
+		}
+	}
+
+	// URL link frames are always encoded as ISO-8859-1.
+
+	if t.URL != "" {
+		frames = appendID3Frame(frames, "WOAS", []byte(t.URL))
+	}
+
+	if len(t.Cover) > 0 {
+		mime, _, _ := t.cover()
+
+		data := append([]byte{0x03}, mime...)
+		data = append(data, 0, 0x03, 0)
+		data = append(data, t.Cover...)
+
+		frames = appendID3Frame(frames, "APIC", data)
+	}
+
+	buf := []byte{'I', 'D', '3', 0x04, 0x00, 0x00}
+	buf = appendSynchsafe(buf, uint32(len(frames)))
+
+	return append(buf, frames...)
+}
+
+// applyID3 prepends an ID3v2.4 tag to the MP3 file buf, replacing any ID3v2 tag at the start of the file.
+func applyID3(buf []byte, t Tags) ([]byte, error) {
+	if len(buf) >= 10 && string(buf[:3]) == "ID3" {
+		size := 10 + int(readSynchsafe(buf[6:10]))
+		if buf[5]&0x10 != 0 {
+			size += 10
+		}
+
+		if size > len(buf) {
+			return nil, errors.New("id3 tag is truncated")
+		}
+
+		buf = buf[size:]
+	}
+
+	return append(ID3(t), buf...), nil
+}
+
+func appendID3Frame(dst []byte, id string, data []byte) []byte {
+	dst = append(dst, id...)
+	dst = appendSynchsafe(dst, uint32(len(data)))
+	dst = append(dst, 0, 0)
+	return append(dst, data...)
+}
+
+// appendSynchsafe appends v as a 28-bit synchsafe integer, whose bytes each have their most significant bit unset.
+func appendSynchsafe(dst []byte, v uint32) []byte {
+	return append(dst, byte(v>>21&0x7f), byte(v>>14&0x7f), byte(v>>7&0x7f), byte(v&0x7f))
+}
+
+func readSynchsafe(buf []byte) uint32 {
+	return uint32(buf[0]&0x7f)<<21 | uint32(buf[1]&0x7f)<<14 | uint32(buf[2]&0x7f)<<7 | uint32(buf[3]&0x7f)
+}
@@ -0,0 +1,172 @@
+package tag
+
+import (
+	"encoding/binary"
+	"errors"
+	"github.com/lithdew/youtube/bmff"
+	"math"
+)
+
+// ILST encodes tags t as an iTunes-style 'meta' box holding an 'ilst' box, which belongs in the 'udta' box of a
+// 'moov' box.
+func ILST(t Tags) *bmff.Box {
+	ilst := bmff.New("ilst", nil)
+
+	item := func(typ string, kind uint32, value []byte) {
+		data := make([]byte, 8, 8+len(value))
+		binary.BigEndian.PutUint32(data[0:4], kind)
+		data = append(data, value...)
+
+		ilst.Children = append(ilst.Children, bmff.New(typ, nil, bmff.New("data", data)))
+	}
+
+	for _, field := range []struct {
+		typ, value string
+	}{
+		{"\xa9nam", t.Title},
+		{"\xa9ART", t.Artist},
+		{"\xa9alb", t.Album},
+		{"\xa9day", t.date()},
+		{"\xa9cmt", t.URL},
+	} {
+		if field.value != "" {
+			item(field.typ, 1, []byte(field.value))
+		}
+	}
+
+	if len(t.Cover) > 0 {
+		kind := uint32(13)
+		if mime, _, _ := t.cover(); mime == "image/png" {
+			kind = 14
+		}
+		item("covr", kind, t.Cover)
+	}
+
+	hdlr := []byte("\x00\x00\x00\x00\x00\x00\x00\x00mdirappl\x00\x00\x00\x00\x00\x00\x00\x00\x00")
+
+	return bmff.New("meta", []byte{0, 0, 0, 0}, bmff.New("hdlr", hdlr), ilst)
+}
+
+// mp4Box is a top-level box of an MP4 file alongside its position within the file.
+type mp4Box struct {
+	box *bmff.Box
+
+	start, payload, end  uint64 // Positions before tags are written.
+	newStart, newPayload uint64 // Positions after tags are written.
+}
+
+// applyMP4 writes tags t into the 'moov' box of the MP4 file buf. All absolute offsets into media data held by
+// chunk offset boxes and track fragment headers are updated to account for boxes having moved.
+func applyMP4(buf []byte, t Tags) ([]byte, error) {
+	var (
+		boxes []mp4Box
+		moov  *bmff.Box
+	)
+
+	for offset := 0; offset < len(buf); {
+		box, n, err := bmff.Parse(buf[offset:])
+		if err != nil {
+			return nil, err
+		}
+
+		header := uint64(n - len(box.Data))
+		if len(box.Children) > 0 {
+			header = bmff.HeaderSize(uint64(n))
+		}
+
+		boxes = append(boxes, mp4Box{
+			box:     box,
+			start:   uint64(offset),
+			payload: uint64(offset) + header,
+			end:     uint64(offset + n),
+		})
+
+		if box.Type == "moov" && moov == nil {
+			moov = box
+		}
+
+		offset += n
+	}
+
+	if moov == nil {
+		return nil, errors.New("no moov box found")
+	}
+
+	udta := moov.Child("udta")
+	if udta == nil {
+		udta = bmff.New("udta", nil)
+		moov.Children = append(moov.Children, udta)
+	}
+
+	udta.Remove("meta")
+	udta.Children = append(udta.Children, ILST(t))
+
+	var position uint64
+
+	for i := range boxes {
+		size := boxes[i].box.Size()
+
+		boxes[i].newStart = position
+		boxes[i].newPayload = position + bmff.HeaderSize(size)
+
+		position += size
+	}
+
+	remap := func(offset uint64) uint64 {
+		for _, b := range boxes {
+			switch {
+			case offset < b.start || offset >= b.end:
+				continue
+			case offset >= b.payload:
+				return offset - b.payload + b.newPayload
+			default:
+				return offset - b.start + b.newStart
+			}
+		}
+		return offset
+	}
+
+	for _, b := range boxes {
+		switch b.box.Type {
+		case "moov":
+			for _, trak := range b.box.All("trak") {
+				stbl := trak.Find("mdia", "minf", "stbl")
+				if stbl == nil {
+					continue
+				}
+
+				if stco := stbl.Child("stco"); stco != nil {
+					stco.Data = append([]byte{}, stco.Data...)
+					for i := 8; i+4 <= len(stco.Data); i += 4 {
+						offset := remap(uint64(binary.BigEndian.Uint32(stco.Data[i:])))
+						if offset > math.MaxUint32 {
+							return nil, errors.New("chunk offset no longer fits into a stco box")
+						}
+						binary.BigEndian.PutUint32(stco.Data[i:], uint32(offset))
+					}
+				}
+
+				if co64 := stbl.Child("co64"); co64 != nil {
+					co64.Data = append([]byte{}, co64.Data...)
+					for i := 8; i+8 <= len(co64.Data); i += 8 {
+						binary.BigEndian.PutUint64(co64.Data[i:], remap(binary.BigEndian.Uint64(co64.Data[i:])))
+					}
+				}
+			}
+		case "moof":
+			for _, traf := range b.box.All("traf") {
+				if tfhd := traf.Child("tfhd"); tfhd != nil && tfhd.Flags()&0x1 != 0 && len(tfhd.Data) >= 16 {
+					tfhd.Data = append([]byte{}, tfhd.Data...)
+					binary.BigEndian.PutUint64(tfhd.Data[8:], remap(binary.BigEndian.Uint64(tfhd.Data[8:])))
+				}
+			}
+		}
+	}
+
+	dst := make([]byte, 0, position)
+	for _, b := range boxes {
+		dst = b.box.AppendTo(dst)
+	}
+
+	return dst, nil
+}
@@ -0,0 +1,145 @@
+// Package tag writes metadata tags into audio files: ID3v2.4 tags into MP3 files, iTunes-style 'ilst' boxes into
+// MP4/M4A files, Vorbis comments into Ogg Opus/Vorbis files, and Matroska tags into WebM files.
+package tag
+
+import (
+	"bytes"
+	"errors"
+	"github.com/lithdew/youtube"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Tags describe a piece of audio.
+type Tags struct {
+	Title  string
+	Artist string
+	Album  string
+
+	// The date the audio was released on. Only its year is written should its month and day both be 1.
+	Date time.Time
+
+	// URL of the web page the audio was sourced from.
+	URL string
+
+	// Encoded JPEG or PNG image used as the front cover.
+	Cover []byte
+}
+
+// FromStreams derives tags from the metadata of a stream. The title, artist, and album are taken from the stream's
+// track info, with featured artists and version descriptors appended to the title. The track info is read from the
+// stream's music metadata rows, or otherwise from the description YouTube generates for tracks provided to it, or
+// otherwise from the stream's title, in which case the stream's author, stripped of suffixes such as ' - Topic', is
+// used as the artist should the title not name one. The date the track was released on, or otherwise the date the
+// stream was published on, is used as the release date. The cover is left empty, and may be populated with the contents
+// of the stream's best thumbnail.
+func FromStreams(s youtube.Streams) Tags {
+	info := s.TrackInfo()
+
+	t := Tags{
+		Title:  info.Track,
+		Artist: info.Artist,
+		Album:  info.Album,
+		Date:   info.Released,
+		URL:    youtube.VideoRef{ID: s.ID()}.URL(),
+	}
+
+	if len(info.Featured) > 0 {
+		t.Title += " (feat. " + strings.Join(info.Featured, ", ") + ")"
+	}
+
+	for _, version := range info.Versions {
+		t.Title += " (" + version + ")"
+	}
+
+	if t.Date.IsZero() {
+		t.Date = s.PublishDate()
+	}
+
+	return t
+}
+
+// date formats the release date of the tags, or returns an empty string if unknown.
+func (t Tags) date() string {
+	switch {
+	case t.Date.IsZero():
+		return ""
+	case t.Date.Month() == time.January && t.Date.Day() == 1:
+		return t.Date.Format("2006")
+	default:
+		return t.Date.Format("2006-01-02")
+	}
+}
+
+// cover returns the MIME type and dimensions of the cover.
+func (t Tags) cover() (mime string, width, height int) {
+	mime = http.DetectContentType(t.Cover)
+
+	if config, _, err := image.DecodeConfig(bytes.NewReader(t.Cover)); err == nil {
+		width, height = config.Width, config.Height
+	}
+
+	return mime, width, height
+}
+
+// WriteFile writes tags t into the audio file at filename, replacing any tags the file already has. The format of
+// the file is detected from its contents. The file is rewritten to a temporary file in the same directory, which
+// then replaces the original file.
+func WriteFile(filename string, t Tags) error {
+	buf, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	if buf, err = Apply(buf, t); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(filename), filepath.Base(filename)+".*")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(buf); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if info, err := os.Stat(filename); err == nil {
+		os.Chmod(tmp.Name(), info.Mode())
+	}
+
+	return os.Rename(tmp.Name(), filename)
+}
+
+// Apply returns the contents of the audio file buf with tags t written into it, replacing any tags the file already
+// has.
+func Apply(buf []byte, t Tags) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(buf, []byte("OggS")):
+		return applyOgg(buf, t)
+	case len(buf) >= 8 && (string(buf[4:8]) == "ftyp" || string(buf[4:8]) == "moov"):
+		return applyMP4(buf, t)
+	case bytes.HasPrefix(buf, []byte{0x1a, 0x45, 0xdf, 0xa3}):
+		return applyWebM(buf, t)
+	case bytes.HasPrefix(buf, []byte("ID3")) || len(buf) >= 2 && buf[0] == 0xff && buf[1]&0xe0 == 0xe0:
+		return applyID3(buf, t)
+	case len(buf) == 0:
+		return nil, errors.New("file is empty")
+	default:
+		return nil, errors.New("unsupported file format")
+	}
+}
@@ -0,0 +1,298 @@
+package tag
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"github.com/lithdew/youtube"
+	"github.com/lithdew/youtube/bmff"
+	"github.com/lithdew/youtube/ebml"
+	"github.com/lithdew/youtube/ogg"
+	"github.com/stretchr/testify/require"
+	"image"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testTags(t *testing.T) Tags {
+	var cover bytes.Buffer
+	require.NoError(t, png.Encode(&cover, image.NewGray(image.Rect(0, 0, 4, 3))))
+
+	return Tags{
+		Title:  "Animus Vox",
+		Artist: "The Glitch Mob",
+		Album:  "Drink the Sea",
+		Date:   time.Date(2010, 6, 17, 0, 0, 0, 0, time.UTC),
+		URL:    "https://www.youtube.com/watch?v=pAsDzfbLM8Y",
+		Cover:  cover.Bytes(),
+	}
+}
+
+func TestFromStreams(t *testing.T) {
+	buf, err := ioutil.ReadFile("../testdata/player_response.json")
+	require.NoError(t, err)
+
+	streams, err := youtube.NewStreamsFromJSON(buf)
+	require.NoError(t, err)
+
+	tags := FromStreams(streams)
+	require.Equal(t, "Animus Vox", tags.Title)
+	require.Equal(t, "The Glitch Mob", tags.Artist)
+	require.Equal(t, "Drink The Sea", tags.Album)
+	require.Equal(t, "2010-06-17", tags.date())
+	require.Equal(t, "https://www.youtube.com/watch?v=pAsDzfbLM8Y", tags.URL)
+
+	require.Equal(t, "2010", Tags{Date: time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC)}.date())
+	require.Equal(t, "", Tags{}.date())
+}
+
+func TestID3(t *testing.T) {
+	tags := testTags(t)
+
+	mp3 := []byte{0xff, 0xfb, 0x90, 0x64, 1, 2, 3, 4}
+
+	buf, err := Apply(mp3, tags)
+	require.NoError(t, err)
+	require.True(t, bytes.HasSuffix(buf, mp3))
+
+	tag := buf[:len(buf)-len(mp3)]
+	require.Equal(t, []byte{'I', 'D', '3', 4, 0, 0}, tag[:6])
+	require.EqualValues(t, len(tag)-10, readSynchsafe(tag[6:10]))
+
+	frames := make(map[string][]byte)
+	for body := tag[10:]; len(body) > 0; {
+		size := readSynchsafe(body[4:8])
+		frames[string(body[:4])] = body[10 : 10+size]
+		body = body[10+size:]
+	}
+
+	require.Equal(t, "\x03Animus Vox", string(frames["TIT2"]))
+	require.Equal(t, "\x03The Glitch Mob", string(frames["TPE1"]))
+	require.Equal(t, "\x03Drink the Sea", string(frames["TALB"]))
+	require.Equal(t, "\x032010-06-17", string(frames["TDRC"]))
+	require.Equal(t, tags.URL, string(frames["WOAS"]))
+	require.Equal(t, append([]byte("\x03image/png\x00\x03\x00"), tags.Cover...), frames["APIC"])
+
+	// Tagging a file again replaces its existing tag.
+
+	tags.Title = "Fortune Days"
+
+	retagged, err := Apply(buf, tags)
+	require.NoError(t, err)
+	require.Equal(t, append(ID3(tags), mp3...), retagged)
+}
+
+func TestMP4(t *testing.T) {
+	tags := testTags(t)
+
+	stco := []byte{0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0}
+
+	moov := bmff.New("moov", nil,
+		bmff.New("trak", nil, bmff.New("mdia", nil, bmff.New("minf", nil, bmff.New("stbl", nil, bmff.New("stco", stco))))),
+	)
+
+	ftyp := bmff.New("ftyp", []byte("M4A \x00\x00\x00\x00M4A isom"))
+
+	// Use a 64-bit size for the mdat box, which is re-encoded with a 32-bit size when tagged.
+
+	binary.BigEndian.PutUint32(stco[8:], uint32(ftyp.Size()+moov.Size()+16+2))
+
+	input := moov.AppendTo(ftyp.AppendTo(nil))
+	input = append(input, 0, 0, 0, 1, 'm', 'd', 'a', 't', 0, 0, 0, 0, 0, 0, 0, 22)
+	input = append(input, "abcdef"...)
+
+	buf, err := Apply(input, tags)
+	require.NoError(t, err)
+
+	boxes, err := bmff.ParseAll(buf)
+	require.NoError(t, err)
+	require.Len(t, boxes, 3)
+
+	ilst := boxes[1].Find("udta", "meta", "ilst")
+	require.NotNil(t, ilst)
+
+	items := make(map[string][]byte)
+	for _, item := range ilst.Children {
+		data, _, err := bmff.Parse(item.Data)
+		require.NoError(t, err)
+		require.Equal(t, "data", data.Type)
+		items[item.Type] = data.Data
+	}
+
+	require.Equal(t, "\x00\x00\x00\x01\x00\x00\x00\x00Animus Vox", string(items["\xa9nam"]))
+	require.Equal(t, "\x00\x00\x00\x01\x00\x00\x00\x00The Glitch Mob", string(items["\xa9ART"]))
+	require.Equal(t, "\x00\x00\x00\x01\x00\x00\x00\x00Drink the Sea", string(items["\xa9alb"]))
+	require.Equal(t, "\x00\x00\x00\x01\x00\x00\x00\x002010-06-17", string(items["\xa9day"]))
+	require.Equal(t, "\x00\x00\x00\x01\x00\x00\x00\x00"+tags.URL, string(items["\xa9cmt"]))
+	require.Equal(t, append([]byte{0, 0, 0, 14, 0, 0, 0, 0}, tags.Cover...), items["covr"])
+
+	// The chunk offset still points to the same media data.
+
+	offset := binary.BigEndian.Uint32(boxes[1].Find("trak", "mdia", "minf", "stbl", "stco").Data[8:])
+	require.Equal(t, "cdef", string(buf[offset:]))
+
+	// Tagging a file again replaces its existing tags.
+
+	retagged, err := Apply(buf, tags)
+	require.NoError(t, err)
+	require.Equal(t, buf, retagged)
+}
+
+func TestOgg(t *testing.T) {
+	tags := testTags(t)
+
+	head := []byte("OpusHead\x01\x02\x38\x01\x80\xbb\x00\x00\x00\x00\x00")
+	packets := [][]byte{{0xf8, 1}, {0xf8, 2}, {0xf8, 3}}
+
+	var input bytes.Buffer
+
+	w := ogg.NewWriter(&input, 7)
+	require.NoError(t, w.WritePacket(head, 0))
+	require.NoError(t, w.Flush())
+	require.NoError(t, w.WritePacket([]byte("OpusTags\x06\x00\x00\x00vendor\x00\x00\x00\x00"), 0))
+	require.NoError(t, w.Flush())
+	for i, packet := range packets {
+		require.NoError(t, w.WritePacket(packet, uint64(i+1)*960))
+		require.NoError(t, w.Flush())
+	}
+	require.NoError(t, w.Close())
+
+	buf, err := Apply(input.Bytes(), tags)
+	require.NoError(t, err)
+
+	r := ogg.NewReader(bytes.NewReader(buf))
+
+	packet, page, err := r.ReadPacket()
+	require.NoError(t, err)
+	require.Equal(t, head, packet)
+	require.EqualValues(t, 7, page.Serial)
+
+	packet, _, err = r.ReadPacket()
+	require.NoError(t, err)
+
+	comments := VorbisComments(tags)
+	require.True(t, bytes.HasPrefix(packet, []byte("OpusTags\x06\x00\x00\x00vendor")))
+	require.True(t, bytes.Contains(packet, []byte("TITLE=Animus Vox")))
+	require.True(t, bytes.HasSuffix(packet, []byte(comments[len(comments)-1])))
+
+	for i, expected := range packets {
+		packet, page, err := r.ReadPacket()
+		require.NoError(t, err)
+		require.Equal(t, expected, packet)
+		require.EqualValues(t, (i+1)*960, page.Granule)
+	}
+
+	_, _, err = r.ReadPacket()
+	require.Equal(t, io.EOF, err)
+
+	// The cover is encoded as a FLAC picture block.
+
+	picture := comments[len(comments)-1]
+	require.True(t, strings.HasPrefix(picture, "METADATA_BLOCK_PICTURE="))
+
+	block, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(picture, "METADATA_BLOCK_PICTURE="))
+	require.NoError(t, err)
+	require.Equal(t, "image/png", string(block[8:17]))
+	require.EqualValues(t, 4, binary.BigEndian.Uint32(block[21:]))
+	require.EqualValues(t, 3, binary.BigEndian.Uint32(block[25:]))
+	require.Equal(t, tags.Cover, block[41:])
+}
+
+func TestWebM(t *testing.T) {
+	tags := testTags(t)
+
+	info := ebml.AppendElement(nil, ebml.IDInfo, ebml.AppendUint(nil, ebml.IDTimecodeScale, 1000000))
+	stale := ebml.AppendElement(nil, ebml.IDTags, make([]byte, 200))
+	cluster := ebml.AppendElement(nil, ebml.IDCluster, ebml.AppendUint(nil, ebml.IDTimecode, 0))
+
+	segment := append(append(append([]byte(nil), info...), stale...), cluster...)
+
+	input := ebml.AppendElement(nil, ebml.IDHeader, ebml.AppendElement(nil, 0x4282, []byte("webm")))
+	input = ebml.AppendVint(ebml.AppendID(input, ebml.IDSegment), uint64(len(segment)), 8)
+	input = append(input, segment...)
+
+	buf, err := Apply(input, tags)
+	require.NoError(t, err)
+
+	elements, err := ebml.ParseAll(buf)
+	require.NoError(t, err)
+	require.Len(t, elements, 2)
+	require.EqualValues(t, ebml.IDSegment, elements[1].ID)
+
+	// Existing tags are overwritten with padding, and the cluster is left at the same position.
+
+	children, err := elements[1].Children()
+	require.NoError(t, err)
+	require.Len(t, children, 4)
+	require.EqualValues(t, ebml.IDVoid, children[1].ID)
+	require.EqualValues(t, len(stale), children[1].Size())
+	require.Equal(t, cluster, children[2].AppendTo(nil))
+	require.Equal(t, MatroskaTags(tags), children[3].AppendTo(nil))
+
+	simple := make(map[string]string)
+
+	tag, err := ebml.ParseAll(children[3].Data)
+	require.NoError(t, err)
+
+	fields, err := tag[0].Children()
+	require.NoError(t, err)
+
+	for _, field := range fields[1:] {
+		pair, err := field.Children()
+		require.NoError(t, err)
+		simple[string(pair[0].Data)] = string(pair[1].Data)
+	}
+
+	require.Equal(t, map[string]string{
+		"TITLE":         "Animus Vox",
+		"ARTIST":        "The Glitch Mob",
+		"ALBUM":         "Drink the Sea",
+		"DATE_RELEASED": "2010-06-17",
+		"URL":           tags.URL,
+	}, simple)
+
+	// Tagging a file again replaces the tags at the end of its segment.
+
+	tags.Title = "Fortune Days"
+
+	retagged, err := Apply(buf, tags)
+	require.NoError(t, err)
+
+	elements, err = ebml.ParseAll(retagged)
+	require.NoError(t, err)
+
+	retaggedChildren, err := elements[1].Children()
+	require.NoError(t, err)
+	require.Len(t, retaggedChildren, 4)
+	require.Equal(t, children[:3], retaggedChildren[:3])
+	require.Equal(t, MatroskaTags(tags), retaggedChildren[3].AppendTo(nil))
+}
+
+func TestWriteFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "audio.mp3")
+	require.NoError(t, ioutil.WriteFile(filename, []byte{0xff, 0xfb, 0x90, 0x64}, 0644))
+
+	require.NoError(t, WriteFile(filename, Tags{Title: "Animus Vox"}))
+
+	buf, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, append(ID3(Tags{Title: "Animus Vox"}), 0xff, 0xfb, 0x90, 0x64), buf)
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	require.Error(t, WriteFile(filename+".missing", Tags{}))
+	require.NoError(t, ioutil.WriteFile(filename, []byte("not audio"), 0644))
+	require.Error(t, WriteFile(filename, Tags{}))
+}
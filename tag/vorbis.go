@@ -0,0 +1,176 @@
+package tag
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/lithdew/youtube/ogg"
+	"io"
+)
+
+// VorbisComments encodes tags t as Vorbis comments of the form 'KEY=value'. The cover is encoded as a FLAC picture
+// block under the key METADATA_BLOCK_PICTURE.
+func VorbisComments(t Tags) []string {
+	var comments []string
+
+	for _, field := range []struct {
+		key, value string
+	}{
+		{"TITLE", t.Title},
+		{"ARTIST", t.Artist},
+		{"ALBUM", t.Album},
+		{"DATE", t.date()},
+		{"COMMENT", t.URL},
+	} {
+		if field.value != "" {
+			comments = append(comments, field.key+"="+field.value)
+		}
+	}
+
+	if len(t.Cover) > 0 {
+		mime, width, height := t.cover()
+
+		var block []byte
+		block = appendUint32BE(block, 3) // Front cover.
+		block = appendUint32BE(block, uint32(len(mime)))
+		block = append(block, mime...)
+		block = appendUint32BE(block, 0) // Description.
+		block = appendUint32BE(block, uint32(width))
+		block = appendUint32BE(block, uint32(height))
+		block = appendUint32BE(block, 0) // Color depth.
+		block = appendUint32BE(block, 0) // Number of colors used by indexed-color pictures.
+		block = appendUint32BE(block, uint32(len(t.Cover)))
+		block = append(block, t.Cover...)
+
+		comments = append(comments, "METADATA_BLOCK_PICTURE="+base64.StdEncoding.EncodeToString(block))
+	}
+
+	return comments
+}
+
+// applyOgg replaces the comment header of the Ogg Opus or Ogg Vorbis file buf with one holding tags t. The pages
+// holding the file's headers are rewritten, and all pages following them are renumbered.
+func applyOgg(buf []byte, t Tags) ([]byte, error) {
+	r := ogg.NewReader(bytes.NewReader(buf))
+
+	packet, page, err := r.ReadPacket()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read identification header: %w", err)
+	}
+
+	ident := append([]byte{}, packet...)
+	serial := page.Serial
+
+	var magic, framing []byte
+
+	switch {
+	case bytes.HasPrefix(ident, []byte("OpusHead")):
+		magic = []byte("OpusTags")
+	case bytes.HasPrefix(ident, []byte("\x01vorbis")):
+		magic, framing = []byte("\x03vorbis"), []byte{0x01}
+	default:
+		return nil, errors.New("ogg file is neither opus nor vorbis")
+	}
+
+	if packet, _, err = r.ReadPacket(); err != nil {
+		return nil, fmt.Errorf("failed to read comment header: %w", err)
+	}
+
+	if !bytes.HasPrefix(packet, magic) || len(packet) < len(magic)+4 {
+		return nil, errors.New("comment header is malformed")
+	}
+
+	// Keep the vendor string of the existing comment header.
+
+	vendor := packet[len(magic):]
+	size := binary.LittleEndian.Uint32(vendor)
+	if uint64(size) > uint64(len(vendor)-4) {
+		return nil, errors.New("comment header vendor is truncated")
+	}
+	vendor = vendor[4 : 4+size]
+
+	comment := append([]byte{}, magic...)
+	comment = appendUint32LE(comment, uint32(len(vendor)))
+	comment = append(comment, vendor...)
+
+	comments := VorbisComments(t)
+
+	comment = appendUint32LE(comment, uint32(len(comments)))
+	for _, c := range comments {
+		comment = appendUint32LE(comment, uint32(len(c)))
+		comment = append(comment, c...)
+	}
+	comment = append(comment, framing...)
+
+	// Vorbis carries a third setup header, which shares its page with the comment header.
+
+	var setup []byte
+
+	if framing != nil {
+		if packet, _, err = r.ReadPacket(); err != nil {
+			return nil, fmt.Errorf("failed to read setup header: %w", err)
+		}
+		setup = append([]byte{}, packet...)
+	}
+
+	if r.Buffered() {
+		return nil, errors.New("audio data does not begin on a fresh page")
+	}
+
+	var out bytes.Buffer
+	out.Grow(len(buf) + len(comment))
+
+	w := ogg.NewWriter(&out, serial)
+
+	if err := w.WritePacket(ident, 0); err != nil {
+		return nil, err
+	}
+
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+
+	if err := w.WritePacket(comment, 0); err != nil {
+		return nil, err
+	}
+
+	if setup != nil {
+		if err := w.WritePacket(setup, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	for {
+		page, err := r.ReadPage()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if binary.LittleEndian.Uint32(page[14:18]) != serial {
+			return nil, errors.New("multiplexed ogg files are not supported")
+		}
+
+		if err := w.WritePage(page); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
+
+func appendUint32BE(dst []byte, v uint32) []byte {
+	return append(dst, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+func appendUint32LE(dst []byte, v uint32) []byte {
+	return append(dst, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
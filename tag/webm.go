@@ -0,0 +1,113 @@
+package tag
+
+import (
+	"errors"
+	"fmt"
+	"github.com/lithdew/youtube/ebml"
+)
+
+// MatroskaTags encodes tags t as a Matroska 'Tags' element holding a single tag that targets the whole file. The
+// cover is not encoded, as Matroska stores pictures as attachments rather than as tags.
+func MatroskaTags(t Tags) []byte {
+	tag := ebml.AppendElement(nil, ebml.IDTargets, nil)
+
+	for _, field := range []struct {
+		name, value string
+	}{
+		{"TITLE", t.Title},
+		{"ARTIST", t.Artist},
+		{"ALBUM", t.Album},
+		{"DATE_RELEASED", t.date()},
+		{"URL", t.URL},
+	} {
+		if field.value == "" {
+			continue
+		}
+
+		var simple []byte
+		simple = ebml.AppendElement(simple, ebml.IDTagName, []byte(field.name))
+		simple = ebml.AppendElement(simple, ebml.IDTagString, []byte(field.value))
+
+		tag = ebml.AppendElement(tag, ebml.IDSimpleTag, simple)
+	}
+
+	return ebml.AppendElement(nil, ebml.IDTags, ebml.AppendElement(nil, ebml.IDTag, tag))
+}
+
+// applyWebM writes tags t into the segment of the WebM file buf. The tags are appended to the end of the segment such
+// that the positions of all elements before them, which SeekHead and Cues elements refer to, are left unchanged.
+// Existing tags at the end of the segment are replaced, and existing tags elsewhere are overwritten with padding.
+func applyWebM(buf []byte, t Tags) ([]byte, error) {
+	_, n, err := ebml.Parse(buf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ebml header: %w", err)
+	}
+
+	id, size, m, err := ebml.ParseHeader(buf[n:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read segment: %w", err)
+	}
+
+	if id != ebml.IDSegment {
+		return nil, errors.New("webm file has no segment")
+	}
+
+	width := m - ebml.IDWidth(id)
+
+	start := n + m
+	end := len(buf)
+
+	if size != ebml.UnknownSize {
+		if size > uint64(len(buf)-start) {
+			return nil, errors.New("segment is truncated")
+		}
+		end = start + int(size)
+	}
+
+	body := make([]byte, 0, end-start)
+
+	for offset := start; offset < end; {
+		e, k, err := ebml.Parse(buf[offset:end])
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case e.ID != ebml.IDTags:
+			body = append(body, buf[offset:offset+k]...)
+		case offset+k < end:
+			body = appendVoid(body, k)
+		}
+
+		offset += k
+	}
+
+	body = append(body, MatroskaTags(t)...)
+
+	if size != ebml.UnknownSize {
+		size = uint64(len(body))
+		if ebml.SizeWidth(size) > width {
+			return nil, errors.New("segment is too large to be tagged")
+		}
+	}
+
+	dst := make([]byte, 0, n+m+len(body)+len(buf)-end)
+	dst = append(dst, buf[:n]...)
+	dst = ebml.AppendVint(ebml.AppendID(dst, ebml.IDSegment), size, width)
+	dst = append(dst, body...)
+	dst = append(dst, buf[end:]...)
+
+	return dst, nil
+}
+
+// appendVoid appends a 'Void' element that is n bytes long in total to dst.
+func appendVoid(dst []byte, n int) []byte {
+	width := 1
+	for width < 8 && uint64(n-1-width) >= 1<<(7*uint(width))-1 {
+		width++
+	}
+
+	dst = ebml.AppendVint(ebml.AppendID(dst, ebml.IDVoid), uint64(n-1-width), width)
+
+	return append(dst, make([]byte, n-1-width)...)
+}
@@ -1,28 +1,35 @@
 package youtube
 
 import (
-	"errors"
 	"fmt"
-	"github.com/lithdew/bytesutil"
-	"github.com/valyala/fasthttp"
+	"net/url"
 	"regexp"
+	"strings"
 )
 
-var RegexStreamID = regexp.MustCompile(`(?i)([a-z0-9_-]{11})`)
+var RegexStreamID = regexp.MustCompile(`^[a-zA-Z0-9_-]{11}$`)
 
 type StreamID string
 
-func ExtractStreamID(url string) (StreamID, error) {
-	uri := fasthttp.AcquireURI()
-	defer fasthttp.ReleaseURI(uri)
+// ExtractStreamID extracts the stream ID out of a YouTube URL. It accepts URLs pointing to youtu.be, youtube.com,
+// m.youtube.com, music.youtube.com and youtube-nocookie.com with the stream ID specified either through the 'v' query
+// parameter or through a /shorts/, /live/, /embed/, /e/, or /v/ path. A bare stream ID is returned as-is.
+func ExtractStreamID(src string) (StreamID, error) {
+	if id := StreamID(strings.TrimSpace(src)); id.Valid() == nil {
+		return id, nil
+	}
 
-	uri.Parse(nil, bytesutil.Slice(url))
+	u, host, err := parseYouTubeURL(src)
+	if err != nil {
+		return "", err
+	}
 
-	matches := RegexStreamID.FindSubmatch(uri.RequestURI())
-	if matches == nil {
-		return "", errors.New("could not find stream id")
+	id, ok := streamIDFromURL(u, host)
+	if !ok {
+		return "", fmt.Errorf("could not find stream id in url %q", src)
 	}
-	return StreamID(matches[1]), nil
+
+	return id, nil
 }
 
 func (v StreamID) Valid() error {
@@ -31,3 +38,61 @@ func (v StreamID) Valid() error {
 	}
 	return nil
 }
+
+// parseYouTubeURL parses src as a URL, defaulting to https if no scheme is specified, and returns the URL alongside
+// its host stripped of any 'www.', 'm.', or 'music.' subdomain. It errors if the URL does not point to YouTube.
+func parseYouTubeURL(src string) (*url.URL, string, error) {
+	src = strings.TrimSpace(src)
+	if !strings.Contains(src, "://") {
+		src = "https://" + src
+	}
+
+	u, err := url.Parse(src)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse url: %w", err)
+	}
+
+	host := strings.ToLower(u.Hostname())
+	for _, prefix := range [...]string{"www.", "m.", "music."} {
+		host = strings.TrimPrefix(host, prefix)
+	}
+
+	switch host {
+	case "youtube.com", "youtu.be", "youtube-nocookie.com":
+		return u, host, nil
+	}
+
+	return nil, "", fmt.Errorf("url %q does not point to youtube", src)
+}
+
+// streamIDFromURL extracts a valid stream ID out of a YouTube URL whose host has been normalized by parseYouTubeURL.
+func streamIDFromURL(u *url.URL, host string) (StreamID, bool) {
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+
+	if host == "youtu.be" {
+		id := StreamID(segments[0])
+		return id, id.Valid() == nil
+	}
+
+	if id := StreamID(u.Query().Get("v")); id.Valid() == nil {
+		return id, true
+	}
+
+	if len(segments) < 2 {
+		return "", false
+	}
+
+	// Embedded playlists are linked as /embed/videoseries?list=..., where 'videoseries' happens to be 11 characters.
+
+	if segments[1] == "videoseries" {
+		return "", false
+	}
+
+	switch segments[0] {
+	case "shorts", "live", "embed", "e", "v":
+		id := StreamID(segments[1])
+		return id, id.Valid() == nil
+	}
+
+	return "", false
+}
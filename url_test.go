@@ -0,0 +1,61 @@
+package youtube
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestStreamIDValid(t *testing.T) {
+	require.NoError(t, StreamID("pAsDzfbLM8Y").Valid())
+	require.NoError(t, StreamID("_-aB3dEfG9z").Valid())
+
+	require.Error(t, StreamID("").Valid())
+	require.Error(t, StreamID("pAsDzfbLM8").Valid())
+	require.Error(t, StreamID("pAsDzfbLM8Yx").Valid())
+	require.Error(t, StreamID("a-very-long-garbage-string").Valid())
+	require.Error(t, StreamID("pAsDzfb.M8Y").Valid())
+}
+
+func TestExtractStreamID(t *testing.T) {
+	valid := []string{
+		"pAsDzfbLM8Y",
+		"https://www.youtube.com/watch?v=pAsDzfbLM8Y",
+		"https://youtube.com/watch?v=pAsDzfbLM8Y&feature=share",
+		"http://www.youtube.com/watch?feature=player_embedded&v=pAsDzfbLM8Y",
+		"https://www.youtube.com/watch?v=pAsDzfbLM8Y&list=PL25785A39039615CF&index=2",
+		"www.youtube.com/watch?v=pAsDzfbLM8Y",
+		"https://m.youtube.com/watch?v=pAsDzfbLM8Y",
+		"https://music.youtube.com/watch?v=pAsDzfbLM8Y&feature=share",
+		"https://youtu.be/pAsDzfbLM8Y",
+		"youtu.be/pAsDzfbLM8Y?t=42",
+		"https://www.youtube.com/shorts/pAsDzfbLM8Y",
+		"https://www.youtube.com/live/pAsDzfbLM8Y?si=abc",
+		"https://www.youtube.com/embed/pAsDzfbLM8Y?start=10",
+		"https://www.youtube-nocookie.com/embed/pAsDzfbLM8Y",
+		"https://www.youtube.com/v/pAsDzfbLM8Y?version=3",
+		"https://www.youtube.com/e/pAsDzfbLM8Y",
+		"  https://WWW.YOUTUBE.COM/watch?v=pAsDzfbLM8Y  ",
+	}
+
+	for _, src := range valid {
+		id, err := ExtractStreamID(src)
+		require.NoError(t, err, src)
+		require.EqualValues(t, "pAsDzfbLM8Y", id, src)
+	}
+
+	invalid := []string{
+		"",
+		"a-very-long-garbage-string",
+		"https://www.youtube.com/",
+		"https://www.youtube.com/watch?v=short",
+		"https://www.youtube.com/playlist?list=PL25785A39039615CF",
+		"https://www.youtube.com/channel/UCHc3wFJ5hMdJMBJS4QhJPFA",
+		"https://www.example.com/watch?v=pAsDzfbLM8Y",
+		"https://youtu.be/",
+	}
+
+	for _, src := range invalid {
+		_, err := ExtractStreamID(src)
+		require.Error(t, err, src)
+	}
+}